@@ -9,9 +9,11 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -23,13 +25,14 @@ import (
 	"github.com/vishen/go-chromecast/cast"
 	pb "github.com/vishen/go-chromecast/cast/proto"
 	"github.com/vishen/go-chromecast/playlists"
+	"github.com/vishen/go-chromecast/podcast"
 	"github.com/vishen/go-chromecast/storage"
-	"path/filepath"
 )
 
 var (
-	// Global request id
-	requestID int
+	// Global request id, shared by every application so it is
+	// incremented atomically.
+	requestID atomic.Int64
 	_         App = &Application{}
 )
 
@@ -50,6 +53,16 @@ type PlayedItem struct {
 	ContentID string `json:"content_id"`
 	Started   int64  `json:"started"`
 	Finished  int64  `json:"finished"`
+	// Position is the last known position in seconds reported by the
+	// device, and Duration the length of the media if it is known.
+	Position float32 `json:"position,omitempty"`
+	Duration float32 `json:"duration,omitempty"`
+	// The device the media was last played on.
+	DeviceUUID string `json:"device_uuid,omitempty"`
+	DeviceName string `json:"device_name,omitempty"`
+	// Watched is set when the media has been marked as watched, whether
+	// or not it was played to the end.
+	Watched bool `json:"watched,omitempty"`
 }
 
 type CastMessageFunc func(*pb.CastMessage)
@@ -59,6 +72,7 @@ type App interface {
 	SetIface(*net.Interface)
 	SetDebug(bool)
 	SetCacheDisabled(bool)
+	SetStore(storage.Store)
 	SetConnectionRetries(int)
 	SetServerPort(int)
 	Start(addr string, port int) error
@@ -75,17 +89,29 @@ type App interface {
 	Seek(value int) error
 	SeekFromStart(value int) error
 	SeekToTime(value float32) error
+	SeekToTimePaused(value float32) error
 	Skipad() error
 	Load(filenameOrUrl string, startTime int, contentType string, transcode, detach, forceDetach bool) error
-	QueueLoad(filenames []string, contentType string, transcode bool) error
+	LoadMedia(media cast.MediaItem, startTime int, autoplay bool, activeTrackIds ...int) error
+	ServeFile(filename, contentType string, transcode bool) (cast.MediaItem, error)
+	MediaStatus() (*cast.Media, error)
+	QueueLoad(filenames []string, startTime int, contentType string, transcode bool) error
+	QueueItems() ([]cast.QueueItem, error)
+	PlayRadio(stationURL, name string, onTitle func(title string)) error
+	PlayEpisode(feed *podcast.Feed, episode podcast.Episode, startTime int) error
 	Transcode(contentType string, command string, args ...string) error
 	Next() error
 	Previous() error
 	SetVolume(value float32) error
 	SetMuted(value bool) error
+	SetVolumeSettings(s VolumeSettings)
+	VolumeSettings() VolumeSettings
+	SetPlaybackRate(rate float32) error
+	SetPlaylist(name string)
 	Slideshow(filenames []string, duration int, repeat bool) error
 	AddMessageFunc(f CastMessageFunc)
 	PlayedItems() map[string]PlayedItem
+	ResumePosition(contentID string, threshold time.Duration) (position int, finished bool)
 	PlayableMediaType(filename string) bool
 }
 
@@ -95,8 +121,13 @@ type Application struct {
 
 	// Device name override (originating e.g. from mdns lookup).
 	deviceNameOverride string
+	// The device as it was found, which played items are recorded
+	// against.
+	deviceUUID string
+	deviceName string
 
 	// Internal mapping of request id to result channel
+	resultMu      sync.Mutex
 	resultChanMap map[int]chan *pb.CastMessage
 
 	messageMu sync.Mutex
@@ -109,8 +140,11 @@ type Application struct {
 
 	// Current values from the chromecast.
 	application *cast.Application // It is possible that there is no current application, can happen for google home.
-	media       *cast.Media
 	info        *cast.DeviceInfo
+	// mediaMu guards media and volumeMedia, which are updated from the
+	// messages received from the device as well as by requests.
+	mediaMu sync.Mutex
+	media   *cast.Media
 	// There seems to be two different volumes returned from the chromecast,
 	// one for the receiver and one for the playing media. It looks we update
 	// the receiver volume from go-chromecast, so we should use that one. But
@@ -127,9 +161,19 @@ type Application struct {
 	mediaFinished  chan bool
 	mediaFilenames []string
 
+	// The internet radio station being proxied to the device.
+	radioMu   sync.Mutex
+	radio     *radioStation
+	radioOnce sync.Once
+
+	playedItemsMu sync.Mutex
 	playedItems   map[string]PlayedItem
 	cacheDisabled bool
-	cache         *storage.Storage
+	store         storage.Store
+	playState     playState
+	// savePlayedMu keeps played items being saved in the order they
+	// change.
+	savePlayedMu sync.Mutex
 
 	// Number of connection retries to try before returning
 	// an error.
@@ -139,6 +183,11 @@ type Application struct {
 	skipadSleep time.Duration
 	// Number of times to try to skip an ad
 	skipadRetries int
+
+	// The limits, step and presets of the volume.
+	volumeSettings VolumeSettings
+	// The playback rate chosen for the media loaded by this application.
+	rate playbackRate
 }
 
 type ApplicationOption func(*Application)
@@ -167,6 +216,12 @@ func WithCacheDisabled(cacheDisabled bool) ApplicationOption {
 	}
 }
 
+func WithStore(store storage.Store) ApplicationOption {
+	return func(a *Application) {
+		a.SetStore(store)
+	}
+}
+
 func WithConnection(conn cast.Conn) ApplicationOption {
 	return func(a *Application) {
 		a.SetConn(conn)
@@ -197,16 +252,31 @@ func WithDeviceNameOverride(deviceName string) ApplicationOption {
 	}
 }
 
+// WithVolumeSettings sets the limits, step and presets of the volume.
+func WithVolumeSettings(s VolumeSettings) ApplicationOption {
+	return func(a *Application) {
+		a.SetVolumeSettings(s)
+	}
+}
+
+// WithDevice sets the device that played media is recorded as played on.
+func WithDevice(uuid, name string) ApplicationOption {
+	return func(a *Application) {
+		a.SetDevice(uuid, name)
+	}
+}
+
 func NewApplication(opts ...ApplicationOption) *Application {
 	a := &Application{
 		conn:              cast.NewConnection(),
 		resultChanMap:     map[int]chan *pb.CastMessage{},
 		messageChan:       make(chan *pb.CastMessage),
 		playedItems:       map[string]PlayedItem{},
-		cache:             storage.NewStorage(),
+		store:             storage.NewFileStore(""),
 		connectionRetries: 5,
 		skipadSleep:       2 * time.Second,
 		skipadRetries:     30,
+		volumeSettings:    DefaultVolumeSettings,
 	}
 
 	// Apply options
@@ -228,6 +298,7 @@ func (a *Application) SetConnectionRetries(connectionRetries int) {
 	a.connectionRetries = connectionRetries
 }
 func (a *Application) SetCacheDisabled(cacheDisabled bool) { a.cacheDisabled = cacheDisabled }
+func (a *Application) SetStore(store storage.Store)        { a.store = store }
 func (a *Application) SetIface(iface *net.Interface)       { a.iface = iface }
 
 func (a *Application) SetSkipadSleep(sleep time.Duration) { a.skipadSleep = sleep }
@@ -237,10 +308,33 @@ func (a *Application) SetDeviceNameOverride(deviceName string) {
 	a.deviceNameOverride = deviceName
 }
 
+func (a *Application) SetDevice(uuid, name string) {
+	a.deviceUUID = uuid
+	a.deviceName = name
+}
+
 func (a *Application) App() *cast.Application { return a.application }
-func (a *Application) Media() *cast.Media     { return a.media }
+func (a *Application) Media() *cast.Media     { return a.currentMedia() }
 func (a *Application) Volume() *cast.Volume   { return a.volumeReceiver }
 
+// currentMedia returns the last known status of the media.
+func (a *Application) currentMedia() *cast.Media {
+	a.mediaMu.Lock()
+	defer a.mediaMu.Unlock()
+	return a.media
+}
+
+// setMedia records the status of the media, which is nil when there is
+// none.
+func (a *Application) setMedia(media *cast.Media) {
+	a.mediaMu.Lock()
+	defer a.mediaMu.Unlock()
+	a.media = media
+	if media != nil {
+		a.volumeMedia = &media.Volume
+	}
+}
+
 func (a *Application) AddMessageFunc(f CastMessageFunc) {
 	a.messageMu.Lock()
 	defer a.messageMu.Unlock()
@@ -284,7 +378,10 @@ func (a *Application) recvMessages() {
 	for msg := range a.conn.MsgChan() {
 		requestID, err := jsonparser.GetInt([]byte(*msg.PayloadUtf8), "requestId")
 		if err == nil {
-			if resultChan, ok := a.resultChanMap[int(requestID)]; ok {
+			a.resultMu.Lock()
+			resultChan, ok := a.resultChanMap[int(requestID)]
+			a.resultMu.Unlock()
+			if ok {
 				resultChan <- msg
 				// Relay the event to any user specified message funcs.
 				a.messageChan <- msg
@@ -302,6 +399,7 @@ func (a *Application) recvMessages() {
 			resp := cast.MediaStatusResponse{}
 			if err := json.Unmarshal(messageBytes, &resp); err == nil {
 				for _, status := range resp.Status {
+					a.keepPlaybackRate(a.trackMediaStatus(status), status)
 					// The LoadingItemId is only set when there is a playlist and there
 					// is an item being loaded to play next.
 					if status.IdleReason == "FINISHED" && status.LoadingItemId == 0 {
@@ -333,7 +431,8 @@ func (a *Application) recvMessages() {
 			}
 		case "CLOSE":
 			a.MediaFinished()
-			a.application, a.media, a.volumeReceiver = nil, nil, nil
+			a.application, a.volumeReceiver = nil, nil
+			a.setMedia(nil)
 		}
 		// Relay the event to any user specified message funcs.
 		a.messageChan <- msg
@@ -366,20 +465,48 @@ func (a *Application) loadPlayedItems() error {
 		return nil
 	}
 
-	b, err := a.cache.Load("application")
-	if err != nil || len(b) == 0 {
+	keys, err := a.store.Keys(storage.BucketPlayedItems, "")
+	if err != nil {
 		return nil
 	}
-	return json.Unmarshal(b, &a.playedItems)
+
+	a.playedItemsMu.Lock()
+	defer a.playedItemsMu.Unlock()
+	for _, k := range keys {
+		b, err := a.store.Load(storage.BucketPlayedItems, k)
+		if err != nil || len(b) == 0 {
+			continue
+		}
+		pi := PlayedItem{}
+		if err := json.Unmarshal(b, &pi); err != nil {
+			continue
+		}
+		a.playedItems[k] = pi
+	}
+	return nil
 }
 
-func (a *Application) writePlayedItems() error {
+// savePlayedItem saves the latest state of the played item. Saves are
+// serialised so an older state never overwrites a newer one.
+func (a *Application) savePlayedItem(contentID string) error {
 	if a.cacheDisabled {
 		return nil
 	}
 
-	playedItemsJson, _ := json.Marshal(a.playedItems)
-	return a.cache.Save("application", playedItemsJson)
+	a.savePlayedMu.Lock()
+	defer a.savePlayedMu.Unlock()
+
+	a.playedItemsMu.Lock()
+	playedItemJson, _ := json.Marshal(a.playedItems[contentID])
+	a.playedItemsMu.Unlock()
+
+	if err := a.store.Save(storage.BucketPlayedItems, contentID, playedItemJson); err != nil {
+		// Most callers are handling device messages and have nobody to
+		// return the error to, so make sure it is seen.
+		log.WithField("package", "application").WithError(err).Warn("unable to save played item")
+		return err
+	}
+	return nil
 }
 
 func (a *Application) Update() error {
@@ -430,8 +557,8 @@ func (a *Application) updateMediaStatus() error {
 		return err
 	}
 	for _, media := range mediaStatus.Status {
-		a.media = &media
-		a.volumeMedia = &media.Volume
+		a.setMedia(&media)
+		a.trackMediaStatus(media)
 	}
 	return nil
 }
@@ -448,7 +575,7 @@ func (a *Application) Close(stopMedia bool) error {
 }
 
 func (a *Application) Status() (*cast.Application, *cast.Media, *cast.Volume) {
-	return a.application, a.media, a.volumeReceiver
+	return a.application, a.currentMedia(), a.volumeReceiver
 }
 
 func (a *Application) Info() (*cast.DeviceInfo, error) {
@@ -471,30 +598,33 @@ func (a *Application) Info() (*cast.DeviceInfo, error) {
 }
 
 func (a *Application) Pause() error {
-	if a.media == nil {
+	media := a.currentMedia()
+	if media == nil {
 		return ErrNoMediaPause
 	}
 	return a.sendMediaRecv(&cast.MediaHeader{
 		PayloadHeader:  cast.PauseHeader,
-		MediaSessionId: a.media.MediaSessionId,
+		MediaSessionId: media.MediaSessionId,
 	})
 }
 
 func (a *Application) Unpause() error {
-	if a.media == nil {
+	media := a.currentMedia()
+	if media == nil {
 		return ErrNoMediaUnpause
 	}
 	return a.sendMediaRecv(&cast.MediaHeader{
 		PayloadHeader:  cast.PlayHeader,
-		MediaSessionId: a.media.MediaSessionId,
+		MediaSessionId: media.MediaSessionId,
 	})
 }
 
 func (a *Application) TogglePause() error {
-	if a.media == nil {
+	media := a.currentMedia()
+	if media == nil {
 		return ErrNoMediaTogglePause
 	}
-	switch a.media.PlayerState {
+	switch media.PlayerState {
 	case "PLAYING", "BUFFERING":
 		{
 			return a.Pause()
@@ -507,23 +637,25 @@ func (a *Application) TogglePause() error {
 }
 
 func (a *Application) Skipad() error {
-	if a.media == nil {
+	media := a.currentMedia()
+	if media == nil {
 		return ErrNoMediaSkip
 	}
-	if a.media.CustomData.PlayerState != 1081 {
+	if media.CustomData.PlayerState != 1081 {
 		return ErrNoMediaSkipad
 	}
 
 	var result error
 	MAX_LOOP := a.skipadRetries
-	for a.media.CustomData.PlayerState == 1081 {
+	for media != nil && media.CustomData.PlayerState == 1081 {
 		result = a.sendMediaRecv(&cast.MediaHeader{
 			PayloadHeader:  cast.SkipHeader,
-			MediaSessionId: a.media.MediaSessionId,
+			MediaSessionId: media.MediaSessionId,
 		})
-		// fmt.Printf("Looping because %d\n", a.media.CustomData.PlayerState)
+		// fmt.Printf("Looping because %d\n", media.CustomData.PlayerState)
 		time.Sleep(a.skipadSleep)
 		a.updateMediaStatus()
+		media = a.currentMedia()
 		MAX_LOOP--
 		if MAX_LOOP == 0 {
 			return ErrAdMaxLoop
@@ -533,12 +665,13 @@ func (a *Application) Skipad() error {
 }
 
 func (a *Application) StopMedia() error {
-	if a.media == nil {
+	media := a.currentMedia()
+	if media == nil {
 		return ErrNoMediaStop
 	}
 	return a.sendMediaRecv(&cast.MediaHeader{
 		PayloadHeader:  cast.StopHeader,
-		MediaSessionId: a.media.MediaSessionId,
+		MediaSessionId: media.MediaSessionId,
 	})
 }
 
@@ -547,34 +680,36 @@ func (a *Application) Stop() error {
 }
 
 func (a *Application) Next() error {
-	if a.media == nil {
+	media := a.currentMedia()
+	if media == nil {
 		return ErrNoMediaNext
 	}
 
 	// TODO(vishen): Get the number of queue items, if none, possibly just skip to the end?
 	return a.sendMediaRecv(&cast.QueueUpdate{
 		PayloadHeader:  cast.QueueUpdateHeader,
-		MediaSessionId: a.media.MediaSessionId,
+		MediaSessionId: media.MediaSessionId,
 		Jump:           1,
 	})
 }
 
 func (a *Application) Previous() error {
-	if a.media == nil {
+	media := a.currentMedia()
+	if media == nil {
 		return ErrNoMediaPrevious
 	}
 
 	// TODO(vishen): Get the number of queue items, if none, possibly just jump to beginning?
 	return a.sendMediaRecv(&cast.QueueUpdate{
 		PayloadHeader:  cast.QueueUpdateHeader,
-		MediaSessionId: a.media.MediaSessionId,
+		MediaSessionId: media.MediaSessionId,
 		Jump:           -1,
 	})
 }
 
 func (a *Application) Skip() error {
 
-	if a.media == nil {
+	if a.currentMedia() == nil {
 		return ErrNoMediaSkip
 	}
 
@@ -583,17 +718,22 @@ func (a *Application) Skip() error {
 	// but just returns it?
 	// that might also make a.media == nil checks pointless?
 	a.updateMediaStatus()
+	media := a.currentMedia()
+	if media == nil {
+		return ErrNoMediaSkip
+	}
 
-	v := a.media.CurrentTime - 10
-	if a.media.Media.Duration > 0 {
-		v = a.media.Media.Duration - 10
+	v := media.CurrentTime - 10
+	if media.Media.Duration > 0 {
+		v = media.Media.Duration - 10
 	}
 
 	return a.Seek(int(v))
 }
 
 func (a *Application) Seek(value int) error {
-	if a.media == nil {
+	media := a.currentMedia()
+	if media == nil {
 		return ErrMediaNotYetInitialised
 	}
 
@@ -606,21 +746,21 @@ func (a *Application) Seek(value int) error {
 
 	for _, app := range appsSeekTo {
 		if app == a.application.AppId {
-			absolute := a.media.CurrentTime + float32(value)
+			absolute := media.CurrentTime + float32(value)
 			return a.SeekToTime(absolute)
 		}
 	}
 
 	return a.sendMediaRecv(&cast.MediaHeader{
 		PayloadHeader:  cast.SeekHeader,
-		MediaSessionId: a.media.MediaSessionId,
+		MediaSessionId: media.MediaSessionId,
 		RelativeTime:   float32(value),
 		ResumeState:    "PLAYBACK_START",
 	})
 }
 
 func (a *Application) SeekFromStart(value int) error {
-	if a.media == nil {
+	if a.currentMedia() == nil {
 		return ErrMediaNotYetInitialised
 	}
 
@@ -632,32 +772,53 @@ func (a *Application) SeekFromStart(value int) error {
 
 	// TODO(vishen): maybe there is another ResumeState that lets us
 	// seek from the end? Although not sure how this works for live media?
+	media := a.currentMedia()
+	if media == nil {
+		return ErrMediaNotYetInitialised
+	}
 
 	return a.sendMediaRecv(&cast.MediaHeader{
 		PayloadHeader:  cast.SeekHeader,
-		MediaSessionId: a.media.MediaSessionId,
+		MediaSessionId: media.MediaSessionId,
 		CurrentTime:    float32(value),
 		ResumeState:    "PLAYBACK_START",
 	})
 }
 
 func (a *Application) SeekToTime(value float32) error {
-	if a.media == nil {
+	return a.seekToTime(value, "PLAYBACK_START")
+}
+
+// SeekToTimePaused seeks to the time but leaves the media paused, so
+// playback can be started once the device has buffered.
+func (a *Application) SeekToTimePaused(value float32) error {
+	return a.seekToTime(value, "PLAYBACK_PAUSE")
+}
+
+func (a *Application) seekToTime(value float32, resumeState string) error {
+	media := a.currentMedia()
+	if media == nil {
 		return ErrMediaNotYetInitialised
 	}
 
 	return a.sendMediaRecv(&cast.MediaHeader{
 		PayloadHeader:  cast.SeekHeader,
-		MediaSessionId: a.media.MediaSessionId,
+		MediaSessionId: media.MediaSessionId,
 		CurrentTime:    value,
-		ResumeState:    "PLAYBACK_START",
+		ResumeState:    resumeState,
 	})
 }
 
+// SetVolume sets the volume, kept within the limits of the volume
+// settings.
 func (a *Application) SetVolume(value float32) error {
 	if value > 1 || value < 0 {
 		return ErrVolumeOutOfRange
 	}
+	if limited := a.volumeSettings.Clamp(value); limited != value {
+		a.log("volume %0.2f is outside of the limits, setting it to %0.2f", value, limited)
+		value = limited
+	}
 
 	return a.sendDefaultRecv(&cast.SetVolume{
 		PayloadHeader: cast.VolumeHeader,
@@ -667,6 +828,12 @@ func (a *Application) SetVolume(value float32) error {
 	})
 }
 
+// SetVolumeSettings sets the limits, step and presets of the volume.
+func (a *Application) SetVolumeSettings(s VolumeSettings) { a.volumeSettings = s }
+
+// VolumeSettings returns the limits, step and presets of the volume.
+func (a *Application) VolumeSettings() VolumeSettings { return a.volumeSettings }
+
 func (a *Application) SetMuted(value bool) error {
 	return a.sendDefaultRecv(&cast.SetVolume{
 		PayloadHeader: cast.VolumeHeader,
@@ -676,6 +843,23 @@ func (a *Application) SetMuted(value bool) error {
 	})
 }
 
+// MediaStatus asks the device for the status of the current media, rather
+// than returning the last known status like Status does. It returns nil
+// if there is no media.
+func (a *Application) MediaStatus() (*cast.Media, error) {
+	mediaStatus, err := a.getMediaStatus()
+	if err != nil {
+		return nil, err
+	}
+	var media *cast.Media
+	for _, m := range mediaStatus.Status {
+		media = &m
+		a.setMedia(&m)
+		a.trackMediaStatus(m)
+	}
+	return media, nil
+}
+
 func (a *Application) getMediaStatus() (*cast.MediaStatusResponse, error) {
 	apiMessage, err := a.sendAndWaitMediaRecv(&cast.GetStatusHeader)
 	if err != nil {
@@ -688,6 +872,50 @@ func (a *Application) getMediaStatus() (*cast.MediaStatusResponse, error) {
 	return &response, nil
 }
 
+// QueueItems returns the items in the queue of the playing media, in the
+// order they play. Media that wasn't loaded as a queue is a queue of one.
+func (a *Application) QueueItems() ([]cast.QueueItem, error) {
+	media := a.currentMedia()
+	if media == nil {
+		return nil, ErrNoMediaQueue
+	}
+	apiMessage, err := a.sendAndWaitMediaRecv(&cast.QueueGetItems{
+		PayloadHeader:  cast.QueueGetItemIdsHeader,
+		MediaSessionId: media.MediaSessionId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var ids cast.QueueItemIdsResponse
+	if err := json.Unmarshal([]byte(*apiMessage.PayloadUtf8), &ids); err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling json")
+	}
+	if len(ids.ItemIds) == 0 {
+		return []cast.QueueItem{{ItemId: media.CurrentItemId, Media: media.Media}}, nil
+	}
+	apiMessage, err = a.sendAndWaitMediaRecv(&cast.QueueGetItems{
+		PayloadHeader:  cast.QueueGetItemsHeader,
+		MediaSessionId: media.MediaSessionId,
+		ItemIds:        ids.ItemIds,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var response cast.QueueItemsResponse
+	if err := json.Unmarshal([]byte(*apiMessage.PayloadUtf8), &response); err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling json")
+	}
+	// The items aren't necessarily in the order they were asked for.
+	order := make(map[int]int, len(ids.ItemIds))
+	for i, id := range ids.ItemIds {
+		order[id] = i
+	}
+	sort.SliceStable(response.Items, func(i, j int) bool {
+		return order[response.Items[i].ItemId] < order[response.Items[j].ItemId]
+	})
+	return response.Items, nil
+}
+
 func (a *Application) getReceiverStatus() (*cast.ReceiverStatusResponse, error) {
 	apiMessage, err := a.sendAndWaitDefaultRecv(&cast.GetStatusHeader)
 	if err != nil {
@@ -702,7 +930,13 @@ func (a *Application) getReceiverStatus() (*cast.ReceiverStatusResponse, error)
 }
 
 func (a *Application) PlayableMediaType(filename string) bool {
-	if a.knownFileType(filename) {
+	return PlayableMediaType(filename)
+}
+
+// PlayableMediaType returns whether the file is media that can be played,
+// either as is or by transcoding it.
+func PlayableMediaType(filename string) bool {
+	if knownFileType(filename) {
 		return true
 	}
 
@@ -714,7 +948,7 @@ func (a *Application) PlayableMediaType(filename string) bool {
 	return false
 }
 
-func (a *Application) possibleContentType(filename string) (string, error) {
+func possibleContentType(filename string) (string, error) {
 	// If filename is an URL returns the content-type from the HEAD response headers
 	// Otherwise returns the content-type thanks to filetype package
 	var contentType string
@@ -773,8 +1007,8 @@ func (a *Application) possibleContentType(filename string) (string, error) {
 	return contentType, nil
 }
 
-func (a *Application) knownFileType(filename string) bool {
-	if ct, _ := a.possibleContentType(filename); ct != "" {
+func knownFileType(filename string) bool {
+	if ct, _ := possibleContentType(filename); ct != "" {
 		return true
 	}
 	return false
@@ -795,35 +1029,98 @@ func (a *Application) castPlayableContentType(contentType string) bool {
 }
 
 func (a *Application) PlayedItems() map[string]PlayedItem {
-	return a.playedItems
+	a.playedItemsMu.Lock()
+	defer a.playedItemsMu.Unlock()
+
+	playedItems := make(map[string]PlayedItem, len(a.playedItems))
+	for k, v := range a.playedItems {
+		playedItems[k] = v
+	}
+	return playedItems
 }
 
 func (a *Application) Load(filenameOrUrl string, startTime int, contentType string, transcode, detach, forceDetach bool) error {
-	// if the file is a playlist, ".pls", then just play the first item.
+	// if the file is a playlist, ".pls" or ".m3u", then queue every item.
 	if playlists.IsPlaylist(filenameOrUrl) {
-		if strings.HasPrefix(filenameOrUrl, "./") { // convert to file:// uri
-			if abs, err := filepath.Abs(filenameOrUrl); err != nil {
-				return err
-			} else {
-				filenameOrUrl = fmt.Sprintf("file://%v", abs)
-			}
-		}
 		it, err := playlists.NewIterator(filenameOrUrl)
-		if err != nil {
+		if errors.Is(err, playlists.ErrHLSManifest) {
+			// An HLS manifest is played as a single stream.
+			return a.play(filenameOrUrl, startTime, contentType, transcode, detach, forceDetach)
+		} else if err != nil && strings.Contains(filenameOrUrl, "://") && playlists.MaybeHLS(filenameOrUrl) {
+			// The device may be able to fetch an HLS stream that can't be
+			// fetched from here, ie: one only reachable from the device.
+			a.log("unable to fetch %q, playing it as an HLS stream: %v", filenameOrUrl, err)
+			return a.play(filenameOrUrl, startTime, contentType, transcode, detach, forceDetach)
+		} else if err != nil {
 			return err
 		}
-		var items []mediaItem
-		for it.HasNext() {
-			url, title := it.Next()
+		items, local, err := a.playlistItems(it, contentType, transcode)
+		if err != nil {
+			return errors.Wrapf(err, "unable to load playlist %q", filenameOrUrl)
+		}
+		a.SetPlaylist(filenameOrUrl)
+		if !forceDetach && local && detach {
+			return fmt.Errorf("unable to detach from locally playing media content")
+		}
+		return a.queueLoadItems(items, startTime, contentType, !(detach && !local) && !forceDetach)
+	}
+	return a.play(filenameOrUrl, startTime, contentType, transcode, detach, forceDetach)
+}
+
+// playlistItems returns the media items for the entries of a playlist.
+// Remote urls are played as they are, and local files are served by the
+// streaming server, transcoding them if needed. Entries that can't be
+// played are logged and skipped, and local is set when any of the items
+// are served locally. Remote playlists can't refer to local files, the
+// playlists package skips them.
+func (a *Application) playlistItems(it playlists.Iterator, contentType string, transcode bool) (items []mediaItem, local bool, err error) {
+	skipped := 0
+	for it.HasNext() {
+		entry := it.Next()
+		if strings.Contains(entry.URL, "://") {
+			log.Infof("Adding url %v (%v)", entry.URL, entry.Title)
 			items = append(items, mediaItem{
-				filename:   url,
-				contentURL: url,
+				filename:    entry.URL,
+				contentURL:  entry.URL,
+				contentType: contentType,
 			})
-			log.Infof("Adding url %v (%v)", url, title)
+			continue
+		}
+		served, err := a.servePlaylistFile(entry.URL, contentType, transcode)
+		if err != nil {
+			log.WithField("package", "application").WithError(err).Warnf("skipping playlist entry %q", entry.URL)
+			skipped++
+			continue
 		}
-		return a.QueueLoadItems(items, "")
+		log.Infof("Adding file %v (%v)", entry.URL, entry.Title)
+		items = append(items, served...)
+		local = true
 	}
-	return a.play(filenameOrUrl, startTime, contentType, transcode, detach, forceDetach)
+	if len(items) == 0 {
+		if skipped > 0 {
+			return nil, false, fmt.Errorf("none of the %d entries can be played", skipped)
+		}
+		return nil, false, fmt.Errorf("playlist is empty")
+	}
+	return items, local, nil
+}
+
+// servePlaylistFile serves a local file from a playlist, checking it can
+// be read first as playlists often refer to files that have since moved.
+func (a *Application) servePlaylistFile(filename, contentType string, transcode bool) ([]mediaItem, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%q is not a file", filename)
+	}
+	return a.loadAndServeFiles([]string{filename}, contentType, transcode)
 }
 
 func (a *Application) play(filenameOrUrl string, startTime int, contentType string, transcode, detach, forceDetach bool) error {
@@ -835,7 +1132,7 @@ func (a *Application) play(filenameOrUrl string, startTime int, contentType stri
 		if contentType == "" {
 			// Try and determine the content type, but if we can't,
 			// let the chromecast try and handle the media file anyway.
-			contentType, _ = a.possibleContentType(filenameOrUrl)
+			contentType, _ = possibleContentType(filenameOrUrl)
 		}
 		mi = mediaItem{
 			contentURL:  filenameOrUrl,
@@ -861,8 +1158,15 @@ func (a *Application) play(filenameOrUrl string, startTime int, contentType stri
 		return err
 	}
 
+	// If we should detach from waiting for media to finish playing
+	// and this is a url loaded external media, then we can exit early.
+	wait := !(detach && isExternalMedia) && !forceDetach
+
 	// NOTE: This isn't concurrent safe, but it doesn't need to be at the moment!
-	a.MediaStart()
+	if wait {
+		a.MediaStart()
+	}
+	a.expectContent(mi.contentURL)
 
 	// Send the command to the chromecast
 	a.sendMediaRecv(&cast.LoadMediaCommand{
@@ -876,17 +1180,68 @@ func (a *Application) play(filenameOrUrl string, startTime int, contentType stri
 		},
 	})
 
-	// If we should detach from waiting for media to finish playing
-	// and this is a url loaded external media, then we can exit early.
-	if (detach && isExternalMedia) || forceDetach {
+	if !wait {
 		return nil
 	}
 
 	// Wait until we have been notified that the media has finished playing
+	defer a.pollPosition()()
 	a.MediaWait()
 	return nil
 }
 
+// LoadMedia loads the media onto the device, and unlike Load it only waits
+// for the device to accept the media rather than for it to finish. If
+// autoplay is false the media is left paused at startTime. Any of the
+// media tracks in activeTrackIds, ie: subtitles, are enabled.
+func (a *Application) LoadMedia(media cast.MediaItem, startTime int, autoplay bool, activeTrackIds ...int) error {
+	if err := a.ensureIsDefaultMediaReceiver(); err != nil {
+		return err
+	}
+	if media.StreamType == "" {
+		media.StreamType = "BUFFERED"
+	}
+	a.expectContent(media.ContentId)
+
+	apiMessage, err := a.sendAndWaitMediaRecv(&cast.LoadMediaCommand{
+		PayloadHeader:  cast.LoadHeader,
+		CurrentTime:    startTime,
+		Autoplay:       autoplay,
+		Media:          media,
+		ActiveTrackIds: activeTrackIds,
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to load media")
+	}
+	messageBytes := []byte(*apiMessage.PayloadUtf8)
+	if messageType, _ := jsonparser.GetString(messageBytes, "type"); messageType != "MEDIA_STATUS" {
+		return fmt.Errorf("unable to load media, device responded with %q", messageType)
+	}
+	var response cast.MediaStatusResponse
+	if err := json.Unmarshal(messageBytes, &response); err != nil {
+		return errors.Wrap(err, "error unmarshaling json")
+	}
+	for _, m := range response.Status {
+		a.setMedia(&m)
+		a.trackMediaStatus(m)
+	}
+	return nil
+}
+
+// ServeFile serves a local file from the streaming server, and returns the
+// media to load onto any device that can reach this host.
+func (a *Application) ServeFile(filename, contentType string, transcode bool) (cast.MediaItem, error) {
+	mediaItems, err := a.loadAndServeFiles([]string{filename}, contentType, transcode)
+	if err != nil {
+		return cast.MediaItem{}, errors.Wrap(err, "unable to load and serve files")
+	}
+	return cast.MediaItem{
+		ContentId:   mediaItems[0].contentURL,
+		ContentType: mediaItems[0].contentType,
+		StreamType:  "BUFFERED",
+	}, nil
+}
+
 func (a *Application) LoadApp(appID, contentID string) error {
 	// old list https://gist.github.com/jloutsenhizer/8855258.
 	// NOTE: This isn't concurrent safe, but it doesn't need to be at the moment!
@@ -913,22 +1268,30 @@ func (a *Application) LoadApp(appID, contentID string) error {
 	return nil
 }
 
-func (a *Application) QueueLoad(filenames []string, contentType string, transcode bool) error {
+// QueueLoad loads the files onto the device as a queue, starting the
+// first of them at startTime.
+func (a *Application) QueueLoad(filenames []string, startTime int, contentType string, transcode bool) error {
 	mediaItems, err := a.loadAndServeFiles(filenames, contentType, transcode)
 	if err != nil {
 		return errors.Wrap(err, "unable to load and serve files")
 	}
-	return a.QueueLoadItems(mediaItems, contentType)
+	return a.QueueLoadItems(mediaItems, startTime, contentType)
 }
 
-func (a *Application) QueueLoadItems(mediaItems []mediaItem, contentType string) error {
+func (a *Application) QueueLoadItems(mediaItems []mediaItem, startTime int, contentType string) error {
+	return a.queueLoadItems(mediaItems, startTime, contentType, true)
+}
 
+// queueLoadItems loads the items onto the device as a queue, waiting for
+// them to finish playing when wait is set.
+func (a *Application) queueLoadItems(mediaItems []mediaItem, startTime int, contentType string, wait bool) error {
 	if err := a.ensureIsDefaultMediaReceiver(); err != nil {
 		return err
 	}
 
 	items := make([]cast.QueueLoadItem, len(mediaItems))
 	for i, mi := range mediaItems {
+		a.expectContent(mi.contentURL)
 		items[i] = cast.QueueLoadItem{
 			Autoplay:         true,
 			PlaybackDuration: 60,
@@ -943,15 +1306,19 @@ func (a *Application) QueueLoadItems(mediaItems []mediaItem, contentType string)
 	// Send the command to the chromecast
 	a.sendMediaRecv(&cast.QueueLoad{
 		PayloadHeader: cast.QueueLoadHeader,
-		CurrentTime:   0,
+		CurrentTime:   float32(startTime),
 		StartIndex:    0,
 		RepeatMode:    "REPEAT_OFF",
 		Items:         items,
 	})
+	if !wait {
+		return nil
+	}
 
 	// Wait until we have been notified that the media has finished playing
 	// TODO: This does nothing. This hasn't been initialised and just blocks
 	// forever.
+	defer a.pollPosition()()
 	a.MediaWait()
 	return nil
 }
@@ -990,6 +1357,7 @@ func (a *Application) Slideshow(filenames []string, duration int, repeat bool) e
 
 	items := make([]cast.QueueLoadItem, len(mediaItems))
 	for i, mi := range mediaItems {
+		a.expectContent(mi.contentURL)
 		items[i] = cast.QueueLoadItem{
 			Autoplay:         true,
 			PlaybackDuration: duration,
@@ -1073,7 +1441,7 @@ func (a *Application) loadAndServeFiles(filenames []string, contentType string,
 			- if we have a filename with an unknown content type, and transcode is true
 			-
 		*/
-		knownFileType := a.knownFileType(filename)
+		knownFileType := knownFileType(filename)
 		if !knownFileType && contentType == "" && !transcodeFile {
 			return nil, fmt.Errorf("unknown content-type for %q, either specify a content-type or set transcode to true", filename)
 		}
@@ -1085,7 +1453,7 @@ func (a *Application) loadAndServeFiles(filenames []string, contentType string,
 		} else if knownFileType {
 			// If this is a media file we know the chromecast can play,
 			// then we don't need to transcode it.
-			contentTypeToUse, _ = a.possibleContentType(filename)
+			contentTypeToUse, _ = possibleContentType(filename)
 			if a.castPlayableContentType(contentTypeToUse) {
 				transcodeFile = false
 			}
@@ -1181,9 +1549,6 @@ func (a *Application) startStreamingServer() error {
 			}
 		}
 
-		a.playedItems[filename] = PlayedItem{ContentID: filename, Started: time.Now().Unix()}
-		a.writePlayedItems()
-
 		// Check to see if this is a live streaming video and we need to use an
 		// infinite range request / response. This comes from media that is either
 		// live or currently being transcoded to a different media format.
@@ -1203,12 +1568,6 @@ func (a *Application) startStreamingServer() error {
 			http.Error(w, "Invalid file", 400)
 		}
 		a.log("method=%s, headers=%v, reponse_headers=%v", r.Method, r.Header, w.Header())
-		pi := a.playedItems[filename]
-
-		// TODO(vishen): make this a pointer?
-		pi.Finished = time.Now().Unix()
-		a.playedItems[filename] = pi
-		a.writePlayedItems()
 	})
 
 	go func() {
@@ -1257,9 +1616,7 @@ func (a *Application) log(message string, args ...interface{}) {
 }
 
 func (a *Application) send(payload cast.Payload, sourceID, destinationID, namespace string) (int, error) {
-	// NOTE: Not concurrent safe, but currently only synchronous flow is possible
-	// TODO(vishen): just make concurrent safe regardless of current flow
-	requestID += 1
+	requestID := int(requestID.Add(1))
 	payload.SetRequestId(requestID)
 	return requestID, a.conn.Send(requestID, payload, sourceID, destinationID, namespace)
 }
@@ -1274,12 +1631,14 @@ func (a *Application) sendAndWait(payload cast.Payload, sourceID, destinationID,
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
-	// TODO(vishen): not concurrent safe. Not a problem at the moment
-	// because only synchronous flow currently allowed.
 	resultChan := make(chan *pb.CastMessage, 1)
+	a.resultMu.Lock()
 	a.resultChanMap[requestID] = resultChan
+	a.resultMu.Unlock()
 	defer func() {
+		a.resultMu.Lock()
 		delete(a.resultChanMap, requestID)
+		a.resultMu.Unlock()
 	}()
 
 	select {
@@ -1367,9 +1726,6 @@ func (a *Application) startTranscodingServer(command string, args ...string) err
 			}
 		}
 
-		a.playedItems[filename] = PlayedItem{ContentID: filename, Started: time.Now().Unix()}
-		a.writePlayedItems()
-
 		a.log("canServe=%t, liveStreaming=%t, filename=%s", canServe, true, filename)
 		if canServe {
 			cmd := exec.Command(command, args...)
@@ -1391,12 +1747,6 @@ func (a *Application) startTranscodingServer(command string, args ...string) err
 			http.Error(w, "Invalid file", 400)
 		}
 		a.log("method=%s, headers=%v, reponse_headers=%v", r.Method, r.Header, w.Header())
-		pi := a.playedItems[filename]
-
-		// TODO(vishen): make this a pointer?
-		pi.Finished = time.Now().Unix()
-		a.playedItems[filename] = pi
-		a.writePlayedItems()
 	})
 
 	go func() {
@@ -12,7 +12,14 @@ var (
 	ErrNoMediaStop            = errors.New("media not yet initialised, there is nothing to stop")
 	ErrNoMediaUnpause         = errors.New("media not yet initialised, there is nothing to unpause")
 	ErrNoMediaTogglePause     = errors.New("media not yet initialised, there is nothing to (un)pause")
+	ErrNoMediaTransfer        = errors.New("media not yet initialised, there is nothing to transfer")
+	ErrNoMediaQueue           = errors.New("media not yet initialised, there is no queue")
+	ErrNoMediaPlaybackRate    = errors.New("media not yet initialised, there is nothing to change the playback rate of")
 	ErrNoMediaSkipad          = errors.New("No ad detected, there is nothing to skip")
 	ErrVolumeOutOfRange       = errors.New("specified volume is out of range (0 - 1)")
+	ErrPlaybackRateOutOfRange = errors.New("specified playback rate is out of range (0.5 - 2)")
 	ErrAdMaxLoop              = errors.New("Unable to skip ad for unknown reason")
+	ErrSyncFinished           = errors.New("media has finished playing on every device")
+	ErrNotPlayed              = errors.New("media hasn't been played")
+	ErrSleepInterrupted       = errors.New("sleep timer interrupted, the device was used")
 )
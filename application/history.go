@@ -0,0 +1,70 @@
+package application
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/vishen/go-chromecast/storage"
+)
+
+// History returns every played item kept in the store, the most recently
+// started first.
+func History(store storage.Store) ([]PlayedItem, error) {
+	keys, err := store.Keys(storage.BucketPlayedItems, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list played items")
+	}
+	items := make([]PlayedItem, 0, len(keys))
+	for _, k := range keys {
+		pi, err := loadPlayedItem(store, k)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, pi)
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Started > items[j].Started })
+	return items, nil
+}
+
+// SetWatched marks the played item as watched or unwatched. Unwatched
+// media also forgets its position, so it is played from the start.
+func SetWatched(store storage.Store, contentID string, watched bool) error {
+	pi, err := loadPlayedItem(store, contentID)
+	if err != nil {
+		return err
+	}
+	pi.Watched = watched
+	if !watched {
+		pi.Position = 0
+	}
+	b, _ := json.Marshal(pi)
+	return errors.Wrap(store.Save(storage.BucketPlayedItems, contentID, b), "unable to save played item")
+}
+
+// Forget removes the played item from the store.
+func Forget(store storage.Store, contentID string) error {
+	if _, err := loadPlayedItem(store, contentID); err != nil {
+		return err
+	}
+	return errors.Wrap(store.Delete(storage.BucketPlayedItems, contentID), "unable to forget played item")
+}
+
+func loadPlayedItem(store storage.Store, contentID string) (PlayedItem, error) {
+	b, err := store.Load(storage.BucketPlayedItems, contentID)
+	if err != nil {
+		return PlayedItem{}, errors.Wrap(err, "unable to load played item")
+	}
+	if len(b) == 0 {
+		return PlayedItem{}, errors.Wrapf(ErrNotPlayed, "%q", contentID)
+	}
+	pi := PlayedItem{}
+	if err := json.Unmarshal(b, &pi); err != nil {
+		return PlayedItem{}, errors.Wrapf(err, "unable to parse played item %q", contentID)
+	}
+	if pi.ContentID == "" {
+		pi.ContentID = contentID
+	}
+	return pi, nil
+}
@@ -1,6 +1,7 @@
 package application
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -15,10 +16,19 @@ import (
 // to use for protobuf-communication,
 
 func GetInfo(ip string) (info *cast.DeviceInfo, err error) {
+	return GetInfoContext(context.Background(), ip)
+}
+
+// GetInfoContext is GetInfo, giving up when ctx is done.
+func GetInfoContext(ctx context.Context, ip string) (info *cast.DeviceInfo, err error) {
 	// Note: Services exposed not on 8009 port are "Google Cast Group"s
 	// The only way to find the true device (group) name, is using mDNS outside of this function.
 	url := fmt.Sprintf("http://%v:8008/setup/eureka_info", ip)
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -9,6 +9,12 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	net "net"
+
+	podcast "github.com/vishen/go-chromecast/podcast"
+
+	storage "github.com/vishen/go-chromecast/storage"
+
+	time "time"
 )
 
 // App is an autogenerated mock type for the App type
@@ -105,6 +111,61 @@ func (_m *App) LoadApp(appID string, contentID string) error {
 	return r0
 }
 
+// LoadMedia provides a mock function with given fields: media, startTime, autoplay, activeTrackIds
+func (_m *App) LoadMedia(media cast.MediaItem, startTime int, autoplay bool, activeTrackIds ...int) error {
+	_va := make([]interface{}, len(activeTrackIds))
+	for _i := range activeTrackIds {
+		_va[_i] = activeTrackIds[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, media, startTime, autoplay)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LoadMedia")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(cast.MediaItem, int, bool, ...int) error); ok {
+		r0 = rf(media, startTime, autoplay, activeTrackIds...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MediaStatus provides a mock function with given fields:
+func (_m *App) MediaStatus() (*cast.Media, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for MediaStatus")
+	}
+
+	var r0 *cast.Media
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (*cast.Media, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() *cast.Media); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*cast.Media)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Next provides a mock function with given fields:
 func (_m *App) Next() error {
 	ret := _m.Called()
@@ -141,6 +202,42 @@ func (_m *App) Pause() error {
 	return r0
 }
 
+// PlayEpisode provides a mock function with given fields: feed, episode, startTime
+func (_m *App) PlayEpisode(feed *podcast.Feed, episode podcast.Episode, startTime int) error {
+	ret := _m.Called(feed, episode, startTime)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PlayEpisode")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*podcast.Feed, podcast.Episode, int) error); ok {
+		r0 = rf(feed, episode, startTime)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PlayRadio provides a mock function with given fields: stationURL, name, onTitle
+func (_m *App) PlayRadio(stationURL string, name string, onTitle func(string)) error {
+	ret := _m.Called(stationURL, name, onTitle)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PlayRadio")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, func(string)) error); ok {
+		r0 = rf(stationURL, name, onTitle)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // PlayableMediaType provides a mock function with given fields: filename
 func (_m *App) PlayableMediaType(filename string) bool {
 	ret := _m.Called(filename)
@@ -197,17 +294,47 @@ func (_m *App) Previous() error {
 	return r0
 }
 
-// QueueLoad provides a mock function with given fields: filenames, contentType, transcode
-func (_m *App) QueueLoad(filenames []string, contentType string, transcode bool) error {
-	ret := _m.Called(filenames, contentType, transcode)
+// QueueItems provides a mock function with given fields:
+func (_m *App) QueueItems() ([]cast.QueueItem, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for QueueItems")
+	}
+
+	var r0 []cast.QueueItem
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]cast.QueueItem, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []cast.QueueItem); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]cast.QueueItem)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// QueueLoad provides a mock function with given fields: filenames, startTime, contentType, transcode
+func (_m *App) QueueLoad(filenames []string, startTime int, contentType string, transcode bool) error {
+	ret := _m.Called(filenames, startTime, contentType, transcode)
 
 	if len(ret) == 0 {
 		panic("no return value specified for QueueLoad")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func([]string, string, bool) error); ok {
-		r0 = rf(filenames, contentType, transcode)
+	if rf, ok := ret.Get(0).(func([]string, int, string, bool) error); ok {
+		r0 = rf(filenames, startTime, contentType, transcode)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -215,6 +342,34 @@ func (_m *App) QueueLoad(filenames []string, contentType string, transcode bool)
 	return r0
 }
 
+// ResumePosition provides a mock function with given fields: contentID, threshold
+func (_m *App) ResumePosition(contentID string, threshold time.Duration) (int, bool) {
+	ret := _m.Called(contentID, threshold)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResumePosition")
+	}
+
+	var r0 int
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(string, time.Duration) (int, bool)); ok {
+		return rf(contentID, threshold)
+	}
+	if rf, ok := ret.Get(0).(func(string, time.Duration) int); ok {
+		r0 = rf(contentID, threshold)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, time.Duration) bool); ok {
+		r1 = rf(contentID, threshold)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
 // Seek provides a mock function with given fields: value
 func (_m *App) Seek(value int) error {
 	ret := _m.Called(value)
@@ -269,6 +424,52 @@ func (_m *App) SeekToTime(value float32) error {
 	return r0
 }
 
+// SeekToTimePaused provides a mock function with given fields: value
+func (_m *App) SeekToTimePaused(value float32) error {
+	ret := _m.Called(value)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SeekToTimePaused")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(float32) error); ok {
+		r0 = rf(value)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ServeFile provides a mock function with given fields: filename, contentType, transcode
+func (_m *App) ServeFile(filename string, contentType string, transcode bool) (cast.MediaItem, error) {
+	ret := _m.Called(filename, contentType, transcode)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ServeFile")
+	}
+
+	var r0 cast.MediaItem
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, bool) (cast.MediaItem, error)); ok {
+		return rf(filename, contentType, transcode)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, bool) cast.MediaItem); ok {
+		r0 = rf(filename, contentType, transcode)
+	} else {
+		r0 = ret.Get(0).(cast.MediaItem)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, bool) error); ok {
+		r1 = rf(filename, contentType, transcode)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SetCacheDisabled provides a mock function with given fields: _a0
 func (_m *App) SetCacheDisabled(_a0 bool) {
 	_m.Called(_a0)
@@ -312,11 +513,39 @@ func (_m *App) SetMuted(value bool) error {
 	return r0
 }
 
+// SetPlaybackRate provides a mock function with given fields: rate
+func (_m *App) SetPlaybackRate(rate float32) error {
+	ret := _m.Called(rate)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetPlaybackRate")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(float32) error); ok {
+		r0 = rf(rate)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetPlaylist provides a mock function with given fields: name
+func (_m *App) SetPlaylist(name string) {
+	_m.Called(name)
+}
+
 // SetServerPort provides a mock function with given fields: _a0
 func (_m *App) SetServerPort(_a0 int) {
 	_m.Called(_a0)
 }
 
+// SetStore provides a mock function with given fields: _a0
+func (_m *App) SetStore(_a0 storage.Store) {
+	_m.Called(_a0)
+}
+
 // SetVolume provides a mock function with given fields: value
 func (_m *App) SetVolume(value float32) error {
 	ret := _m.Called(value)
@@ -335,6 +564,11 @@ func (_m *App) SetVolume(value float32) error {
 	return r0
 }
 
+// SetVolumeSettings provides a mock function with given fields: s
+func (_m *App) SetVolumeSettings(s application.VolumeSettings) {
+	_m.Called(s)
+}
+
 // Skipad provides a mock function with given fields:
 func (_m *App) Skipad() error {
 	ret := _m.Called()
@@ -545,6 +779,24 @@ func (_m *App) Update() error {
 	return r0
 }
 
+// VolumeSettings provides a mock function with given fields:
+func (_m *App) VolumeSettings() application.VolumeSettings {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for VolumeSettings")
+	}
+
+	var r0 application.VolumeSettings
+	if rf, ok := ret.Get(0).(func() application.VolumeSettings); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(application.VolumeSettings)
+	}
+
+	return r0
+}
+
 // NewApp creates a new instance of App. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewApp(t interface {
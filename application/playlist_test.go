@@ -0,0 +1,52 @@
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vishen/go-chromecast/playlists"
+)
+
+func TestPlaylistItems(t *testing.T) {
+	assertions := require.New(t)
+	app, _ := newTestApplication()
+	app.localIP = "127.0.0.1"
+
+	dir := t.TempDir()
+	assertions.NoError(os.WriteFile(filepath.Join(dir, "song.mp3"), []byte("ID3"), 0644))
+	assertions.NoError(os.WriteFile(filepath.Join(dir, "notes.xyz"), []byte("notes"), 0644))
+	assertions.NoError(os.Mkdir(filepath.Join(dir, "album"), 0755))
+	playlist := filepath.Join(dir, "list.m3u")
+	assertions.NoError(os.WriteFile(playlist, []byte("#EXTM3U\nsong.mp3\nmissing.mp3\nalbum\nnotes.xyz\nhttp://example.com/radio\n"), 0644))
+
+	it, err := playlists.NewIterator(playlist)
+	assertions.NoError(err)
+	items, local, err := app.playlistItems(it, "", false)
+	assertions.NoError(err)
+	assertions.True(local)
+	assertions.Len(items, 2)
+
+	song := filepath.Join(dir, "song.mp3")
+	assertions.Equal(song, items[0].filename)
+	assertions.Equal("audio/mpeg", items[0].contentType)
+	assertions.Contains(items[0].contentURL, "http://127.0.0.1:")
+	filename, ok := LocalFile(items[0].contentURL)
+	assertions.True(ok)
+	assertions.Equal(song, filename)
+
+	assertions.Equal(mediaItem{filename: "http://example.com/radio", contentURL: "http://example.com/radio"}, items[1])
+}
+
+func TestPlaylistItemsUnplayable(t *testing.T) {
+	app, _ := newTestApplication()
+	dir := t.TempDir()
+	playlist := filepath.Join(dir, "list.m3u")
+	require.NoError(t, os.WriteFile(playlist, []byte("missing.mp3\n"), 0644))
+
+	it, err := playlists.NewIterator(playlist)
+	require.NoError(t, err)
+	_, _, err = app.playlistItems(it, "", true)
+	require.EqualError(t, err, "none of the 1 entries can be played")
+}
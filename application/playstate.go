@@ -0,0 +1,164 @@
+package application
+
+import (
+	"time"
+
+	"github.com/vishen/go-chromecast/cast"
+)
+
+// positionSaveInterval is how often the position of playing media is
+// saved, it is always saved when the player state changes.
+const positionSaveInterval = 10 * time.Second
+
+// playState follows the media loaded by this application through the
+// MEDIA_STATUS messages from the device. The device only includes the
+// media in a status when it changes, so the queue item ids are used to
+// know which media a status is for.
+type playState struct {
+	// loaded are the content ids this application loaded, media loaded
+	// by anything else isn't recorded.
+	loaded map[string]bool
+	// items are the content ids of the queue item ids on the device.
+	items map[int]string
+
+	contentID   string
+	playerState string
+	saved       time.Time
+}
+
+// contentIDFor returns the id that played items are kept under for the
+// content url loaded onto the device. Media served by go-chromecast is
+// kept under its filename, radio stations under their stream url, and
+// anything else under its url.
+func contentIDFor(contentURL string) string {
+	if filename, _, ok := servedFile(contentURL); ok {
+		return filename
+	}
+	if streamURL, ok := radioStream(contentURL); ok {
+		return streamURL
+	}
+	return contentURL
+}
+
+// expectContent records that the content urls are being loaded by this
+// application, so their play state is recorded.
+func (a *Application) expectContent(contentURLs ...string) {
+	a.playedItemsMu.Lock()
+	defer a.playedItemsMu.Unlock()
+
+	if a.playState.loaded == nil {
+		a.playState.loaded = map[string]bool{}
+	}
+	for _, u := range contentURLs {
+		a.playState.loaded[contentIDFor(u)] = true
+	}
+}
+
+// loadedMedia reports whether the media was loaded by this application.
+func (a *Application) loadedMedia(media *cast.Media) bool {
+	a.playedItemsMu.Lock()
+	defer a.playedItemsMu.Unlock()
+
+	contentID := a.playState.items[media.CurrentItemId]
+	if media.Media.ContentId != "" {
+		contentID = contentIDFor(media.Media.ContentId)
+	}
+	return contentID != "" && a.playState.loaded[contentID]
+}
+
+// trackMediaStatus updates the played item for the media in a
+// MEDIA_STATUS. Media is started when the device starts playing it, and
+// finished when the device reports it went idle because it finished. Any
+// other reason for going idle, ie: INTERRUPTED or ERROR, keeps the
+// position so it can be resumed. The content id of the media is returned
+// when this application loaded it.
+func (a *Application) trackMediaStatus(media cast.Media) string {
+	a.playedItemsMu.Lock()
+	st := &a.playState
+	if st.items == nil {
+		st.items = map[int]string{}
+	}
+	for _, item := range media.Items {
+		if item.Media.ContentId != "" {
+			st.items[item.ItemId] = contentIDFor(item.Media.ContentId)
+		}
+	}
+
+	contentID := st.contentID
+	if media.Media.ContentId != "" {
+		contentID = contentIDFor(media.Media.ContentId)
+		if media.CurrentItemId != 0 {
+			st.items[media.CurrentItemId] = contentID
+		}
+	} else if id, ok := st.items[media.CurrentItemId]; ok && media.CurrentItemId != 0 {
+		contentID = id
+	}
+	if contentID == "" || !st.loaded[contentID] {
+		a.playedItemsMu.Unlock()
+		return ""
+	}
+
+	var changed []string
+	if contentID != st.contentID && st.contentID != "" && st.playerState != "IDLE" {
+		// The queue moved on without the previous media going idle, it
+		// is finished if it was played to the end.
+		prev := a.playedItem(st.contentID)
+		if prev.Finished < prev.Started && prev.Duration > 0 && secondsToDuration(prev.Duration-prev.Position) <= DefaultResumeThreshold {
+			prev.Finished = time.Now().Unix()
+			a.playedItems[prev.ContentID] = prev
+			changed = append(changed, prev.ContentID)
+		}
+	}
+	started := contentID != st.contentID || st.playerState == "" || st.playerState == "IDLE"
+
+	pi := a.playedItem(contentID)
+	if media.Media.Duration > 0 {
+		pi.Duration = media.Media.Duration
+	}
+	save := media.PlayerState != st.playerState || time.Since(st.saved) >= positionSaveInterval
+	switch media.PlayerState {
+	case "PLAYING", "BUFFERING", "PAUSED":
+		if started {
+			pi.Started = time.Now().Unix()
+			pi.Finished = 0
+		}
+		if media.CurrentTime > 0 {
+			pi.Position = media.CurrentTime
+		}
+	case "IDLE":
+		if media.IdleReason == "FINISHED" && pi.Finished < pi.Started {
+			// The position isn't reported once the media has finished,
+			// so record that it was played to the end.
+			pi.Finished = time.Now().Unix()
+			pi.Duration = max(pi.Duration, pi.Position)
+			pi.Position = pi.Duration
+		} else if media.IdleReason != "" {
+			a.log("media %q stopped: %s", contentID, media.IdleReason)
+		}
+	}
+	a.playedItems[contentID] = pi
+	st.contentID = contentID
+	st.playerState = media.PlayerState
+	if save {
+		st.saved = time.Now()
+		changed = append(changed, contentID)
+	}
+	a.playedItemsMu.Unlock()
+
+	for _, id := range changed {
+		a.savePlayedItem(id)
+	}
+	return contentID
+}
+
+// playedItem returns the played item for the content, recorded against
+// the current device. The caller must hold a.playedItemsMu.
+func (a *Application) playedItem(contentID string) PlayedItem {
+	pi := a.playedItems[contentID]
+	pi.ContentID = contentID
+	if a.deviceUUID != "" || a.deviceName != "" {
+		pi.DeviceUUID = a.deviceUUID
+		pi.DeviceName = a.deviceName
+	}
+	return pi
+}
@@ -0,0 +1,95 @@
+package application
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vishen/go-chromecast/cast"
+	"github.com/vishen/go-chromecast/storage"
+)
+
+func newTestApplication() (*Application, storage.Store) {
+	store := storage.NewMemoryStore()
+	return &Application{
+		playedItems: map[string]PlayedItem{},
+		store:       store,
+		deviceUUID:  "abc",
+		deviceName:  "Living Room",
+	}, store
+}
+
+func savedPlayedItem(t *testing.T, store storage.Store, contentID string) PlayedItem {
+	b, err := store.Load(storage.BucketPlayedItems, contentID)
+	require.NoError(t, err)
+	pi := PlayedItem{}
+	require.NoError(t, json.Unmarshal(b, &pi))
+	return pi
+}
+
+func TestTrackMediaStatusQueue(t *testing.T) {
+	assertions := require.New(t)
+	app, store := newTestApplication()
+
+	first, second := "http://example.com/1.mp3", "http://example.com/2.mp3"
+	app.expectContent(first, second)
+	app.trackMediaStatus(cast.Media{
+		PlayerState:   "PLAYING",
+		CurrentItemId: 1,
+		CurrentTime:   10,
+		Media:         cast.MediaItem{ContentId: first, Duration: 100},
+		Items: []cast.QueueItem{
+			{ItemId: 1, Media: cast.MediaItem{ContentId: first}},
+			{ItemId: 2, Media: cast.MediaItem{ContentId: second}},
+		},
+	})
+	pi := savedPlayedItem(t, store, first)
+	assertions.NotZero(pi.Started)
+	assertions.Zero(pi.Finished)
+	assertions.Equal("Living Room", pi.DeviceName)
+
+	// Later statuses only include the media when it changes.
+	app.trackMediaStatus(cast.Media{PlayerState: "PAUSED", CurrentItemId: 1, CurrentTime: 42.5})
+	assertions.Equal(float32(42.5), savedPlayedItem(t, store, first).Position)
+	position, finished := app.ResumePosition(first, DefaultResumeThreshold)
+	assertions.Equal(42, position)
+	assertions.False(finished)
+
+	app.trackMediaStatus(cast.Media{PlayerState: "IDLE", IdleReason: "FINISHED", CurrentItemId: 1, LoadingItemId: 2})
+	pi = savedPlayedItem(t, store, first)
+	assertions.NotZero(pi.Finished)
+	_, finished = app.ResumePosition(first, DefaultResumeThreshold)
+	assertions.True(finished)
+
+	app.trackMediaStatus(cast.Media{PlayerState: "PLAYING", CurrentItemId: 2, CurrentTime: 1})
+	pi = savedPlayedItem(t, store, second)
+	assertions.NotZero(pi.Started)
+	assertions.Equal(float32(1), pi.Position)
+}
+
+func TestTrackMediaStatusInterrupted(t *testing.T) {
+	assertions := require.New(t)
+	app, store := newTestApplication()
+
+	url := "http://example.com/movie.mp4"
+	app.expectContent(url)
+	app.trackMediaStatus(cast.Media{PlayerState: "PLAYING", CurrentTime: 600, Media: cast.MediaItem{ContentId: url, Duration: 6000}})
+	app.trackMediaStatus(cast.Media{PlayerState: "IDLE", IdleReason: "INTERRUPTED"})
+
+	pi := savedPlayedItem(t, store, url)
+	assertions.Zero(pi.Finished)
+	assertions.Equal(float32(600), pi.Position)
+
+	app.trackMediaStatus(cast.Media{PlayerState: "PLAYING", CurrentTime: 5990})
+	_, finished := app.ResumePosition(url, DefaultResumeThreshold)
+	assertions.True(finished, "media within the threshold of the end should count as finished")
+}
+
+func TestTrackMediaStatusIgnoresOtherMedia(t *testing.T) {
+	app, store := newTestApplication()
+	app.trackMediaStatus(cast.Media{PlayerState: "PLAYING", CurrentTime: 10, Media: cast.MediaItem{ContentId: "http://example.com/other.mp3"}})
+
+	keys, err := store.Keys(storage.BucketPlayedItems, "")
+	require.NoError(t, err)
+	require.Empty(t, keys, "media not loaded by the application shouldn't be recorded")
+}
@@ -0,0 +1,43 @@
+package application
+
+import (
+	"github.com/vishen/go-chromecast/cast"
+	"github.com/vishen/go-chromecast/podcast"
+)
+
+// PlayEpisode plays an episode of the podcast from startTime, in seconds,
+// with the artwork and details from the feed. Like Load it waits for the
+// episode to finish, recording how far it has been played so it can be
+// resumed.
+func (a *Application) PlayEpisode(feed *podcast.Feed, episode podcast.Episode, startTime int) error {
+	a.MediaStart()
+	if err := a.LoadMedia(episodeMedia(feed, episode), startTime, true); err != nil {
+		return err
+	}
+	defer a.pollPosition()()
+	a.MediaWait()
+	return nil
+}
+
+// episodeMedia returns the media of a podcast episode as it is loaded onto
+// the device.
+func episodeMedia(feed *podcast.Feed, episode podcast.Episode) cast.MediaItem {
+	media := cast.MediaItem{
+		ContentId:   episode.URL,
+		ContentType: episode.Type,
+		StreamType:  "BUFFERED",
+		Duration:    float32(episode.Duration.Seconds()),
+		Metadata: cast.MediaMetadata{
+			MetadataType: 0, // GenericMediaMetadata
+			Title:        episode.Title,
+			Subtitle:     feed.Title,
+		},
+	}
+	if !episode.Published.IsZero() {
+		media.Metadata.ReleaseDate = episode.Published.Format("2006-01-02")
+	}
+	if episode.Image != "" {
+		media.Metadata.Images = []cast.Image{{URL: episode.Image}}
+	}
+	return media
+}
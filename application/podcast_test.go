@@ -0,0 +1,40 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vishen/go-chromecast/cast"
+	"github.com/vishen/go-chromecast/podcast"
+)
+
+func TestEpisodeMedia(t *testing.T) {
+	feed := &podcast.Feed{Title: "Lectures in Go"}
+	episode := podcast.Episode{
+		Title:     "Episode 2: Channels",
+		Published: time.Date(2026, 10, 14, 8, 0, 0, 0, time.UTC),
+		Duration:  45*time.Minute + 30*time.Second,
+		URL:       "https://example.com/episodes/2.mp3",
+		Type:      "audio/mpeg",
+		Image:     "https://example.com/episodes/2.jpg",
+	}
+	require.Equal(t, cast.MediaItem{
+		ContentId:   "https://example.com/episodes/2.mp3",
+		ContentType: "audio/mpeg",
+		StreamType:  "BUFFERED",
+		Duration:    2730,
+		Metadata: cast.MediaMetadata{
+			Title:       "Episode 2: Channels",
+			Subtitle:    "Lectures in Go",
+			ReleaseDate: "2026-10-14",
+			Images:      []cast.Image{{URL: "https://example.com/episodes/2.jpg"}},
+		},
+	}, episodeMedia(feed, episode))
+
+	// Episodes are recorded as played under their url.
+	app, store := newTestApplication()
+	app.expectContent(episode.URL)
+	app.trackMediaStatus(cast.Media{PlayerState: "PLAYING", CurrentTime: 600, Media: episodeMedia(feed, episode)})
+	require.Equal(t, float32(600), savedPlayedItem(t, store, episode.URL).Position)
+}
@@ -0,0 +1,390 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/vishen/go-chromecast/cast"
+	"github.com/vishen/go-chromecast/playlists"
+)
+
+// radioStation is the internet radio station being proxied to the device.
+type radioStation struct {
+	streamURL   string
+	name        string
+	contentType string
+	// contentURL is the url of the stream on the streaming server.
+	contentURL string
+	// title is the last now playing title sent by the station.
+	title   string
+	onTitle func(title string)
+}
+
+// radioClient fetches radio streams. Shoutcast servers answer with an
+// "ICY 200 OK" status line, which is rewritten so that net/http can read
+// the response. Streams don't end, so there is only a timeout for the
+// response headers.
+var radioClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := (&net.Dialer{Timeout: 15 * time.Second}).DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return &icyConn{Conn: conn}, nil
+		},
+		ResponseHeaderTimeout: 15 * time.Second,
+	},
+}
+
+// PlayRadio plays an internet radio station, the url of an Icecast or
+// Shoutcast stream or of a playlist of them, in which case each stream is
+// tried in order. The stream is proxied through the streaming server so
+// the now playing title the station sends can be shown on the device, and
+// onTitle, if not nil, is called with each new title. The station name is
+// taken from the stream when name is empty.
+func (a *Application) PlayRadio(stationURL, name string, onTitle func(title string)) error {
+	streams := []playlists.Entry{{URL: stationURL}}
+	if playlists.IsPlaylist(stationURL) {
+		it, err := playlists.NewIterator(stationURL)
+		if err != nil {
+			return errors.Wrap(err, "unable to read station playlist")
+		}
+		streams = streams[:0]
+		for it.HasNext() {
+			if e := it.Next(); isRadioURL(e.URL) {
+				streams = append(streams, e)
+			}
+		}
+	}
+
+	var station *radioStation
+	var err error
+	for _, e := range streams {
+		if station, err = probeRadio(e.URL); err == nil {
+			if station.name == "" {
+				station.name = e.Title
+			}
+			break
+		}
+		a.log("unable to open radio stream %s: %v", e.URL, err)
+	}
+	if station == nil {
+		if err == nil {
+			err = fmt.Errorf("%s has no streams", stationURL)
+		}
+		return errors.Wrap(err, "unable to open radio station")
+	}
+	if name != "" {
+		station.name = name
+	}
+	if station.name == "" {
+		station.name = stationURL
+	}
+	station.onTitle = onTitle
+
+	if err := a.startStreamingServer(); err != nil {
+		return errors.Wrap(err, "unable to start streaming server")
+	}
+	localIP, err := a.getLocalIP()
+	if err != nil {
+		return errors.Wrap(err, "unable to get local ip")
+	}
+	a.radioOnce.Do(func() {
+		a.httpServer.HandleFunc("/radio", a.serveRadio)
+	})
+	station.contentURL = fmt.Sprintf("http://%s:%d/radio?station=%s", localIP, a.serverPort, url.QueryEscape(station.streamURL))
+
+	a.radioMu.Lock()
+	a.radio = station
+	a.radioMu.Unlock()
+
+	a.MediaStart()
+	if err := a.LoadMedia(station.media(""), 0, true); err != nil {
+		return err
+	}
+	a.MediaWait()
+	return nil
+}
+
+// media returns the stream as it is loaded onto the device, showing the
+// now playing title if there is one.
+func (s *radioStation) media(title string) cast.MediaItem {
+	return cast.MediaItem{
+		ContentId:   s.contentURL,
+		ContentType: s.contentType,
+		StreamType:  "LIVE",
+		Metadata:    radioMetadata(s.name, title),
+	}
+}
+
+// radioMetadata returns the metadata for a station and its now playing
+// title. Stations usually send titles as "Artist - Title".
+func radioMetadata(name, title string) cast.MediaMetadata {
+	md := cast.MediaMetadata{
+		MetadataType: 3, // MusicTrackMediaMetadata
+		Title:        name,
+	}
+	if title == "" {
+		return md
+	}
+	md.AlbumName = name
+	if artist, song, ok := strings.Cut(title, " - "); ok && artist != "" && song != "" {
+		md.Artist = artist
+		md.Title = song
+	} else {
+		md.Title = title
+	}
+	return md
+}
+
+// radioStream returns the stream url of a radio station proxied by the
+// streaming server.
+func radioStream(contentURL string) (string, bool) {
+	u, err := url.Parse(contentURL)
+	if err != nil || u.Path != "/radio" {
+		return "", false
+	}
+	streamURL := u.Query().Get("station")
+	return streamURL, streamURL != ""
+}
+
+// isRadioURL returns whether the url is a stream the radio can proxy.
+func isRadioURL(u string) bool {
+	return strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://")
+}
+
+// openRadio starts streaming a radio station, asking for ICY metadata.
+func openRadio(ctx context.Context, streamURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, streamURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Icy-MetaData", "1")
+	res, err := radioClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("%s responded with %s", streamURL, res.Status)
+	}
+	return res, nil
+}
+
+// probeRadio opens a radio stream to find its content type and name.
+func probeRadio(streamURL string) (*radioStation, error) {
+	if !isRadioURL(streamURL) {
+		return nil, fmt.Errorf("%s is not an http url", streamURL)
+	}
+	res, err := openRadio(context.Background(), streamURL)
+	if err != nil {
+		return nil, err
+	}
+	res.Body.Close()
+
+	contentType := "audio/mpeg"
+	if mt, _, err := mime.ParseMediaType(res.Header.Get("Content-Type")); err == nil {
+		contentType = mt
+	}
+	switch contentType {
+	case "audio/aacp":
+		// The AAC+ streams of Shoutcast servers play as AAC.
+		contentType = "audio/aac"
+	case "text/html":
+		return nil, fmt.Errorf("%s is a web page, not a stream", streamURL)
+	}
+	return &radioStation{
+		streamURL:   streamURL,
+		name:        icyText([]byte(res.Header.Get("icy-name"))),
+		contentType: contentType,
+	}, nil
+}
+
+// serveRadio proxies the station being played to the device, without its
+// ICY metadata, updating the title on the device as it changes. Only the
+// station being played can be requested.
+func (a *Application) serveRadio(w http.ResponseWriter, r *http.Request) {
+	a.radioMu.Lock()
+	station := a.radio
+	a.radioMu.Unlock()
+	if station == nil || r.URL.Query().Get("station") != station.streamURL {
+		http.Error(w, "Invalid station", 400)
+		return
+	}
+
+	res, err := openRadio(r.Context(), station.streamURL)
+	if err != nil {
+		a.log("unable to open radio stream %s: %v", station.streamURL, err)
+		http.Error(w, "Unable to open station", 502)
+		return
+	}
+	defer res.Body.Close()
+
+	w.Header().Set("Content-Type", station.contentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	if r.Method == http.MethodHead {
+		return
+	}
+	metaint, _ := strconv.Atoi(res.Header.Get("icy-metaint"))
+	body := &icyReader{
+		r:       res.Body,
+		metaint: metaint,
+		onTitle: func(title string) { a.setRadioTitle(station, title) },
+	}
+	if _, err := io.Copy(w, body); err != nil {
+		a.log("radio stream %s ended: %v", station.streamURL, err)
+	}
+}
+
+// setRadioTitle shows the now playing title of the station on the device.
+// The device can have more than one connection to the stream open, so
+// the title is only sent when it changes.
+func (a *Application) setRadioTitle(station *radioStation, title string) {
+	a.radioMu.Lock()
+	if a.radio != station || station.title == title {
+		a.radioMu.Unlock()
+		return
+	}
+	station.title = title
+	a.radioMu.Unlock()
+
+	a.log("now playing %q on %s", title, station.name)
+	if station.onTitle != nil {
+		station.onTitle(title)
+	}
+	media := a.currentMedia()
+	if media == nil {
+		return
+	}
+	if err := a.sendMediaRecv(&cast.QueueUpdate{
+		PayloadHeader:  cast.QueueUpdateHeader,
+		MediaSessionId: media.MediaSessionId,
+		Items: []cast.QueueItem{{
+			ItemId: media.CurrentItemId,
+			Media:  station.media(title),
+		}},
+	}); err != nil {
+		a.log("unable to update radio title: %v", err)
+	}
+}
+
+// icyReader reads an ICY stream, calling onTitle with the StreamTitle of
+// each metadata block and leaving only the audio. A metadata block is
+// sent after every metaint bytes of audio, and is a length in 16 byte
+// blocks followed by text like "StreamTitle='Artist - Title';StreamUrl=”;".
+type icyReader struct {
+	r       io.Reader
+	metaint int
+	// audio is the number of bytes of audio read since the last metadata.
+	audio   int
+	onTitle func(title string)
+}
+
+func (ir *icyReader) Read(p []byte) (int, error) {
+	if ir.metaint <= 0 {
+		return ir.r.Read(p)
+	}
+	if ir.audio == ir.metaint {
+		if err := ir.readMetadata(); err != nil {
+			return 0, err
+		}
+		ir.audio = 0
+	}
+	if len(p) > ir.metaint-ir.audio {
+		p = p[:ir.metaint-ir.audio]
+	}
+	n, err := ir.r.Read(p)
+	ir.audio += n
+	return n, err
+}
+
+func (ir *icyReader) readMetadata() error {
+	var length [1]byte
+	if _, err := io.ReadFull(ir.r, length[:]); err != nil {
+		return err
+	}
+	if length[0] == 0 {
+		return nil
+	}
+	meta := make([]byte, int(length[0])*16)
+	if _, err := io.ReadFull(ir.r, meta); err != nil {
+		return err
+	}
+	if title, ok := streamTitle(meta); ok && ir.onTitle != nil {
+		ir.onTitle(title)
+	}
+	return nil
+}
+
+// streamTitle returns the StreamTitle of an ICY metadata block.
+func streamTitle(meta []byte) (string, bool) {
+	const key = "StreamTitle='"
+	i := bytes.Index(meta, []byte(key))
+	if i < 0 {
+		return "", false
+	}
+	value := bytes.TrimRight(meta[i+len(key):], "\x00")
+	// Titles can contain quotes, so the value ends before the next key,
+	// or at the last "';" in the block.
+	if end := bytes.Index(value, []byte("';Stream")); end >= 0 {
+		value = value[:end]
+	} else if end := bytes.LastIndex(value, []byte("';")); end >= 0 {
+		value = value[:end]
+	}
+	return icyText(value), true
+}
+
+// icyText decodes ICY text, which is UTF-8 or, from older servers,
+// Latin-1.
+func icyText(b []byte) string {
+	if utf8.Valid(b) {
+		return strings.TrimSpace(string(b))
+	}
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+	return strings.TrimSpace(string(r))
+}
+
+// icyConn is a connection to a radio server that rewrites a Shoutcast
+// "ICY" status line to HTTP/1.0.
+type icyConn struct {
+	net.Conn
+	checked bool
+	pending []byte
+}
+
+func (c *icyConn) Read(p []byte) (int, error) {
+	if !c.checked {
+		c.checked = true
+		status := make([]byte, 4)
+		n, err := io.ReadFull(c.Conn, status)
+		if n == 0 {
+			return 0, err
+		}
+		c.pending = status[:n]
+		if string(c.pending) == "ICY " {
+			c.pending = []byte("HTTP/1.0 ")
+		}
+	}
+	if len(c.pending) > 0 {
+		n := copy(p, c.pending)
+		c.pending = c.pending[n:]
+		return n, nil
+	}
+	return c.Conn.Read(p)
+}
@@ -0,0 +1,148 @@
+package application
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// icyStream returns a stream of audio with a metadata block after every
+// metaint bytes.
+func icyStream(metaint int, audio []byte, meta ...string) []byte {
+	var b bytes.Buffer
+	for i := 0; len(audio) > 0; i++ {
+		n := min(metaint, len(audio))
+		b.Write(audio[:n])
+		audio = audio[n:]
+		if n < metaint {
+			break
+		}
+		m := ""
+		if i < len(meta) {
+			m = meta[i]
+		}
+		blocks := (len(m) + 15) / 16
+		b.WriteByte(byte(blocks))
+		b.WriteString(m)
+		b.Write(make([]byte, blocks*16-len(m)))
+	}
+	return b.Bytes()
+}
+
+func TestIcyReader(t *testing.T) {
+	assertions := require.New(t)
+	audio := bytes.Repeat([]byte("0123456789"), 10)
+	stream := icyStream(16, audio,
+		"StreamTitle='Belle and Sebastian - Get Me Away from Here, I'm Dying';StreamUrl='';",
+		"",
+		"StreamTitle='Camera Obscura - Lloyd, I'm Ready to Be Heartbroken';",
+	)
+
+	var titles []string
+	ir := &icyReader{
+		r:       bytes.NewReader(stream),
+		metaint: 16,
+		onTitle: func(title string) { titles = append(titles, title) },
+	}
+	got, err := io.ReadAll(ir)
+	assertions.NoError(err)
+	assertions.Equal(audio, got)
+	assertions.Equal([]string{
+		"Belle and Sebastian - Get Me Away from Here, I'm Dying",
+		"Camera Obscura - Lloyd, I'm Ready to Be Heartbroken",
+	}, titles)
+}
+
+func TestIcyReaderWithoutMetadata(t *testing.T) {
+	assertions := require.New(t)
+	ir := &icyReader{r: bytes.NewReader([]byte("audio"))}
+	got, err := io.ReadAll(ir)
+	assertions.NoError(err)
+	assertions.Equal("audio", string(got))
+}
+
+func TestStreamTitle(t *testing.T) {
+	assertions := require.New(t)
+	for meta, want := range map[string]string{
+		"StreamTitle='Artist - Title';":                 "Artist - Title",
+		"StreamTitle='It's Not';StreamUrl='http://x';":  "It's Not",
+		"StreamTitle='';\x00\x00":                       "",
+		"StreamTitle='Caf\xe9 del Mar';":                "Café del Mar",
+		"StreamTitle='Truncated\x00\x00":                "Truncated",
+		"StreamUrl='http://x';StreamTitle='After URL';": "After URL",
+	} {
+		got, ok := streamTitle([]byte(meta))
+		assertions.True(ok, meta)
+		assertions.Equal(want, got, meta)
+	}
+	_, ok := streamTitle([]byte("StreamUrl='http://x';"))
+	assertions.False(ok)
+}
+
+func TestRadioMetadata(t *testing.T) {
+	assertions := require.New(t)
+	md := radioMetadata("Indie Pop Rocks!", "")
+	assertions.Equal("Indie Pop Rocks!", md.Title)
+	assertions.Empty(md.Artist)
+
+	md = radioMetadata("Indie Pop Rocks!", "Alvvays - Archie, Marry Me")
+	assertions.Equal("Archie, Marry Me", md.Title)
+	assertions.Equal("Alvvays", md.Artist)
+	assertions.Equal("Indie Pop Rocks!", md.AlbumName)
+
+	md = radioMetadata("Indie Pop Rocks!", "Station ID")
+	assertions.Equal("Station ID", md.Title)
+	assertions.Empty(md.Artist)
+}
+
+func TestProbeRadio(t *testing.T) {
+	assertions := require.New(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assertions.Equal("1", r.Header.Get("Icy-MetaData"))
+		w.Header().Set("Content-Type", "audio/aacp")
+		w.Header().Set("icy-name", "Indie Pop Rocks!")
+		w.Header().Set("icy-metaint", "16000")
+		w.Write([]byte("audio"))
+	}))
+	defer ts.Close()
+
+	station, err := probeRadio(ts.URL)
+	assertions.NoError(err)
+	assertions.Equal("Indie Pop Rocks!", station.name)
+	assertions.Equal("audio/aac", station.contentType)
+
+	_, err = probeRadio("file:///radio.mp3")
+	assertions.Error(err)
+}
+
+func TestProbeRadioShoutcast(t *testing.T) {
+	assertions := require.New(t)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assertions.NoError(err)
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Read(make([]byte, 1024))
+		conn.Write([]byte("ICY 200 OK\r\nicy-name:Shoutcast Radio\r\ncontent-type:audio/mpeg\r\nicy-metaint:8192\r\n\r\naudio"))
+	}()
+
+	station, err := probeRadio("http://" + l.Addr().String() + "/")
+	assertions.NoError(err)
+	assertions.Equal("Shoutcast Radio", station.name)
+	assertions.Equal("audio/mpeg", station.contentType)
+}
+
+func TestContentIDForRadio(t *testing.T) {
+	assertions := require.New(t)
+	assertions.Equal("https://ice4.somafm.com/indiepop-64-aac",
+		contentIDFor("http://192.168.1.2:8080/radio?station=https%3A%2F%2Fice4.somafm.com%2Findiepop-64-aac"))
+}
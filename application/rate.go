@@ -0,0 +1,216 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vishen/go-chromecast/cast"
+	"github.com/vishen/go-chromecast/storage"
+)
+
+// The playback rates supported by the Default Media Receiver.
+const (
+	MinPlaybackRate float32 = 0.5
+	MaxPlaybackRate float32 = 2
+)
+
+// playbackRate keeps the media loaded by this application playing at the
+// rate chosen for the playlist being played, or for the device.
+type playbackRate struct {
+	mu sync.Mutex
+	// playlist is the playlist being played, the rate is kept for it
+	// rather than for the device when it is set.
+	playlist string
+	// rate is the chosen rate, zero when none has been chosen. It is
+	// loaded from the store the first time it is needed.
+	rate   float32
+	loaded bool
+	// contentID is the media the rate was last kept for, and pending a
+	// rate that was sent to the device that it hasn't reported yet.
+	contentID string
+	pending   float32
+}
+
+// ParsePlaybackRate parses a playback rate, ie: 1.5 or 1.5x.
+func ParsePlaybackRate(s string) (float32, error) {
+	f, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "x"), 32)
+	if err != nil {
+		return 0, fmt.Errorf("%q isn't a playback rate", s)
+	}
+	rate := float32(f)
+	if rate < MinPlaybackRate || rate > MaxPlaybackRate {
+		return 0, ErrPlaybackRateOutOfRange
+	}
+	return rate, nil
+}
+
+// MediaPlaybackRate returns the playback rate of the media, which is 1
+// when the device doesn't report it, or 0 when there is no media.
+func MediaPlaybackRate(media *cast.Media) float32 {
+	switch {
+	case media == nil:
+		return 0
+	case media.PlaybackRate == 0:
+		return 1
+	}
+	return media.PlaybackRate
+}
+
+// SetPlaylist sets the playlist being played, a playlist file, directory
+// or url, which the playback rate is then kept for instead of the device.
+// Playlist files played with Load set it themselves.
+func (a *Application) SetPlaylist(name string) {
+	if !strings.Contains(name, "://") {
+		if abs, err := filepath.Abs(name); err == nil {
+			name = abs
+		}
+	}
+	a.rate.mu.Lock()
+	defer a.rate.mu.Unlock()
+	a.rate.playlist = name
+	a.rate.rate, a.rate.loaded = 0, false
+}
+
+// SetPlaybackRate sets the playback rate of the media. When this
+// application loaded the media the rate is kept for the playlist being
+// played, or the device, so the media played after it plays at the same
+// rate. Otherwise it is left to whatever loaded the media to keep it.
+func (a *Application) SetPlaybackRate(rate float32) error {
+	if rate < MinPlaybackRate || rate > MaxPlaybackRate {
+		return ErrPlaybackRateOutOfRange
+	}
+	media := a.currentMedia()
+	if media == nil {
+		return ErrNoMediaPlaybackRate
+	}
+	if !a.loadedMedia(media) {
+		return a.sendPlaybackRate(media.MediaSessionId, rate)
+	}
+
+	a.rate.mu.Lock()
+	a.rate.rate, a.rate.loaded, a.rate.pending = rate, true, rate
+	key := a.playbackRateKey()
+	a.rate.mu.Unlock()
+
+	if err := a.sendPlaybackRate(media.MediaSessionId, rate); err != nil {
+		return err
+	}
+	a.savePlaybackRate(key, rate)
+	return nil
+}
+
+func (a *Application) sendPlaybackRate(mediaSessionID int, rate float32) error {
+	return a.sendMediaRecv(&cast.MediaHeader{
+		PayloadHeader:  cast.SetPlaybackRateHeader,
+		MediaSessionId: mediaSessionID,
+		PlaybackRate:   rate,
+	})
+}
+
+// keepPlaybackRate keeps the media loaded by this application, with the
+// content id, playing at the chosen rate. The device goes back to the
+// normal rate for new media, so the rate is set again when the media
+// changes, and a rate changed by another sender while the media plays,
+// ie: the rate command, becomes the chosen rate.
+func (a *Application) keepPlaybackRate(contentID string, media cast.Media) {
+	if contentID == "" || media.PlayerState != "PLAYING" {
+		return
+	}
+	current := MediaPlaybackRate(&media)
+
+	r := &a.rate
+	r.mu.Lock()
+	a.loadPlaybackRate()
+	var send, save float32
+	switch {
+	case contentID != r.contentID:
+		r.contentID, r.pending = contentID, 0
+		if r.rate != 0 && r.rate != current {
+			send, r.pending = r.rate, r.rate
+		}
+	case r.pending != 0:
+		if current == r.pending {
+			r.pending = 0
+		}
+	case current != r.rate && (r.rate != 0 || current != 1):
+		r.rate = current
+		save = current
+	}
+	key := a.playbackRateKey()
+	r.mu.Unlock()
+
+	if send != 0 {
+		a.log("setting the playback rate of %q to %v", contentID, send)
+		if err := a.sendPlaybackRate(media.MediaSessionId, send); err != nil {
+			a.log("unable to set the playback rate: %v", err)
+		}
+	}
+	if save != 0 {
+		a.savePlaybackRate(key, save)
+	}
+}
+
+// playbackRateKeys returns the keys the playback rate is stored under,
+// the playlist being played first and then the device. a.rate.mu must be
+// held.
+func (a *Application) playbackRateKeys() []string {
+	var keys []string
+	if a.rate.playlist != "" {
+		keys = append(keys, "playlist/"+a.rate.playlist)
+	}
+	if a.deviceUUID != "" {
+		keys = append(keys, "device/"+a.deviceUUID)
+	} else if a.deviceName != "" {
+		keys = append(keys, "device/"+a.deviceName)
+	}
+	return keys
+}
+
+// playbackRateKey returns the key the chosen playback rate is saved
+// under, or an empty string if there is nothing to keep it for.
+// a.rate.mu must be held.
+func (a *Application) playbackRateKey() string {
+	if keys := a.playbackRateKeys(); len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
+
+// loadPlaybackRate loads the rate chosen for the playlist, falling back to
+// the one chosen for the device. a.rate.mu must be held.
+func (a *Application) loadPlaybackRate() {
+	if a.rate.loaded {
+		return
+	}
+	a.rate.loaded = true
+	if a.cacheDisabled || a.store == nil {
+		return
+	}
+	for _, key := range a.playbackRateKeys() {
+		b, err := a.store.Load(storage.BucketPlaybackRates, key)
+		if err != nil || len(b) == 0 {
+			continue
+		}
+		var rate float32
+		if err := json.Unmarshal(b, &rate); err == nil && rate >= MinPlaybackRate && rate <= MaxPlaybackRate {
+			a.rate.rate = rate
+			return
+		}
+	}
+}
+
+func (a *Application) savePlaybackRate(key string, rate float32) {
+	if a.cacheDisabled || a.store == nil || key == "" {
+		return
+	}
+	b, _ := json.Marshal(rate)
+	if err := a.store.Save(storage.BucketPlaybackRates, key, b); err != nil {
+		log.WithField("package", "application").WithError(err).Warn("unable to save playback rate")
+	}
+}
@@ -0,0 +1,147 @@
+package application
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vishen/go-chromecast/cast"
+	mockCast "github.com/vishen/go-chromecast/cast/mocks"
+	"github.com/vishen/go-chromecast/storage"
+)
+
+// newRateTestApplication returns an application that records the playback
+// rates it sends to the device.
+func newRateTestApplication(t *testing.T) (*Application, storage.Store, *[]float32) {
+	app, store := newTestApplication()
+	app.application = &cast.Application{AppId: defaultChromecastAppID, TransportId: "transport-0"}
+	var sent []float32
+	conn := &mockCast.Conn{}
+	conn.On("Send", mock.Anything, mock.Anything, defaultSender, "transport-0", namespaceMedia).
+		Run(func(args mock.Arguments) {
+			header := args.Get(1).(*cast.MediaHeader)
+			require.Equal(t, cast.SetPlaybackRateHeader.Type, header.Type)
+			sent = append(sent, header.PlaybackRate)
+		}).Return(nil)
+	app.conn = conn
+	return app, store, &sent
+}
+
+func savedPlaybackRate(t *testing.T, store storage.Store, key string) float32 {
+	b, err := store.Load(storage.BucketPlaybackRates, key)
+	require.NoError(t, err)
+	if b == nil {
+		return 0
+	}
+	var rate float32
+	require.NoError(t, json.Unmarshal(b, &rate))
+	return rate
+}
+
+func TestParsePlaybackRate(t *testing.T) {
+	assertions := require.New(t)
+	rate, err := ParsePlaybackRate("1.5")
+	assertions.NoError(err)
+	assertions.Equal(float32(1.5), rate)
+	rate, err = ParsePlaybackRate("0.75x")
+	assertions.NoError(err)
+	assertions.Equal(float32(0.75), rate)
+	_, err = ParsePlaybackRate("3")
+	assertions.ErrorIs(err, ErrPlaybackRateOutOfRange)
+	_, err = ParsePlaybackRate("fast")
+	assertions.ErrorContains(err, `"fast" isn't a playback rate`)
+
+	assertions.Zero(MediaPlaybackRate(nil))
+	assertions.Equal(float32(1), MediaPlaybackRate(&cast.Media{}))
+}
+
+func TestSetPlaybackRate(t *testing.T) {
+	assertions := require.New(t)
+	app, store, sent := newRateTestApplication(t)
+
+	assertions.ErrorIs(app.SetPlaybackRate(1.5), ErrNoMediaPlaybackRate)
+	loaded := "http://example.com/0.mp3"
+	app.expectContent(loaded)
+	app.media = &cast.Media{MediaSessionId: 1, PlayerState: "PLAYING", Media: cast.MediaItem{ContentId: loaded}}
+	assertions.ErrorIs(app.SetPlaybackRate(4), ErrPlaybackRateOutOfRange)
+	assertions.NoError(app.SetPlaybackRate(1.5))
+	assertions.Equal([]float32{1.5}, *sent)
+	assertions.Equal(float32(1.5), savedPlaybackRate(t, store, "device/abc"))
+
+	// Media loaded later on the device plays at the same rate.
+	app, _, sent = newRateTestApplication(t)
+	app.store = store
+	content := "http://example.com/1.mp3"
+	app.expectContent(content)
+	media := cast.Media{MediaSessionId: 2, PlayerState: "PLAYING", Media: cast.MediaItem{ContentId: content}}
+	app.keepPlaybackRate(app.trackMediaStatus(media), media)
+	assertions.Equal([]float32{1.5}, *sent)
+
+	// Media that wasn't loaded by the application is left alone.
+	other := cast.Media{MediaSessionId: 3, PlayerState: "PLAYING", Media: cast.MediaItem{ContentId: "http://example.com/other.mp3"}}
+	app.keepPlaybackRate(app.trackMediaStatus(other), other)
+	assertions.Len(*sent, 1)
+}
+
+func TestSetPlaybackRateNotLoaded(t *testing.T) {
+	assertions := require.New(t)
+	app, store, sent := newRateTestApplication(t)
+
+	// The rate command sets the rate of media loaded by another
+	// go-chromecast process, which keeps the rate itself.
+	app.media = &cast.Media{MediaSessionId: 1, PlayerState: "PLAYING", Media: cast.MediaItem{ContentId: "http://example.com/1.mp3"}}
+	assertions.NoError(app.SetPlaybackRate(1.5))
+	assertions.Equal([]float32{1.5}, *sent)
+	keys, err := store.Keys(storage.BucketPlaybackRates, "")
+	assertions.NoError(err)
+	assertions.Empty(keys)
+}
+
+func TestKeepPlaybackRatePlaylist(t *testing.T) {
+	assertions := require.New(t)
+	app, store, sent := newRateTestApplication(t)
+	dir := t.TempDir()
+	app.SetPlaylist(dir)
+	playlistKey := "playlist/" + dir
+
+	first, second := "http://example.com/1.mp3", "http://example.com/2.mp3"
+	app.expectContent(first, second)
+	status := func(media cast.Media) {
+		app.keepPlaybackRate(app.trackMediaStatus(media), media)
+	}
+	status(cast.Media{
+		PlayerState:   "PLAYING",
+		CurrentItemId: 1,
+		Media:         cast.MediaItem{ContentId: first},
+		Items: []cast.QueueItem{
+			{ItemId: 1, Media: cast.MediaItem{ContentId: first}},
+			{ItemId: 2, Media: cast.MediaItem{ContentId: second}},
+		},
+	})
+	assertions.Empty(*sent)
+
+	// The rate is changed by another sender while the media plays.
+	status(cast.Media{PlayerState: "PLAYING", CurrentItemId: 1, PlaybackRate: 1.25})
+	assertions.Empty(*sent)
+	assertions.Equal(float32(1.25), savedPlaybackRate(t, store, playlistKey))
+	assertions.Zero(savedPlaybackRate(t, store, "device/abc"))
+
+	// The device goes back to the normal rate for the next item.
+	status(cast.Media{PlayerState: "PLAYING", CurrentItemId: 2})
+	assertions.Equal([]float32{1.25}, *sent)
+	// A status from before the device changed the rate isn't taken as the
+	// rate being changed back.
+	status(cast.Media{PlayerState: "PLAYING", CurrentItemId: 2})
+	status(cast.Media{PlayerState: "PLAYING", CurrentItemId: 2, PlaybackRate: 1.25})
+	assertions.Equal([]float32{1.25}, *sent)
+	assertions.Equal(float32(1.25), savedPlaybackRate(t, store, playlistKey))
+
+	// Playing the playlist again keeps its rate.
+	app, _, sent = newRateTestApplication(t)
+	app.store = store
+	app.SetPlaylist(dir)
+	app.expectContent(first)
+	status(cast.Media{PlayerState: "PLAYING", CurrentItemId: 1, Media: cast.MediaItem{ContentId: first}})
+	assertions.Equal([]float32{1.25}, *sent)
+}
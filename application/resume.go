@@ -0,0 +1,63 @@
+package application
+
+import (
+	"time"
+)
+
+const (
+	// DefaultResumeThreshold is how close to the end media has to be
+	// played for it to count as finished.
+	DefaultResumeThreshold = 30 * time.Second
+
+	// positionPollInterval is how often the device is asked for the
+	// position of the media while waiting for it to finish, as the device
+	// only sends a MEDIA_STATUS itself when the player state changes.
+	positionPollInterval = 5 * time.Second
+)
+
+// ResumePosition returns the position in seconds to resume the content
+// from. If the content was played to within threshold of its end it
+// counts as finished, and should be played from the start.
+func (a *Application) ResumePosition(contentID string, threshold time.Duration) (position int, finished bool) {
+	a.playedItemsMu.Lock()
+	defer a.playedItemsMu.Unlock()
+
+	pi, ok := a.playedItems[contentID]
+	if !ok {
+		return 0, false
+	}
+	if pi.IsFinished(threshold) {
+		return 0, true
+	}
+	return int(pi.Position), false
+}
+
+// IsFinished returns whether the media was marked as watched, or played
+// to within threshold of its end.
+func (pi PlayedItem) IsFinished(threshold time.Duration) bool {
+	if pi.Watched {
+		return true
+	}
+	return pi.Duration > 0 && pi.Position > 0 && secondsToDuration(pi.Duration-pi.Position) <= threshold
+}
+
+// pollPosition asks the device for the media status until stop is called,
+// so the position is recorded while waiting for media to finish.
+func (a *Application) pollPosition() (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(positionPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			if _, err := a.MediaStatus(); err != nil {
+				a.log("unable to get media position: %v", err)
+			}
+		}
+	}()
+	return func() { close(done) }
+}
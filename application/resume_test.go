@@ -0,0 +1,72 @@
+package application
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vishen/go-chromecast/cast"
+	mockCast "github.com/vishen/go-chromecast/cast/mocks"
+	pb "github.com/vishen/go-chromecast/cast/proto"
+	"github.com/vishen/go-chromecast/storage"
+)
+
+func mediaStatusMessage(t *testing.T, media cast.Media) *pb.CastMessage {
+	payloadBytes, err := json.Marshal(&cast.MediaStatusResponse{
+		PayloadHeader: cast.PayloadHeader{Type: "MEDIA_STATUS"},
+		Status:        []cast.Media{media},
+	})
+	require.NoError(t, err)
+	payloadString := string(payloadBytes)
+	return &pb.CastMessage{PayloadUtf8: &payloadString}
+}
+
+func TestApplicationResumePosition(t *testing.T) {
+	assertions := require.New(t)
+
+	recvChan := make(chan *pb.CastMessage, 5)
+	conn := &mockCast.Conn{}
+	conn.On("MsgChan").Return(recvChan)
+	store := storage.NewMemoryStore()
+	app := NewApplication(WithConnection(conn), WithStore(store))
+
+	url := "http://example.com/episode.mp3"
+	other := "http://example.com/other.mp3"
+	app.expectContent(url)
+
+	// Media loaded by anything else isn't recorded.
+	recvChan <- mediaStatusMessage(t, cast.Media{
+		PlayerState: "PLAYING",
+		CurrentTime: 30,
+		Media:       cast.MediaItem{ContentId: other, Duration: 600},
+	})
+	recvChan <- mediaStatusMessage(t, cast.Media{
+		PlayerState: "PLAYING",
+		CurrentTime: 60,
+		Media:       cast.MediaItem{ContentId: url, Duration: 600},
+	})
+	// Later statuses only include the media when it changes.
+	recvChan <- mediaStatusMessage(t, cast.Media{PlayerState: "PAUSED", CurrentTime: 120.5})
+
+	assertions.Eventually(func() bool {
+		position, finished := app.ResumePosition(url, DefaultResumeThreshold)
+		return position == 120 && !finished
+	}, time.Second, 10*time.Millisecond)
+
+	b, err := store.Load(storage.BucketPlayedItems, url)
+	assertions.NoError(err)
+	pi := PlayedItem{}
+	assertions.NoError(json.Unmarshal(b, &pi))
+	assertions.Equal(float32(120.5), pi.Position)
+
+	b, err = store.Load(storage.BucketPlayedItems, other)
+	assertions.NoError(err)
+	assertions.Empty(b)
+
+	recvChan <- mediaStatusMessage(t, cast.Media{PlayerState: "PLAYING", CurrentTime: 590})
+	assertions.Eventually(func() bool {
+		position, finished := app.ResumePosition(url, DefaultResumeThreshold)
+		return position == 0 && finished
+	}, time.Second, 10*time.Millisecond, "media within the threshold of the end should count as finished")
+}
@@ -0,0 +1,195 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vishen/go-chromecast/cast"
+)
+
+// SleepTimer stops the media playing on a device after a time. The volume
+// is lowered gradually over the fade before the end, and once the media
+// has stopped it is put back to where it was so whatever plays next isn't
+// silent.
+//
+// The timer is interrupted, with ErrSleepInterrupted, if someone uses the
+// device while the volume is fading: changing the volume or muting leaves
+// the volume as they set it, and pausing or stopping the media puts the
+// volume back.
+type SleepTimer struct {
+	ends   time.Time
+	fade   time.Duration
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+// StartSleepTimer starts a timer that stops the media on the device after
+// a time, fading the volume out over the last fade of it.
+func StartSleepTimer(app App, after, fade time.Duration) (*SleepTimer, error) {
+	if err := CheckSleepTimer(after, fade); err != nil {
+		return nil, err
+	}
+	fade = min(fade, after)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &SleepTimer{
+		ends:   time.Now().Add(after),
+		fade:   fade,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(t.done)
+		err := t.run(ctx, app, after-fade)
+		t.mu.Lock()
+		t.err = err
+		t.mu.Unlock()
+	}()
+	return t, nil
+}
+
+// CheckSleepTimer returns the error StartSleepTimer would for the times,
+// so they can be checked before any timer is started.
+func CheckSleepTimer(after, fade time.Duration) error {
+	if after <= 0 {
+		return fmt.Errorf("sleep timer must be longer than 0s, not %s", after)
+	}
+	if fade < 0 {
+		return fmt.Errorf("fade must not be negative, not %s", fade)
+	}
+	return nil
+}
+
+// Ends returns when the media is stopped.
+func (t *SleepTimer) Ends() time.Time {
+	return t.ends
+}
+
+// Fade returns how long the volume fades out for before the end.
+func (t *SleepTimer) Fade() time.Duration {
+	return t.fade
+}
+
+// Remaining returns how long is left until the media is stopped.
+func (t *SleepTimer) Remaining() time.Duration {
+	return max(time.Until(t.ends), 0)
+}
+
+// Cancel stops the timer, putting the volume back if it is fading, and
+// waits for it to finish.
+func (t *SleepTimer) Cancel() {
+	t.cancel()
+	<-t.done
+}
+
+// Done is closed when the timer has finished.
+func (t *SleepTimer) Done() <-chan struct{} {
+	return t.done
+}
+
+// Err returns why the timer finished: nil when the media was stopped,
+// ErrSleepInterrupted when the device was used while fading, or
+// context.Canceled when the timer was cancelled. It is nil until Done is
+// closed.
+func (t *SleepTimer) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+func (t *SleepTimer) run(ctx context.Context, app App, wait time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+	}
+
+	if err := app.Update(); err != nil {
+		return errors.Wrap(err, "unable to update device")
+	}
+	_, media, volume := app.Status()
+	if !isPlaying(media) {
+		return ErrSleepInterrupted
+	}
+	if volume == nil {
+		return errors.New("unable to get the volume of the device")
+	}
+	level := volume.Level
+
+	if t.fade > 0 && !volume.Muted {
+		if err := t.fadeOut(ctx, app, level); err != nil {
+			return err
+		}
+	}
+
+	if err := app.StopMedia(); err != nil && err != ErrNoMediaStop {
+		return errors.Wrap(err, "unable to stop media")
+	}
+	return errors.Wrap(app.SetVolume(level), "unable to restore volume")
+}
+
+// fadeOut lowers the volume from level to nothing over the fade, stopping
+// early if the device is used.
+func (t *SleepTimer) fadeOut(ctx context.Context, app App, level float32) error {
+	interval := min(t.fade/fadeSteps, time.Second)
+	// The device is being used if the volume is further from what it was
+	// set to than a step of the fade.
+	tolerance := 0.01 + float64(level)*float64(interval)/float64(t.fade)
+
+	// The volume can't be lowered past the minimum of the device.
+	settings := app.VolumeSettings()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	start := time.Now()
+	current := level
+	for {
+		select {
+		case <-ctx.Done():
+			app.SetVolume(level)
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		if err := app.Update(); err != nil {
+			app.SetVolume(level)
+			return errors.Wrap(err, "unable to update device")
+		}
+		_, media, volume := app.Status()
+		if !isPlaying(media) {
+			app.SetVolume(level)
+			return ErrSleepInterrupted
+		}
+		if volume != nil && (volume.Muted || math.Abs(float64(volume.Level-current)) > tolerance) {
+			return ErrSleepInterrupted
+		}
+
+		elapsed := time.Since(start)
+		if elapsed >= t.fade {
+			// A late tick can skip the last steps, so the fade always
+			// ends at the lowest volume.
+			if err := app.SetVolume(settings.Clamp(0)); err != nil {
+				app.SetVolume(level)
+				return errors.Wrap(err, "unable to set volume")
+			}
+			return nil
+		}
+		current = settings.Clamp(level * float32(1-float64(elapsed)/float64(t.fade)))
+		if err := app.SetVolume(current); err != nil {
+			app.SetVolume(level)
+			return errors.Wrap(err, "unable to set volume")
+		}
+	}
+}
+
+// isPlaying returns whether there is media that is playing, or about to.
+func isPlaying(media *cast.Media) bool {
+	return media != nil && (media.PlayerState == "PLAYING" || media.PlayerState == "BUFFERING")
+}
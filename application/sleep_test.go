@@ -0,0 +1,175 @@
+package application_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vishen/go-chromecast/application"
+	"github.com/vishen/go-chromecast/application/mocks"
+	"github.com/vishen/go-chromecast/cast"
+)
+
+// sleepDevice is a device whose volume is set by a sleep timer.
+type sleepDevice struct {
+	mu          sync.Mutex
+	playerState string
+	volume      cast.Volume
+	set         []float32
+	settings    application.VolumeSettings
+	// touch, if set, is called with the number of times the volume has
+	// been set, to use the device while the volume fades.
+	touch func(d *sleepDevice, n int)
+}
+
+func newSleepDevice(t *testing.T, d *sleepDevice) *mocks.App {
+	app := mocks.NewApp(t)
+	app.On("Update").Return(nil).Maybe()
+	app.On("Status").Return(func() (*cast.Application, *cast.Media, *cast.Volume) {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		volume := d.volume
+		return nil, &cast.Media{PlayerState: d.playerState}, &volume
+	}).Maybe()
+	if d.settings.Max == 0 {
+		d.settings = application.DefaultVolumeSettings
+	}
+	app.On("VolumeSettings").Return(d.settings).Maybe()
+	app.On("SetVolume", mock.Anything).Run(func(args mock.Arguments) {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		d.volume.Level = d.settings.Clamp(args.Get(0).(float32))
+		d.set = append(d.set, d.volume.Level)
+		if d.touch != nil {
+			d.touch(d, len(d.set))
+		}
+	}).Return(nil).Maybe()
+	return app
+}
+
+func TestSleepTimer(t *testing.T) {
+	assertions := require.New(t)
+	d := &sleepDevice{playerState: "PLAYING", volume: cast.Volume{Level: 0.5}}
+	app := newSleepDevice(t, d)
+	app.On("StopMedia").Return(nil).Once()
+
+	timer, err := application.StartSleepTimer(app, 60*time.Millisecond, 50*time.Millisecond)
+	assertions.NoError(err)
+	<-timer.Done()
+	assertions.NoError(timer.Err())
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	assertions.Greater(len(d.set), 2)
+	for i, level := range d.set[:len(d.set)-1] {
+		assertions.Less(level, float32(0.5))
+		if i > 0 {
+			assertions.Less(level, d.set[i-1])
+		}
+	}
+	// The volume is put back once the media has stopped.
+	assertions.Equal(float32(0.5), d.set[len(d.set)-1])
+}
+
+func TestSleepTimerVolumeLimits(t *testing.T) {
+	assertions := require.New(t)
+	d := &sleepDevice{playerState: "PLAYING", volume: cast.Volume{Level: 0.5}}
+	d.settings = application.DefaultVolumeSettings
+	d.settings.Min = 0.2
+	app := newSleepDevice(t, d)
+	app.On("StopMedia").Return(nil).Once()
+
+	// The fade stops at the minimum volume, which isn't the device being
+	// used.
+	timer, err := application.StartSleepTimer(app, 10*time.Millisecond, 50*time.Millisecond)
+	assertions.NoError(err)
+	<-timer.Done()
+	assertions.NoError(timer.Err())
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	assertions.Equal(float32(0.2), d.set[len(d.set)-2])
+	assertions.Equal(float32(0.5), d.volume.Level)
+}
+
+func TestSleepTimerVolumeChanged(t *testing.T) {
+	assertions := require.New(t)
+	d := &sleepDevice{
+		playerState: "PLAYING",
+		volume:      cast.Volume{Level: 0.5},
+		touch: func(d *sleepDevice, n int) {
+			if n == 3 {
+				d.volume.Level = 0.3
+			}
+		},
+	}
+	app := newSleepDevice(t, d)
+
+	timer, err := application.StartSleepTimer(app, 100*time.Millisecond, 100*time.Millisecond)
+	assertions.NoError(err)
+	<-timer.Done()
+	assertions.ErrorIs(timer.Err(), application.ErrSleepInterrupted)
+
+	// The volume is left where it was changed to.
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	assertions.Len(d.set, 3)
+	assertions.Equal(float32(0.3), d.volume.Level)
+	app.AssertNotCalled(t, "StopMedia")
+}
+
+func TestSleepTimerPaused(t *testing.T) {
+	assertions := require.New(t)
+	d := &sleepDevice{
+		playerState: "PLAYING",
+		volume:      cast.Volume{Level: 0.5},
+		touch: func(d *sleepDevice, n int) {
+			if n == 2 {
+				d.playerState = "PAUSED"
+			}
+		},
+	}
+	app := newSleepDevice(t, d)
+
+	timer, err := application.StartSleepTimer(app, 100*time.Millisecond, 100*time.Millisecond)
+	assertions.NoError(err)
+	<-timer.Done()
+	assertions.ErrorIs(timer.Err(), application.ErrSleepInterrupted)
+
+	// The volume is put back for when the media is unpaused.
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	assertions.Equal(float32(0.5), d.volume.Level)
+	app.AssertNotCalled(t, "StopMedia")
+}
+
+func TestSleepTimerNothingPlaying(t *testing.T) {
+	assertions := require.New(t)
+	d := &sleepDevice{playerState: "IDLE", volume: cast.Volume{Level: 0.5}}
+	app := newSleepDevice(t, d)
+
+	timer, err := application.StartSleepTimer(app, 10*time.Millisecond, 0)
+	assertions.NoError(err)
+	<-timer.Done()
+	assertions.ErrorIs(timer.Err(), application.ErrSleepInterrupted)
+	app.AssertNotCalled(t, "SetVolume", mock.Anything)
+}
+
+func TestSleepTimerCancel(t *testing.T) {
+	assertions := require.New(t)
+	app := mocks.NewApp(t)
+
+	timer, err := application.StartSleepTimer(app, time.Hour, 2*time.Minute)
+	assertions.NoError(err)
+	assertions.InDelta(time.Hour, timer.Remaining(), float64(time.Second))
+	timer.Cancel()
+	assertions.ErrorIs(timer.Err(), context.Canceled)
+
+	_, err = application.StartSleepTimer(app, 0, 0)
+	assertions.Error(err)
+	assertions.Error(application.CheckSleepTimer(time.Minute, -time.Second))
+	assertions.NoError(application.CheckSleepTimer(time.Minute, 2*time.Minute))
+}
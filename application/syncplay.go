@@ -0,0 +1,362 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vishen/go-chromecast/cast"
+)
+
+const (
+	defaultSyncThreshold     = 250 * time.Millisecond
+	defaultSyncInterval      = 5 * time.Second
+	defaultSyncSeekThreshold = 3 * time.Second
+	defaultLatencySamples    = 5
+
+	// syncLead is added to the start time of a coordinated play so every
+	// device is sent its command ahead of time.
+	syncLead = 100 * time.Millisecond
+	// syncReadyTimeout is how long to wait for every device to buffer.
+	syncReadyTimeout = 30 * time.Second
+)
+
+// SyncGroup plays the same media on several devices at once, for devices
+// that can't be put in a native cast group. The devices don't share a
+// clock with us, so the latency to each device is measured and commands
+// are sent early to the devices that are slower to respond.
+//
+// While playing, the positions reported in each device's MEDIA_STATUS are
+// compared. A device that has drifted ahead of the others is paused for
+// the difference, and if the devices have drifted too far apart they are
+// all seeked back together.
+type SyncGroup struct {
+	apps []App
+	// latency is the estimated one-way latency to each app.
+	latency []time.Duration
+
+	threshold     time.Duration
+	seekThreshold time.Duration
+	interval      time.Duration
+	samples       int
+}
+
+type SyncOption func(*SyncGroup)
+
+// WithSyncThreshold sets how far a device can drift before it is corrected.
+func WithSyncThreshold(threshold time.Duration) SyncOption {
+	return func(g *SyncGroup) {
+		g.threshold = threshold
+	}
+}
+
+// WithSyncSeekThreshold sets how far apart the devices can drift before
+// they are all seeked back together, rather than pausing the ones ahead.
+func WithSyncSeekThreshold(threshold time.Duration) SyncOption {
+	return func(g *SyncGroup) {
+		g.seekThreshold = threshold
+	}
+}
+
+// WithSyncInterval sets how often the device positions are compared.
+func WithSyncInterval(interval time.Duration) SyncOption {
+	return func(g *SyncGroup) {
+		g.interval = interval
+	}
+}
+
+// WithLatencySamples sets how many status requests are made to each
+// device when measuring latency.
+func WithLatencySamples(samples int) SyncOption {
+	return func(g *SyncGroup) {
+		g.samples = samples
+	}
+}
+
+func NewSyncGroup(apps []App, opts ...SyncOption) *SyncGroup {
+	g := &SyncGroup{
+		apps:          apps,
+		latency:       make([]time.Duration, len(apps)),
+		threshold:     defaultSyncThreshold,
+		seekThreshold: defaultSyncSeekThreshold,
+		interval:      defaultSyncInterval,
+		samples:       defaultLatencySamples,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Latency returns the estimated one-way latency to each device, in the
+// same order the apps were given.
+func (g *SyncGroup) Latency() []time.Duration {
+	return g.latency
+}
+
+// Load loads the media onto every device, paused at startTime, and waits
+// for them all to buffer. A local file is served by the first device's
+// application, so it is only read once.
+func (g *SyncGroup) Load(filenameOrUrl string, startTime int, contentType string, transcode bool) error {
+	if len(g.apps) == 0 {
+		return fmt.Errorf("no devices to play on")
+	}
+
+	media := cast.MediaItem{
+		ContentId:   filenameOrUrl,
+		ContentType: contentType,
+		StreamType:  "BUFFERED",
+	}
+	if !strings.HasPrefix(filenameOrUrl, "http://") && !strings.HasPrefix(filenameOrUrl, "https://") {
+		var err error
+		media, err = g.apps[0].ServeFile(filenameOrUrl, contentType, transcode)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := g.each(func(app App) error {
+		return app.LoadMedia(media, startTime, false)
+	}); err != nil {
+		return err
+	}
+	return g.waitUntilReady()
+}
+
+// MeasureLatency estimates the one-way latency to each device as half the
+// fastest of several status round trips.
+func (g *SyncGroup) MeasureLatency() error {
+	return g.eachIndex(func(i int, app App) error {
+		var best time.Duration
+		for n := 0; n < g.samples; n++ {
+			start := time.Now()
+			if _, err := app.MediaStatus(); err != nil {
+				return err
+			}
+			if rtt := time.Since(start); n == 0 || rtt < best {
+				best = rtt
+			}
+		}
+		g.latency[i] = best / 2
+		g.log("device %d latency %v", i, g.latency[i])
+		return nil
+	})
+}
+
+// Play starts playback on every device at the same moment, sending the
+// command to each device early by its latency.
+func (g *SyncGroup) Play() error {
+	var maxLatency time.Duration
+	for _, l := range g.latency {
+		maxLatency = max(maxLatency, l)
+	}
+	start := time.Now().Add(maxLatency + syncLead)
+	return g.eachIndex(func(i int, app App) error {
+		time.Sleep(time.Until(start.Add(-g.latency[i])))
+		return app.Unpause()
+	})
+}
+
+// Seek moves every device to the position and then resumes playback on
+// them together once they have all buffered.
+func (g *SyncGroup) Seek(position float32) error {
+	if err := g.each(func(app App) error {
+		return app.SeekToTimePaused(position)
+	}); err != nil {
+		return err
+	}
+	if err := g.waitUntilReady(); err != nil {
+		return err
+	}
+	return g.Play()
+}
+
+// Run keeps the devices in sync until the context is done, or the media
+// has finished on every device.
+func (g *SyncGroup) Run(ctx context.Context) error {
+	ticker := time.NewTicker(g.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		if err := g.Resync(); err == ErrSyncFinished {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// Resync compares the position of every playing device and corrects any
+// that have drifted. It returns ErrSyncFinished once no device has any
+// media loaded.
+func (g *SyncGroup) Resync() error {
+	positions, active, err := g.positions()
+	if err != nil {
+		return err
+	}
+	if !active {
+		return ErrSyncFinished
+	}
+
+	var (
+		playing   int
+		behind    float32
+		ahead     float32
+		firstSeen = true
+	)
+	for _, p := range positions {
+		if p == nil {
+			continue
+		}
+		playing++
+		if firstSeen || *p < behind {
+			behind = *p
+		}
+		if firstSeen || *p > ahead {
+			ahead = *p
+		}
+		firstSeen = false
+	}
+	if playing == 0 {
+		return nil
+	}
+
+	spread := secondsToDuration(ahead - behind)
+	g.log("devices are %v apart", spread)
+	switch {
+	case spread <= g.threshold:
+		return nil
+	case spread > g.seekThreshold:
+		// Seeking makes every device buffer again, so it is only worth it
+		// when pausing would leave the devices silent for too long.
+		return g.Seek(ahead)
+	}
+
+	// Hold back every device that is ahead of the one furthest behind.
+	return g.eachIndex(func(i int, app App) error {
+		if positions[i] == nil {
+			return nil
+		}
+		drift := secondsToDuration(*positions[i] - behind)
+		if drift <= g.threshold {
+			return nil
+		}
+		g.log("device %d is %v ahead, pausing", i, drift)
+		if err := app.Pause(); err != nil {
+			return err
+		}
+		time.Sleep(drift)
+		return app.Unpause()
+	})
+}
+
+// positions returns where each playing device is at the same instant, or
+// nil for devices that aren't playing, and whether any device still has
+// media loaded.
+func (g *SyncGroup) positions() ([]*float32, bool, error) {
+	type sample struct {
+		position float32
+		at       time.Time
+		playing  bool
+	}
+	samples := make([]sample, len(g.apps))
+	loaded := make([]bool, len(g.apps))
+	if err := g.eachIndex(func(i int, app App) error {
+		start := time.Now()
+		media, err := app.MediaStatus()
+		if err != nil {
+			return err
+		}
+		loaded[i] = media != nil && media.PlayerState != "IDLE"
+		if media == nil || media.PlayerState != "PLAYING" {
+			return nil
+		}
+		rtt := time.Since(start)
+		samples[i] = sample{
+			position: media.CurrentTime,
+			at:       start.Add(rtt / 2),
+			playing:  true,
+		}
+		return nil
+	}); err != nil {
+		return nil, false, err
+	}
+
+	// Move every position forward to the same instant.
+	now := time.Now()
+	positions := make([]*float32, len(samples))
+	for i, s := range samples {
+		if !s.playing {
+			continue
+		}
+		p := s.position + float32(now.Sub(s.at).Seconds())
+		positions[i] = &p
+	}
+	return positions, slices.Contains(loaded, true), nil
+}
+
+// waitUntilReady waits for every device to finish buffering and be paused.
+func (g *SyncGroup) waitUntilReady() error {
+	deadline := time.Now().Add(syncReadyTimeout)
+	return g.eachIndex(func(i int, app App) error {
+		for {
+			media, err := app.MediaStatus()
+			if err != nil {
+				return err
+			}
+			if media != nil && media.PlayerState == "PAUSED" {
+				return nil
+			}
+			if media != nil && media.PlayerState == "IDLE" {
+				return fmt.Errorf("device %d stopped while loading: %s", i, media.IdleReason)
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("timed out waiting for device %d to buffer", i)
+			}
+			time.Sleep(250 * time.Millisecond)
+		}
+	})
+}
+
+func (g *SyncGroup) each(f func(app App) error) error {
+	return g.eachIndex(func(_ int, app App) error { return f(app) })
+}
+
+// eachIndex runs f against every app concurrently, returning the first
+// error.
+func (g *SyncGroup) eachIndex(f func(i int, app App) error) error {
+	errs := make([]error, len(g.apps))
+	var wg sync.WaitGroup
+	for i, app := range g.apps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = f(i, app)
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return errors.Wrapf(err, "device %d", i)
+		}
+	}
+	return nil
+}
+
+func (g *SyncGroup) log(message string, args ...interface{}) {
+	log.WithField("package", "application").Debugf(message, args...)
+}
+
+func secondsToDuration(seconds float32) time.Duration {
+	return time.Duration(float64(seconds) * float64(time.Second))
+}
@@ -0,0 +1,74 @@
+package application_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vishen/go-chromecast/application"
+	"github.com/vishen/go-chromecast/application/mocks"
+	"github.com/vishen/go-chromecast/cast"
+)
+
+func TestSyncGroupLoad(t *testing.T) {
+	assertions := require.New(t)
+
+	url := "http://example.com/song.mp3"
+	var apps []application.App
+	for i := 0; i < 2; i++ {
+		app := mocks.NewApp(t)
+		app.On("LoadMedia", mock.MatchedBy(func(m cast.MediaItem) bool { return m.ContentId == url }), 30, false).Return(nil).Once()
+		app.On("MediaStatus").Return(&cast.Media{PlayerState: "PAUSED", CurrentTime: 30}, nil)
+		apps = append(apps, app)
+	}
+
+	group := application.NewSyncGroup(apps)
+	assertions.NoError(group.Load(url, 30, "audio/mpeg", false))
+}
+
+func TestSyncGroupResync(t *testing.T) {
+	assertions := require.New(t)
+
+	behind := mocks.NewApp(t)
+	behind.On("MediaStatus").Return(&cast.Media{PlayerState: "PLAYING", CurrentTime: 10}, nil).Once()
+
+	ahead := mocks.NewApp(t)
+	ahead.On("MediaStatus").Return(&cast.Media{PlayerState: "PLAYING", CurrentTime: 10.5}, nil).Once()
+	ahead.On("Pause").Return(nil).Once()
+	ahead.On("Unpause").Return(nil).Once()
+
+	group := application.NewSyncGroup(
+		[]application.App{behind, ahead},
+		application.WithSyncThreshold(200*time.Millisecond),
+	)
+	start := time.Now()
+	assertions.NoError(group.Resync())
+	assertions.GreaterOrEqual(time.Since(start), 400*time.Millisecond, "the device ahead should be paused for its drift")
+}
+
+func TestSyncGroupResyncInSync(t *testing.T) {
+	assertions := require.New(t)
+
+	var apps []application.App
+	for _, position := range []float32{20, 20.1} {
+		app := mocks.NewApp(t)
+		app.On("MediaStatus").Return(&cast.Media{PlayerState: "PLAYING", CurrentTime: position}, nil).Once()
+		apps = append(apps, app)
+	}
+
+	group := application.NewSyncGroup(apps, application.WithSyncThreshold(200*time.Millisecond))
+	assertions.NoError(group.Resync())
+}
+
+func TestSyncGroupResyncFinished(t *testing.T) {
+	assertions := require.New(t)
+
+	finished := mocks.NewApp(t)
+	finished.On("MediaStatus").Return(&cast.Media{PlayerState: "IDLE", IdleReason: "FINISHED"}, nil).Once()
+	stopped := mocks.NewApp(t)
+	stopped.On("MediaStatus").Return(nil, nil).Once()
+
+	group := application.NewSyncGroup([]application.App{finished, stopped})
+	assertions.ErrorIs(group.Resync(), application.ErrSyncFinished)
+}
@@ -0,0 +1,85 @@
+package application
+
+import (
+	"net/url"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// Transfer moves the media playing on from over to to, at the same
+// position and with the same metadata and tracks, and then stops from. If
+// withVolume is set the volume is carried over as well.
+//
+// A local file served by go-chromecast is served again by to, as whatever
+// was serving it will likely stop once from does. When that happens served
+// is true and the caller needs to keep running for as long as the media
+// plays.
+func Transfer(from, to App, withVolume bool) (served bool, err error) {
+	if err := from.Update(); err != nil {
+		return false, errors.Wrap(err, "unable to update source device")
+	}
+	_, media, volume := from.Status()
+	// Get the latest position rather than the one from the last update.
+	if latest, err := from.MediaStatus(); err == nil && latest != nil {
+		media = latest
+	}
+	if media == nil || media.Media.ContentId == "" || media.PlayerState == "IDLE" {
+		return false, ErrNoMediaTransfer
+	}
+
+	item := media.Media
+	if filename, transcode, ok := servedFile(item.ContentId); ok {
+		servedItem, err := to.ServeFile(filename, item.ContentType, transcode)
+		if err != nil {
+			return false, err
+		}
+		item.ContentId = servedItem.ContentId
+		item.ContentType = servedItem.ContentType
+		served = true
+	}
+
+	if withVolume && volume != nil {
+		if err := to.SetVolume(volume.Level); err != nil {
+			return served, errors.Wrap(err, "unable to set volume")
+		}
+		if err := to.SetMuted(volume.Muted); err != nil {
+			return served, errors.Wrap(err, "unable to set muted")
+		}
+	}
+
+	autoplay := media.PlayerState != "PAUSED"
+	startTime := int(media.CurrentTime + 0.5)
+	if err := to.LoadMedia(item, startTime, autoplay, media.ActiveTrackIds...); err != nil {
+		return served, err
+	}
+	if err := from.Stop(); err != nil {
+		return served, errors.Wrap(err, "unable to stop source device")
+	}
+	return served, nil
+}
+
+// LocalFile returns the local file behind a content url served by
+// go-chromecast, if the file exists on this host.
+func LocalFile(contentURL string) (filename string, ok bool) {
+	filename, _, ok = servedFile(contentURL)
+	return filename, ok
+}
+
+// servedFile returns the local file behind a content url created by
+// loadAndServeFiles, if the file exists on this host.
+func servedFile(contentURL string) (filename string, transcode bool, ok bool) {
+	u, err := url.Parse(contentURL)
+	if err != nil {
+		return "", false, false
+	}
+	q := u.Query()
+	filename = q.Get("media_file")
+	if filename == "" {
+		return "", false, false
+	}
+	if _, err := os.Stat(filename); err != nil {
+		return "", false, false
+	}
+	return filename, q.Get("live_streaming") == "true", true
+}
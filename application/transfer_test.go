@@ -0,0 +1,91 @@
+package application_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vishen/go-chromecast/application"
+	"github.com/vishen/go-chromecast/application/mocks"
+	"github.com/vishen/go-chromecast/cast"
+)
+
+func TestTransfer(t *testing.T) {
+	assertions := require.New(t)
+
+	media := &cast.Media{
+		PlayerState:    "PLAYING",
+		CurrentTime:    42.6,
+		ActiveTrackIds: []int{1},
+		Media: cast.MediaItem{
+			ContentId:   "https://example.com/film.mp4",
+			ContentType: "video/mp4",
+			Metadata:    cast.MediaMetadata{Title: "Film"},
+			Tracks:      []cast.MediaTrack{{TrackId: 1, Type: "TEXT", Language: "en"}},
+		},
+	}
+	volume := &cast.Volume{Level: 0.4}
+
+	from := mocks.NewApp(t)
+	from.On("Update").Return(nil)
+	from.On("Status").Return(nil, media, volume)
+	from.On("MediaStatus").Return(media, nil)
+	from.On("Stop").Return(nil).Once()
+
+	to := mocks.NewApp(t)
+	to.On("SetVolume", float32(0.4)).Return(nil).Once()
+	to.On("SetMuted", false).Return(nil).Once()
+	to.On("LoadMedia", media.Media, 43, true, 1).Return(nil).Once()
+
+	served, err := application.Transfer(from, to, true)
+	assertions.NoError(err)
+	assertions.False(served)
+}
+
+func TestTransferServedFile(t *testing.T) {
+	assertions := require.New(t)
+
+	filename := filepath.Join(t.TempDir(), "song.mp3")
+	assertions.NoError(os.WriteFile(filename, []byte("not really a song"), 0644))
+
+	media := &cast.Media{
+		PlayerState: "PAUSED",
+		CurrentTime: 10,
+		Media: cast.MediaItem{
+			ContentId:   fmt.Sprintf("http://10.0.0.2:34567?media_file=%s&live_streaming=false", filename),
+			ContentType: "audio/mpeg",
+		},
+	}
+
+	from := mocks.NewApp(t)
+	from.On("Update").Return(nil)
+	from.On("Status").Return(nil, media, nil)
+	from.On("MediaStatus").Return(media, nil)
+	from.On("Stop").Return(nil).Once()
+
+	to := mocks.NewApp(t)
+	to.On("ServeFile", filename, "audio/mpeg", false).Return(cast.MediaItem{
+		ContentId:   "http://10.0.0.3:45678?media_file=" + filename,
+		ContentType: "audio/mpeg",
+	}, nil).Once()
+	to.On("LoadMedia", mock.MatchedBy(func(m cast.MediaItem) bool {
+		return m.ContentId == "http://10.0.0.3:45678?media_file="+filename
+	}), 10, false).Return(nil).Once()
+
+	served, err := application.Transfer(from, to, false)
+	assertions.NoError(err)
+	assertions.True(served)
+}
+
+func TestTransferNothingPlaying(t *testing.T) {
+	from := mocks.NewApp(t)
+	from.On("Update").Return(nil)
+	from.On("Status").Return(nil, nil, nil)
+	from.On("MediaStatus").Return(nil, nil)
+
+	_, err := application.Transfer(from, mocks.NewApp(t), false)
+	require.ErrorIs(t, err, application.ErrNoMediaTransfer)
+}
@@ -0,0 +1,209 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// fadeSteps is the number of times the volume is changed while fading in
+// or out, at most once a second.
+const fadeSteps = 50
+
+// FadeVolume sets the volume of the device to from, then changes it
+// gradually to to over d. The volume is set straight to to when d isn't
+// positive.
+func FadeVolume(ctx context.Context, app App, from, to float32, d time.Duration) error {
+	if d <= 0 {
+		return errors.Wrap(app.SetVolume(to), "unable to set volume")
+	}
+	if err := app.SetVolume(from); err != nil {
+		return errors.Wrap(err, "unable to set volume")
+	}
+
+	ticker := time.NewTicker(min(d/fadeSteps, time.Second))
+	defer ticker.Stop()
+	start := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		elapsed := time.Since(start)
+		if elapsed >= d {
+			return errors.Wrap(app.SetVolume(to), "unable to set volume")
+		}
+		level := from + (to-from)*float32(float64(elapsed)/float64(d))
+		if err := app.SetVolume(level); err != nil {
+			return errors.Wrap(err, "unable to set volume")
+		}
+	}
+}
+
+// DefaultVolumeStep is how much the volume is turned up or down by when
+// there is no step set.
+const DefaultVolumeStep = 0.05
+
+// VolumeConfig is the volume settings of devices, kept in a yaml file. The
+// step and presets apply to every device, and devices, by name or uuid,
+// can have their own along with limits on their volume.
+//
+//	step: 0.05
+//	presets:
+//	  night: 0.15
+//	  party: 0.8
+//	devices:
+//	  Kitchen speaker:
+//	    min: 0.05
+//	    max: 0.6
+//	    step: 0.02
+//	    presets:
+//	      party: 0.6
+type VolumeConfig struct {
+	Step    float32                 `yaml:"step"`
+	Presets map[string]float32      `yaml:"presets"`
+	Devices map[string]DeviceVolume `yaml:"devices"`
+}
+
+// DeviceVolume is the volume settings of a device.
+type DeviceVolume struct {
+	Min     *float32           `yaml:"min"`
+	Max     *float32           `yaml:"max"`
+	Step    float32            `yaml:"step"`
+	Presets map[string]float32 `yaml:"presets"`
+}
+
+// VolumeSettings is the volume settings of a device, with the settings
+// for every device filled in.
+type VolumeSettings struct {
+	Min     float32
+	Max     float32
+	Step    float32
+	Presets map[string]float32
+}
+
+// DefaultVolumeSettings are the settings of a device without any.
+var DefaultVolumeSettings = VolumeSettings{Min: 0, Max: 1, Step: DefaultVolumeStep}
+
+// LoadVolumeConfig reads and checks a volume config file. A file that
+// doesn't exist is an empty config.
+func LoadVolumeConfig(filename string) (*VolumeConfig, error) {
+	cfg := &VolumeConfig{}
+	b, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("unable to parse volume config %q: %w", filename, err)
+	}
+	if err := cfg.check(); err != nil {
+		return nil, fmt.Errorf("volume config %q: %w", filename, err)
+	}
+	return cfg, nil
+}
+
+func (c *VolumeConfig) check() error {
+	if err := checkVolumes(c.Step, c.Presets); err != nil {
+		return err
+	}
+	for name, d := range c.Devices {
+		if err := checkVolumes(d.Step, d.Presets); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		s := c.For("", name)
+		for _, v := range []*float32{d.Min, d.Max} {
+			if v != nil && (*v < 0 || *v > 1) {
+				return fmt.Errorf("%s: volume %v is out of range (0 - 1)", name, *v)
+			}
+		}
+		if s.Min > s.Max {
+			return fmt.Errorf("%s: min %v is above max %v", name, s.Min, s.Max)
+		}
+	}
+	return nil
+}
+
+func checkVolumes(step float32, presets map[string]float32) error {
+	if step < 0 || step > 1 {
+		return fmt.Errorf("step %v is out of range (0 - 1)", step)
+	}
+	for name, v := range presets {
+		if v < 0 || v > 1 {
+			return fmt.Errorf("preset %s: volume %v is out of range (0 - 1)", name, v)
+		}
+	}
+	return nil
+}
+
+// For returns the settings of the device with the uuid or name. A nil
+// config has the default settings.
+func (c *VolumeConfig) For(uuid, name string) VolumeSettings {
+	s := DefaultVolumeSettings
+	if c == nil {
+		return s
+	}
+	s.Presets = map[string]float32{}
+	if c.Step > 0 {
+		s.Step = c.Step
+	}
+	copyPresets(s.Presets, c.Presets)
+
+	for key, d := range c.Devices {
+		if key == "" || (key != uuid && !strings.EqualFold(key, name)) {
+			continue
+		}
+		if d.Min != nil {
+			s.Min = *d.Min
+		}
+		if d.Max != nil {
+			s.Max = *d.Max
+		}
+		if d.Step > 0 {
+			s.Step = d.Step
+		}
+		copyPresets(s.Presets, d.Presets)
+	}
+	return s
+}
+
+// copyPresets copies presets, which are case insensitive.
+func copyPresets(dst, src map[string]float32) {
+	for name, level := range src {
+		dst[strings.ToLower(name)] = level
+	}
+}
+
+// Clamp returns the level kept within the limits.
+func (s VolumeSettings) Clamp(level float32) float32 {
+	return max(s.Min, min(level, s.Max))
+}
+
+// Level returns the volume given by a number between 0 and 1, or by the
+// name of a preset.
+func (s VolumeSettings) Level(volume string) (float32, error) {
+	if level, ok := s.Presets[strings.ToLower(volume)]; ok {
+		return level, nil
+	}
+	level, err := strconv.ParseFloat(volume, 32)
+	if err != nil {
+		if len(s.Presets) == 0 {
+			return 0, fmt.Errorf("%q isn't a volume or a preset, there are no presets", volume)
+		}
+		return 0, fmt.Errorf("%q isn't a volume or a preset, one of: %s", volume, strings.Join(slices.Sorted(maps.Keys(s.Presets)), ", "))
+	}
+	if level < 0 || level > 1 {
+		return 0, ErrVolumeOutOfRange
+	}
+	return float32(level), nil
+}
@@ -0,0 +1,144 @@
+package application_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vishen/go-chromecast/application"
+	"github.com/vishen/go-chromecast/application/mocks"
+	"github.com/vishen/go-chromecast/cast"
+	mockCast "github.com/vishen/go-chromecast/cast/mocks"
+	pb "github.com/vishen/go-chromecast/cast/proto"
+)
+
+func TestLoadVolumeConfig(t *testing.T) {
+	assertions := require.New(t)
+	filename := filepath.Join(t.TempDir(), "volume.yaml")
+	assertions.NoError(os.WriteFile(filename, []byte(`
+step: 0.1
+presets:
+  Night: 0.15
+  party: 0.8
+devices:
+  Kitchen speaker:
+    min: 0.05
+    max: 0.6
+    presets:
+      party: 0.6
+  b87d86bed423a6feb8b91a7d2778b55c:
+    step: 0.02
+`), 0644))
+	cfg, err := application.LoadVolumeConfig(filename)
+	assertions.NoError(err)
+
+	kitchen := cfg.For("", "kitchen speaker")
+	assertions.Equal(float32(0.05), kitchen.Min)
+	assertions.Equal(float32(0.6), kitchen.Max)
+	assertions.Equal(float32(0.1), kitchen.Step)
+	assertions.Equal(map[string]float32{"night": 0.15, "party": 0.6}, kitchen.Presets)
+	assertions.Equal(float32(0.6), kitchen.Clamp(0.9))
+	assertions.Equal(float32(0.05), kitchen.Clamp(0))
+	assertions.Equal(float32(0.3), kitchen.Clamp(0.3))
+
+	byUUID := cfg.For("b87d86bed423a6feb8b91a7d2778b55c", "Bedroom speaker")
+	assertions.Equal(float32(0.02), byUUID.Step)
+	assertions.Equal(float32(1), byUUID.Max)
+	assertions.Equal(float32(0.8), byUUID.Presets["party"])
+
+	level, err := kitchen.Level("NIGHT")
+	assertions.NoError(err)
+	assertions.Equal(float32(0.15), level)
+	level, err = kitchen.Level("0.35")
+	assertions.NoError(err)
+	assertions.Equal(float32(0.35), level)
+	_, err = kitchen.Level("loud")
+	assertions.ErrorContains(err, `"loud" isn't a volume or a preset, one of: night, party`)
+	_, err = kitchen.Level("1.5")
+	assertions.ErrorIs(err, application.ErrVolumeOutOfRange)
+
+	// Without a config every device has the defaults.
+	cfg, err = application.LoadVolumeConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	assertions.NoError(err)
+	assertions.Equal(float32(application.DefaultVolumeStep), cfg.For("", "Kitchen speaker").Step)
+	var none *application.VolumeConfig
+	assertions.Equal(application.DefaultVolumeSettings, none.For("", "Kitchen speaker"))
+	_, err = application.DefaultVolumeSettings.Level("night")
+	assertions.ErrorContains(err, "there are no presets")
+}
+
+func TestLoadVolumeConfigInvalid(t *testing.T) {
+	for content, want := range map[string]string{
+		"step: 2":                                   "step 2 is out of range",
+		"presets: {night: -0.1}":                    "preset night: volume -0.1 is out of range",
+		"devices: {kitchen: {max: 1.2}}":            "kitchen: volume 1.2 is out of range",
+		"devices: {kitchen: {min: 0.5, max: 0.2}}":  "kitchen: min 0.5 is above max 0.2",
+		"devices: {kitchen: {presets: {party: 3}}}": "kitchen: preset party: volume 3 is out of range",
+		"devices: {kitchen: {min: loud}}":           "unable to parse volume config",
+	} {
+		filename := filepath.Join(t.TempDir(), "volume.yaml")
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+		_, err := application.LoadVolumeConfig(filename)
+		require.ErrorContains(t, err, want, content)
+	}
+}
+
+func TestSetVolumeLimits(t *testing.T) {
+	assertions := require.New(t)
+
+	recvChan := make(chan *pb.CastMessage, 5)
+	var levels []float32
+	conn := &mockCast.Conn{}
+	conn.On("MsgChan").Return(recvChan)
+	conn.On("Send", mock.IsType(0), mock.IsType(&cast.SetVolume{}), mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) {
+			levels = append(levels, args.Get(1).(*cast.SetVolume).Volume.Level)
+			payload := cast.GetStatusHeader
+			payload.SetRequestId(args.Int(0))
+			payloadBytes, err := json.Marshal(&cast.ReceiverStatusResponse{PayloadHeader: payload})
+			assertions.NoError(err)
+			payloadString := string(payloadBytes)
+			recvChan <- &pb.CastMessage{PayloadUtf8: &payloadString}
+		}).Return(nil)
+
+	settings := application.DefaultVolumeSettings
+	settings.Min, settings.Max = 0.1, 0.5
+	app := application.NewApplication(application.WithConnection(conn), application.WithVolumeSettings(settings))
+	assertions.Equal(settings, app.VolumeSettings())
+
+	assertions.NoError(app.SetVolume(0.8))
+	assertions.NoError(app.SetVolume(0.3))
+	assertions.NoError(app.SetVolume(0))
+	assertions.ErrorIs(app.SetVolume(1.2), application.ErrVolumeOutOfRange)
+	assertions.Equal([]float32{0.5, 0.3, 0.1}, levels)
+}
+
+func TestFadeVolume(t *testing.T) {
+	assertions := require.New(t)
+	var levels []float32
+	app := mocks.NewApp(t)
+	app.On("SetVolume", mock.Anything).Run(func(args mock.Arguments) {
+		levels = append(levels, args.Get(0).(float32))
+	}).Return(nil)
+
+	assertions.NoError(application.FadeVolume(context.Background(), app, 0.2, 0.6, 50*time.Millisecond))
+	assertions.Equal(float32(0.2), levels[0])
+	assertions.Equal(float32(0.6), levels[len(levels)-1])
+	for i := 1; i < len(levels); i++ {
+		assertions.GreaterOrEqual(levels[i], levels[i-1])
+	}
+
+	// Without a duration the volume is set straight away.
+	levels = nil
+	assertions.NoError(application.FadeVolume(context.Background(), app, 0.6, 0.1, 0))
+	assertions.Equal([]float32{0.1}, levels)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assertions.ErrorIs(application.FadeVolume(ctx, app, 0.1, 0.6, time.Minute), context.Canceled)
+}
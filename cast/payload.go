@@ -16,6 +16,11 @@ var (
 	QueueLoadHeader   = PayloadHeader{Type: "QUEUE_LOAD"}   // Loads an application onto the chromecast
 	QueueUpdateHeader = PayloadHeader{Type: "QUEUE_UPDATE"} // Loads an application onto the chromecast
 	SkipHeader        = PayloadHeader{Type: "SKIP_AD"}      // Skip add based off https://developers.google.com/cast/docs/reference/web_receiver/cast.framework.messages#.SKIP_AD
+
+	SetPlaybackRateHeader = PayloadHeader{Type: "SET_PLAYBACK_RATE"} // Sets the playback rate of the media
+
+	QueueGetItemIdsHeader = PayloadHeader{Type: "QUEUE_GET_ITEM_IDS"} // Gets the ids of the items in the queue
+	QueueGetItemsHeader   = PayloadHeader{Type: "QUEUE_GET_ITEMS"}    // Gets the items in the queue by their ids
 )
 
 type Payload interface {
@@ -35,6 +40,26 @@ type QueueUpdate struct {
 	PayloadHeader
 	MediaSessionId int `json:"mediaSessionId,omitempty"`
 	Jump           int `json:"jump,omitempty"`
+	// Items replaces the media of the queue items with the same ids.
+	Items []QueueItem `json:"items,omitempty"`
+}
+
+// QueueGetItems requests the ids of the items in the queue, or the items
+// with ItemIds.
+type QueueGetItems struct {
+	PayloadHeader
+	MediaSessionId int   `json:"mediaSessionId"`
+	ItemIds        []int `json:"itemIds,omitempty"`
+}
+
+type QueueItemIdsResponse struct {
+	PayloadHeader
+	ItemIds []int `json:"itemIds"`
+}
+
+type QueueItemsResponse struct {
+	PayloadHeader
+	Items []QueueItem `json:"items"`
 }
 
 type QueueLoad struct {
@@ -58,6 +83,7 @@ type MediaHeader struct {
 	CurrentTime    float32 `json:"currentTime"`
 	RelativeTime   float32 `json:"relativeTime,omitempty"`
 	ResumeState    string  `json:"resumeState"`
+	PlaybackRate   float32 `json:"playbackRate,omitempty"`
 }
 
 type Volume struct {
@@ -96,11 +122,12 @@ type LaunchRequest struct {
 
 type LoadMediaCommand struct {
 	PayloadHeader
-	Media       MediaItem   `json:"media"`
-	CurrentTime int         `json:"currentTime"`
-	Autoplay    bool        `json:"autoplay"`
-	QueueData   QueueData   `json:"queueData"`
-	CustomData  interface{} `json:"customData"`
+	Media          MediaItem   `json:"media"`
+	CurrentTime    int         `json:"currentTime"`
+	Autoplay       bool        `json:"autoplay"`
+	ActiveTrackIds []int       `json:"activeTrackIds,omitempty"`
+	QueueData      QueueData   `json:"queueData"`
+	CustomData     interface{} `json:"customData"`
 }
 
 type QueueData struct {
@@ -113,6 +140,19 @@ type MediaItem struct {
 	StreamType  string        `json:"streamType"`
 	Duration    float32       `json:"duration"`
 	Metadata    MediaMetadata `json:"metadata"`
+	Tracks      []MediaTrack  `json:"tracks,omitempty"`
+}
+
+// MediaTrack is an extra track for the media, ie: subtitles or an
+// alternative audio language.
+type MediaTrack struct {
+	TrackId          int    `json:"trackId"`
+	Type             string `json:"type"`
+	TrackContentId   string `json:"trackContentId,omitempty"`
+	TrackContentType string `json:"trackContentType,omitempty"`
+	Subtype          string `json:"subtype,omitempty"`
+	Name             string `json:"name,omitempty"`
+	Language         string `json:"language,omitempty"`
 }
 
 type MediaMetadata struct {
@@ -122,6 +162,7 @@ type MediaMetadata struct {
 	Subtitle     string  `json:"subtitle"`
 	Images       []Image `json:"images"`
 	ReleaseDate  string  `json:"releaseDate"`
+	AlbumName    string  `json:"albumName,omitempty"`
 }
 
 type Image struct {
@@ -134,13 +175,24 @@ type Media struct {
 	MediaSessionId int        `json:"mediaSessionId"`
 	PlayerState    string     `json:"playerState"`
 	CurrentTime    float32    `json:"currentTime"`
+	PlaybackRate   float32    `json:"playbackRate,omitempty"`
 	IdleReason     string     `json:"idleReason"`
 	Volume         Volume     `json:"volume"`
 	CurrentItemId  int        `json:"currentItemId"`
+	ActiveTrackIds []int      `json:"activeTrackIds,omitempty"`
 	LoadingItemId  int        `json:"loadingItemId"`
 	CustomData     CustomData `json:"customData"`
 
 	Media MediaItem `json:"media"`
+	// Items are the items in the queue, only sent when the queue changes.
+	Items []QueueItem `json:"items,omitempty"`
+}
+
+// QueueItem is an item in the media queue, identified by the item id
+// the device gave it.
+type QueueItem struct {
+	ItemId int       `json:"itemId"`
+	Media  MediaItem `json:"media"`
 }
 
 type CustomData struct {
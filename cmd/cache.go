@@ -0,0 +1,104 @@
+// Copyright © 2018 Jonathan Pentecost <pentecostjonathan@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/discovery"
+)
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache of previously found devices",
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the cached devices",
+	Run: func(cmd *cobra.Command, args []string) {
+		ttl, _ := cmd.Flags().GetDuration("cache-ttl")
+		entries, err := managedCache(cmd).Entries()
+		if err != nil {
+			exit("unable to read cache: %v", err)
+		}
+		if len(entries) == 0 {
+			outputInfo("no cached devices")
+			return
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tUUID\tADDRESS\tAGE\tSTATUS")
+		for _, e := range entries {
+			age, status := "-", "expired"
+			if !e.SavedAt.IsZero() {
+				age = time.Since(e.SavedAt).Round(time.Second).String()
+			}
+			if !e.Expired(ttl) {
+				status = "ok"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s:%d\t%s\t%s\n", e.Name, e.UUID, e.Addr, e.Port, age, status)
+		}
+		tw.Flush()
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove expired devices from the cache",
+	Long: `Remove cached devices older than --cache-ttl. With --validate the remaining
+devices are checked with --cache-validate, and any that fail are removed too.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		validate, _ := cmd.Flags().GetBool("validate")
+		pruned, err := managedCache(cmd).Prune(context.Background(), validate)
+		for _, e := range pruned {
+			outputInfo("removed %q at %s:%d", e.Name, e.Addr, e.Port)
+		}
+		if err != nil {
+			exit("unable to prune cache: %v", err)
+		}
+		outputInfo("removed %d devices", len(pruned))
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove every device from the cache",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := managedCache(cmd).Clear(); err != nil {
+			exit("unable to clear cache: %v", err)
+		}
+	},
+}
+
+// managedCache returns the device cache, exiting if the store can't be
+// opened rather than falling back to an empty cache.
+func managedCache(cmd *cobra.Command) *discovery.Cache {
+	if _, err := openStore(cmd); err != nil {
+		exit("%v", err)
+	}
+	return deviceCache(cmd)
+}
+
+func init() {
+	cachePruneCmd.Flags().Bool("validate", false, "also remove devices that fail validation")
+	cacheCmd.AddCommand(cacheListCmd, cachePruneCmd, cacheClearCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
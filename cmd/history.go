@@ -0,0 +1,248 @@
+// Copyright © 2018 Jonathan Pentecost <pentecostjonathan@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
+	"github.com/vishen/go-chromecast/discovery"
+	"github.com/vishen/go-chromecast/storage"
+)
+
+// historyRecord is a played item as it is exported.
+type historyRecord struct {
+	ContentID  string    `json:"content_id"`
+	DeviceUUID string    `json:"device_uuid,omitempty"`
+	DeviceName string    `json:"device_name,omitempty"`
+	Started    time.Time `json:"started"`
+	Played     float32   `json:"played_seconds"`
+	Duration   float32   `json:"duration_seconds,omitempty"`
+	Finished   bool      `json:"finished"`
+}
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List the media that has been played",
+	Long: `List the media that has been played, on which device, when, for how long and
+whether it was finished.
+
+The history can be filtered by device with --device, --device-name or --uuid,
+by when the media was played with --since and --until, and by the start of
+the file path or url with --prefix.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+		threshold, _ := cmd.Flags().GetDuration("resume-threshold")
+
+		items, err := filteredHistory(cmd)
+		if err != nil {
+			exit("%v", err)
+		}
+		records := make([]historyRecord, len(items))
+		for i, pi := range items {
+			records[i] = historyRecord{
+				ContentID:  pi.ContentID,
+				DeviceUUID: pi.DeviceUUID,
+				DeviceName: pi.DeviceName,
+				Started:    time.Unix(pi.Started, 0),
+				Played:     pi.Position,
+				Duration:   pi.Duration,
+				Finished:   pi.IsFinished(threshold),
+			}
+		}
+
+		switch format {
+		case "table":
+			err = writeHistoryTable(os.Stdout, records)
+		case "json":
+			err = json.NewEncoder(os.Stdout).Encode(records)
+		case "csv":
+			err = writeHistoryCSV(os.Stdout, records)
+		default:
+			exit("unknown format %q, expected one of table, json or csv", format)
+		}
+		if err != nil {
+			exit("unable to write history: %v", err)
+		}
+	},
+}
+
+var historyWatchedCmd = &cobra.Command{
+	Use:   "watched <content>...",
+	Short: "Mark media as watched",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		updateHistory(cmd, args, func(store storage.Store, contentID string) error {
+			return application.SetWatched(store, contentID, true)
+		})
+	},
+}
+
+var historyUnwatchedCmd = &cobra.Command{
+	Use:   "unwatched <content>...",
+	Short: "Mark media as unwatched, so it is played from the start",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		updateHistory(cmd, args, func(store storage.Store, contentID string) error {
+			return application.SetWatched(store, contentID, false)
+		})
+	},
+}
+
+var historyForgetCmd = &cobra.Command{
+	Use:   "forget <content>...",
+	Short: "Remove media from the history",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		updateHistory(cmd, args, application.Forget)
+	},
+}
+
+// filteredHistory returns the played items that match the filter flags.
+func filteredHistory(cmd *cobra.Command) ([]application.PlayedItem, error) {
+	prefix, _ := cmd.Flags().GetString("prefix")
+	sinceFlag, _ := cmd.Flags().GetString("since")
+	untilFlag, _ := cmd.Flags().GetString("until")
+
+	since, err := parseHistoryTime(sinceFlag)
+	if err != nil {
+		return nil, fmt.Errorf("invalid since: %w", err)
+	}
+	until, err := parseHistoryTime(untilFlag)
+	if err != nil {
+		return nil, fmt.Errorf("invalid until: %w", err)
+	}
+	inventory, err := loadInventory(cmd)
+	if err != nil {
+		return nil, err
+	}
+	filter := deviceFilterFromFlags(cmd)
+
+	store, err := openStore(cmd)
+	if err != nil {
+		return nil, err
+	}
+	items, err := application.History(store)
+	if err != nil {
+		return nil, err
+	}
+	var filtered []application.PlayedItem
+	for _, pi := range items {
+		started := time.Unix(pi.Started, 0)
+		switch {
+		case !strings.HasPrefix(pi.ContentID, prefix):
+		case !since.IsZero() && started.Before(since):
+		case !until.IsZero() && !started.Before(until):
+		case !filter.empty() && !filter.match(inventory, discovery.Device{UUID: pi.DeviceUUID, Name: pi.DeviceName}):
+		default:
+			filtered = append(filtered, pi)
+		}
+	}
+	return filtered, nil
+}
+
+// parseHistoryTime parses a date, a date and time, or a duration to go
+// back from now, ie: '2024-01-31', '2024-01-31T20:00:00Z' or '48h'.
+func parseHistoryTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func updateHistory(cmd *cobra.Command, contentIDs []string, update func(store storage.Store, contentID string) error) {
+	store, err := openStore(cmd)
+	if err != nil {
+		exit("%v", err)
+	}
+	for _, contentID := range contentIDs {
+		if err := update(store, contentID); err != nil {
+			exit("%v", err)
+		}
+	}
+}
+
+func writeHistoryTable(w io.Writer, records []historyRecord) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STARTED\tDEVICE\tPLAYED\tFINISHED\tCONTENT")
+	for _, r := range records {
+		started := "-"
+		if r.Started.Unix() > 0 {
+			started = r.Started.Format("2006-01-02 15:04")
+		}
+		device := r.DeviceName
+		if device == "" {
+			device = "-"
+		}
+		played := formatSeconds(r.Played)
+		if r.Duration > 0 {
+			played += "/" + formatSeconds(r.Duration)
+		}
+		finished := "no"
+		if r.Finished {
+			finished = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", started, device, played, finished, r.ContentID)
+	}
+	return tw.Flush()
+}
+
+func writeHistoryCSV(w io.Writer, records []historyRecord) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"content_id", "device_uuid", "device_name", "started", "played_seconds", "duration_seconds", "finished"})
+	for _, r := range records {
+		cw.Write([]string{
+			r.ContentID,
+			r.DeviceUUID,
+			r.DeviceName,
+			r.Started.Format(time.RFC3339),
+			strconv.FormatFloat(float64(r.Played), 'f', -1, 32),
+			strconv.FormatFloat(float64(r.Duration), 'f', -1, 32),
+			strconv.FormatBool(r.Finished),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatSeconds(seconds float32) string {
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+func init() {
+	historyCmd.Flags().String("since", "", "only show media played since a date (2006-01-02), time (RFC3339) or duration ago (48h)")
+	historyCmd.Flags().String("until", "", "only show media played before a date (2006-01-02), time (RFC3339) or duration ago (48h)")
+	historyCmd.Flags().String("prefix", "", "only show media whose path or url starts with the prefix")
+	historyCmd.Flags().String("format", "table", "output format, one of: table, json, csv")
+	historyCmd.Flags().Duration("resume-threshold", application.DefaultResumeThreshold, "media played to within this of the end counts as finished")
+	historyCmd.AddCommand(historyWatchedCmd, historyUnwatchedCmd, historyForgetCmd)
+	rootCmd.AddCommand(historyCmd)
+}
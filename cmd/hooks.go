@@ -0,0 +1,193 @@
+// Copyright © 2018 Jonathan Pentecost <pentecostjonathan@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
+	pb "github.com/vishen/go-chromecast/cast/proto"
+	"github.com/vishen/go-chromecast/discovery"
+	"github.com/vishen/go-chromecast/hooks"
+	"github.com/vishen/go-chromecast/storage"
+)
+
+// hooksCmd represents the hooks command
+var hooksCmd = &cobra.Command{
+	Use:   "hooks [<hooks_file>]",
+	Short: "Run commands or webhooks when the state of cast devices changes",
+	Long: `Watch cast devices and run shell commands, or post to webhooks, when their
+state changes, until interrupted. The hooks are a yaml file, by default
+hooks.yaml in the go-chromecast config directory:
+
+  debounce: 2s
+  hooks:
+    - name: lights
+      events: [media_start, media_pause, media_finish]
+      command: ~/bin/lights.sh
+    - name: home assistant
+      events: [volume_change]
+      webhook: http://homeassistant.local:8123/api/webhook/chromecast
+      debounce: 5s
+
+The events are:
+
+  media_start    media starts playing, or is unpaused
+  media_pause    media is paused
+  media_finish   media stops playing, ie: it finished or was stopped
+  app_change     the application on the device changes or closes
+  volume_change  the volume is changed or muted
+  disconnect     the connection to the device is lost
+
+Hooks without events are run for every event. The event is JSON, with the
+device and the state of it, and is written to the stdin of commands and is
+in their GO_CHROMECAST_EVENT_JSON environment variable, along with
+GO_CHROMECAST_EVENT for the type of event and GO_CHROMECAST_DEVICE for the
+name of the device. For webhooks the event is the body of a POST request.
+
+A hook with a debounce is run once events of the same type from a device
+have stopped for the debounce, with the last of them. Hooks can run for up
+to their timeout, 10 seconds by default.
+
+--check prints the hooks without watching any device.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		filename, err := hooksFile(args)
+		if err != nil {
+			exit("unable to find hooks: %v", err)
+		}
+		cfg, err := hooks.Load(filename)
+		if err != nil {
+			exit("unable to load hooks: %v", err)
+		}
+
+		if check, _ := cmd.Flags().GetBool("check"); check {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "HOOK\tEVENTS\tDEBOUNCE\tRUNS")
+			for _, h := range cfg.Hooks {
+				events := "all"
+				if len(h.Events) > 0 {
+					events = strings.Join(h.Events, ", ")
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", h.Name, events, h.Debounce, h)
+			}
+			w.Flush()
+			return
+		}
+
+		devices, err := findDevices(cmd, deviceFilterFromFlags(cmd))
+		if err != nil {
+			exit("unable to find devices: %v", err)
+		}
+		interval, _ := cmd.Flags().GetDuration("interval")
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		d := hooks.NewDispatcher(ctx, cfg)
+		d.Output = os.Stdout
+		d.Done = func(h hooks.Hook, e hooks.Event, err error) {
+			if err != nil {
+				outputError("%s: %s from %s: %v", h.Name, e.Type, e.Device.Name, err)
+				return
+			}
+			outputInfo("%s: ran for %s from %s", h.Name, e.Type, e.Device.Name)
+		}
+
+		var wg sync.WaitGroup
+		for _, device := range devices {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				watchDevice(ctx, cmd, device, interval, nil, d.Send)
+			}()
+		}
+		wg.Wait()
+		d.Wait()
+	},
+}
+
+// hooksFile returns the hooks given as an argument, or the default ones in
+// the config directory.
+func hooksFile(args []string) (string, error) {
+	if len(args) == 1 {
+		return args[0], nil
+	}
+	dir, err := storage.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "hooks.yaml"), nil
+}
+
+// watchDevice calls send with the events of the device until ctx is done,
+// connecting to the device again whenever the connection is lost. The
+// connection is checked every interval. connected, when not nil, is called
+// with the application and the state of the device each time it connects.
+func watchDevice(ctx context.Context, cmd *cobra.Command, device discovery.Device, interval time.Duration, connected func(app application.App, state hooks.Event), send func(e hooks.Event)) {
+	tracker := hooks.NewTracker(hooks.Device{Name: device.Name, UUID: device.UUID, Addr: device.Addr, Port: device.Port})
+	for {
+		app, err := connectApplication(cmd, device)
+		if err != nil {
+			outputError("unable to connect to %s, retrying: %v", deviceName(device), err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(10 * time.Second):
+			}
+			continue
+		}
+		outputInfo("Watching %s", deviceName(device))
+		tracker.Reset(app.Status())
+		if connected != nil {
+			connected(app, tracker.State())
+		}
+		app.AddMessageFunc(func(msg *pb.CastMessage) {
+			for _, e := range tracker.Message(msg) {
+				send(e)
+			}
+		})
+
+		ticker := time.NewTicker(interval)
+		for err == nil {
+			select {
+			case <-ctx.Done():
+				ticker.Stop()
+				app.Close(false)
+				return
+			case <-ticker.C:
+				err = app.Update()
+			}
+		}
+		ticker.Stop()
+		app.Close(false)
+		outputError("lost connection to %s: %v", deviceName(device), err)
+		send(tracker.Disconnect(err))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(hooksCmd)
+	hooksCmd.Flags().Bool("check", false, "print the hooks without watching any device")
+	hooksCmd.Flags().Duration("interval", 30*time.Second, "how often to check the connection to the devices")
+}
@@ -35,7 +35,25 @@ api to control chromecast devices on a network.`,
 		verbose, _ := cmd.Flags().GetBool("verbose")
 		debug, _ := cmd.Flags().GetBool("debug")
 
-		if err := http.NewHandler(verbose || debug).Serve(addr + ":" + port); err != nil {
+		// Multicast dns is always used by the handler, with the interface
+		// and timeout given in each request.
+		sources, err := discoverySources(cmd, nil, "mdns", "cache")
+		if err != nil {
+			exit("unable to set up device discovery: %v", err)
+		}
+		inventory, err := loadInventory(cmd)
+		if err != nil {
+			exit("%v", err)
+		}
+		volumeConfig, err := loadVolumeConfig(cmd)
+		if err != nil {
+			exit("%v", err)
+		}
+		handler := http.NewHandler(verbose || debug)
+		handler.SetDiscoverySources(sources...)
+		handler.SetInventory(inventory)
+		handler.SetVolumeConfig(volumeConfig)
+		if err := handler.Serve(addr + ":" + port); err != nil {
 			exit("unable to run http server: %v", err)
 		}
 	},
@@ -15,6 +15,11 @@
 package cmd
 
 import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/vishen/go-chromecast/application"
 	"github.com/vishen/go-chromecast/ui"
 
 	"github.com/spf13/cobra"
@@ -40,16 +45,34 @@ that ffmpeg is installed.`,
 			exit("unable to get cast application: %v", err)
 		}
 
+		// Local files are played by their absolute path, so they are
+		// resumed whichever directory they are played from.
+		filenameOrUrl := args[0]
+		if !strings.Contains(filenameOrUrl, "://") {
+			if abs, err := filepath.Abs(filenameOrUrl); err == nil {
+				filenameOrUrl = abs
+			}
+		}
+
 		contentType, _ := cmd.Flags().GetString("content-type")
 		transcode, _ := cmd.Flags().GetBool("transcode")
 		detach, _ := cmd.Flags().GetBool("detach")
 		startTime, _ := cmd.Flags().GetInt("start-time")
+		resume, _ := cmd.Flags().GetBool("resume")
+		resumeThreshold, _ := cmd.Flags().GetDuration("resume-threshold")
+
+		if resume && !cmd.Flags().Changed("start-time") {
+			if position, _ := app.ResumePosition(filenameOrUrl, resumeThreshold); position > 0 {
+				outputInfo("Resuming %s from %s", args[0], time.Duration(position)*time.Second)
+				startTime = position
+			}
+		}
 
 		// Optionally run a UI when playing this media:
 		runWithUI, _ := cmd.Flags().GetBool("with-ui")
 		if runWithUI {
 			go func() {
-				if err := app.Load(args[0], startTime, contentType, transcode, detach, false); err != nil {
+				if err := app.Load(filenameOrUrl, startTime, contentType, transcode, detach, false); err != nil {
 					exit("unable to load media: %v", err)
 				}
 			}()
@@ -65,7 +88,7 @@ that ffmpeg is installed.`,
 		}
 
 		// Otherwise just run in CLI mode:
-		if err := app.Load(args[0], startTime, contentType, transcode, detach, false); err != nil {
+		if err := app.Load(filenameOrUrl, startTime, contentType, transcode, detach, false); err != nil {
 			exit("unable to load media: %v", err)
 		}
 	},
@@ -77,4 +100,6 @@ func init() {
 	loadCmd.Flags().Bool("detach", false, "detach from waiting until media finished. Only works with url loaded external media")
 	loadCmd.Flags().StringP("content-type", "c", "", "content-type to serve the media file as")
 	loadCmd.Flags().Int("start-time", 0, "start time to play media, in seconds")
+	loadCmd.Flags().Bool("resume", false, "start from where the media was last played up to")
+	loadCmd.Flags().Duration("resume-threshold", application.DefaultResumeThreshold, "media played to within this of the end counts as finished, and isn't resumed")
 }
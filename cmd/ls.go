@@ -16,11 +16,11 @@ package cmd
 
 import (
 	"context"
-	"net"
-	"time"
+	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
-	castdns "github.com/vishen/go-chromecast/dns"
+	"github.com/vishen/go-chromecast/discovery"
 )
 
 // lsCmd represents the ls command
@@ -28,24 +28,31 @@ var lsCmd = &cobra.Command{
 	Use:   "ls",
 	Short: "List devices",
 	Run: func(cmd *cobra.Command, args []string) {
-		ifaceName, _ := cmd.Flags().GetString("iface")
-		dnsTimeoutSeconds, _ := cmd.Flags().GetInt("dns-timeout")
-		var iface *net.Interface
-		var err error
-		if ifaceName != "" {
-			if iface, err = net.InterfaceByName(ifaceName); err != nil {
-				exit("unable to find interface %q: %v", ifaceName, err)
-			}
+		iface, err := interfaceFromFlags(cmd)
+		if err != nil {
+			exit("%v", err)
+		}
+		// Only list devices that can currently be found, cached entries
+		// might be stale.
+		sources, err := discoverySources(cmd, iface, "cache")
+		if err != nil {
+			exit("unable to discover chromecast devices: %v", err)
 		}
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(dnsTimeoutSeconds))
-		defer cancel()
-		castEntryChan, err := castdns.DiscoverCastDNSEntries(ctx, iface)
+		castEntryChan, err := discovery.Multi(sources...).Discover(context.Background())
 		if err != nil {
 			exit("unable to discover chromecast devices: %v", err)
 		}
+		inventory, err := loadInventory(cmd)
+		if err != nil {
+			exit("%v", err)
+		}
 		i := 1
 		for d := range castEntryChan {
-			outputInfo("%d) device=%q device_name=%q address=\"%s:%d\" uuid=%q", i, d.Device, d.DeviceName, d.AddrV4, d.Port, d.UUID)
+			line := fmt.Sprintf("%d) device=%q device_name=%q address=\"%s:%d\" uuid=%q", i, d.Device, d.Name, d.Addr, d.Port, d.UUID)
+			if tags := inventory.TagsFor(d); len(tags) > 0 {
+				line += fmt.Sprintf(" tags=%q", strings.Join(tags, ","))
+			}
+			outputInfo("%s", line)
 			i++
 		}
 		if i == 1 {
@@ -15,7 +15,10 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
 )
 
 // muteCmd represents the mute command
@@ -23,13 +26,12 @@ var muteCmd = &cobra.Command{
 	Use:   "mute",
 	Short: "Mute the chromecast",
 	Run: func(cmd *cobra.Command, args []string) {
-		app, err := castApplication(cmd, args)
-		if err != nil {
-			exit("unable to get cast application: %v", err)
-		}
-		if err := app.SetMuted(true); err != nil {
-			exit("unable to mute cast application: %v", err)
-		}
+		runOnDevices(cmd, args, func(app application.App) (string, error) {
+			if err := app.SetMuted(true); err != nil {
+				return "", fmt.Errorf("unable to mute cast application: %w", err)
+			}
+			return "", nil
+		})
 	},
 }
 
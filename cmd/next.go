@@ -15,7 +15,10 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
 )
 
 // nextCmd represents the next command
@@ -23,13 +26,12 @@ var nextCmd = &cobra.Command{
 	Use:   "next",
 	Short: "Play the next available media",
 	Run: func(cmd *cobra.Command, args []string) {
-		app, err := castApplication(cmd, args)
-		if err != nil {
-			exit("unable to get cast application: %v", err)
-		}
-		if err := app.Next(); err != nil {
-			exit("unable to play next media: %v", err)
-		}
+		runOnDevices(cmd, args, func(app application.App) (string, error) {
+			if err := app.Next(); err != nil {
+				return "", fmt.Errorf("unable to play next media: %w", err)
+			}
+			return "", nil
+		})
 	},
 }
 
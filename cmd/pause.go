@@ -15,7 +15,10 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
 )
 
 // pauseCmd represents the pause command
@@ -23,13 +26,12 @@ var pauseCmd = &cobra.Command{
 	Use:   "pause",
 	Short: "Pause the currently playing media on the chromecast",
 	Run: func(cmd *cobra.Command, args []string) {
-		app, err := castApplication(cmd, args)
-		if err != nil {
-			exit("unable to get cast application: %v", err)
-		}
-		if err := app.Pause(); err != nil {
-			exit("unable to pause cast application: %v", err)
-		}
+		runOnDevices(cmd, args, func(app application.App) (string, error) {
+			if err := app.Pause(); err != nil {
+				return "", fmt.Errorf("unable to pause cast application: %w", err)
+			}
+			return "", nil
+		})
 	},
 }
 
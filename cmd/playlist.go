@@ -16,23 +16,19 @@ package cmd
 
 import (
 	"bufio"
-	"io/ioutil"
+	"bytes"
 	"os"
 	"path/filepath"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
+	"github.com/vishen/go-chromecast/playlists"
 	"github.com/vishen/go-chromecast/ui"
 )
 
-type mediaFile struct {
-	filename        string
-	possibleNumbers []int
-}
-
 // playlistCmd represents the playlist command
 var playlistCmd = &cobra.Command{
 	Use:   "playlist <directory>",
@@ -60,80 +56,24 @@ that ffmpeg is installed.`,
 
 		contentType, _ := cmd.Flags().GetString("content-type")
 		transcode, _ := cmd.Flags().GetBool("transcode")
-		forcePlay, _ := cmd.Flags().GetBool("force-play")
 		continuePlaying, _ := cmd.Flags().GetBool("continue")
 		selection, _ := cmd.Flags().GetBool("select")
-		files, err := ioutil.ReadDir(args[0])
+		resume, _ := cmd.Flags().GetBool("resume")
+		resumeThreshold, _ := cmd.Flags().GetDuration("resume-threshold")
+		filenames, seed, err := playlistFiles(cmd, args[0], app.PlayableMediaType)
 		if err != nil {
-			exit("unable to list files from %q: %v", args[0], err)
+			exit("%v", err)
 		}
-		filesToPlay := make([]mediaFile, 0, len(files))
-		for _, f := range files {
-			if !forcePlay && !app.PlayableMediaType(filepath.Join(args[0], f.Name())) {
-				continue
-			}
-
-			foundNum := false
-			numPos := 0
-			foundNumbers := []int{}
-			for i, c := range f.Name() {
-				if c < '0' || c > '9' {
-					if foundNum {
-						val, _ := strconv.Atoi(f.Name()[numPos:i])
-						foundNumbers = append(foundNumbers, val)
-					}
-					foundNum = false
-					continue
-				}
-
-				if !foundNum {
-					numPos = i
-					foundNum = true
-				}
-			}
-
-			filesToPlay = append(filesToPlay, mediaFile{
-				filename:        f.Name(),
-				possibleNumbers: foundNumbers,
-			})
-
+		if len(filenames) == 0 {
+			exit("no playable media found in %q", args[0])
 		}
-
-		sort.Slice(filesToPlay, func(i, j int) bool {
-			iNum := filesToPlay[i].possibleNumbers
-			jNum := filesToPlay[j].possibleNumbers
-			if len(iNum) == 0 {
-				return false
-			}
-			if len(jNum) == 0 {
-				return true
-			}
-			max := len(iNum)
-			if len(iNum) < len(jNum) {
-				max = len(jNum)
-			}
-			for vi := 0; vi < max; vi++ {
-				if len(iNum) <= vi {
-					return false
-				}
-				if len(jNum) <= vi {
-					return true
-				}
-				if iNum[vi] == jNum[vi] {
-					continue
-				}
-				if iNum[vi] > jNum[vi] {
-					return false
-				}
-				return true
+		if shuffled(cmd) {
+			outputInfo("Shuffled with --seed %d", seed)
+			// A new order can't be continued from where the last one
+			// was up to.
+			if !cmd.Flags().Changed("seed") {
+				continuePlaying = false
 			}
-			return true
-		})
-
-		filenames := make([]string, len(filesToPlay))
-		for i, f := range filesToPlay {
-			filename := filepath.Join(args[0], f.filename)
-			filenames[i] = filename
 		}
 
 		indexToPlayFrom := 0
@@ -168,8 +108,9 @@ that ffmpeg is installed.`,
 			var lastPlayedStartUnix int64 = 0
 			var lastPlayedEndUnix int64 = 0
 			lastPlayedIndex := 0
+			playedItems := app.PlayedItems()
 			for i, f := range filenames {
-				p, ok := app.PlayedItems()[f]
+				p, ok := playedItems[f]
 				if ok && p.Started > lastPlayedStartUnix {
 					lastPlayedStartUnix = p.Started
 					lastPlayedEndUnix = p.Finished
@@ -177,29 +118,44 @@ that ffmpeg is installed.`,
 				}
 			}
 
-			if lastPlayedIndex > 0 {
-				if lastPlayedStartUnix < lastPlayedEndUnix {
-					if len(filenames) > lastPlayedIndex {
-						// lastPlayedIndex += 1
-					} else {
-						lastPlayedIndex = 0
-					}
-				}
+			// If the last played media was finished, move on to the next
+			// one, or back to the start once everything has been played.
+			if lastPlayedStartUnix > 0 && lastPlayedStartUnix <= lastPlayedEndUnix {
+				lastPlayedIndex = (lastPlayedIndex + 1) % len(filenames)
 			}
 			indexToPlayFrom = lastPlayedIndex
 		}
 
+		startTime := 0
+		if resume {
+			position, finished := app.ResumePosition(filenames[indexToPlayFrom], resumeThreshold)
+			switch {
+			case finished && !selection && indexToPlayFrom+1 < len(filenames):
+				// The last played media was finished, so move on to the
+				// next one.
+				indexToPlayFrom++
+			case position > 0:
+				outputInfo("Resuming %s from %s", filenames[indexToPlayFrom], time.Duration(position)*time.Second)
+				startTime = position
+			}
+		}
+
+		filenames = limitFiles(cmd, filenames[indexToPlayFrom:])
+
 		s := "Attemping to play the following media:"
-		for _, f := range filenames[indexToPlayFrom:] {
+		for _, f := range filenames {
 			s += "- " + f + " "
 		}
 		outputInfo(s)
+		// Keep the playback rate for the directory, rather than the
+		// device.
+		app.SetPlaylist(args[0])
 
 		// Optionally run a UI when playing this media:
 		runWithUI, _ := cmd.Flags().GetBool("with-ui")
 		if runWithUI {
 			go func() {
-				if err := app.QueueLoad(filenames[indexToPlayFrom:], contentType, transcode); err != nil {
+				if err := app.QueueLoad(filenames, startTime, contentType, transcode); err != nil {
 					exit("unable to play playlist on cast application: %v", err)
 				}
 			}()
@@ -213,17 +169,223 @@ that ffmpeg is installed.`,
 			}
 		}
 
-		if err := app.QueueLoad(filenames[indexToPlayFrom:], contentType, transcode); err != nil {
+		if err := app.QueueLoad(filenames, startTime, contentType, transcode); err != nil {
 			exit("unable to play playlist on cast application: %v", err)
 		}
 	},
 }
 
+var playlistExportCmd = &cobra.Command{
+	Use:   "export [<directory>] <output>",
+	Short: "Save media as a playlist file",
+	Long: `Save media as a playlist file, either the media in a directory in the order the
+playlist command would play it, the queue playing on the device with --queue,
+or the watch history with --history, oldest first. The history can be filtered
+with the same flags as the history command.
+
+The format is taken from the extension of the output file, or set with
+--format, and the output is written to stdout as m3u when it is '-'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		queue, _ := cmd.Flags().GetBool("queue")
+		history, _ := cmd.Flags().GetBool("history")
+
+		var entries []playlists.Entry
+		switch {
+		case queue && history:
+			exit("only one of --queue or --history can be used")
+		case queue || history:
+			if len(args) != 1 {
+				exit("requires exactly one argument, should be the playlist to write")
+			}
+			if queue {
+				entries = queueEntries(cmd, args)
+			} else {
+				entries = historyEntries(cmd)
+			}
+		default:
+			if len(args) != 2 {
+				exit("requires a directory and the playlist to write")
+			}
+			filenames, seed, err := playlistFiles(cmd, args[0], application.PlayableMediaType)
+			if err != nil {
+				exit("%v", err)
+			}
+			if shuffled(cmd) && args[1] != "-" {
+				outputInfo("Shuffled with --seed %d", seed)
+			}
+			for _, f := range limitFiles(cmd, filenames) {
+				if abs, err := filepath.Abs(f); err == nil {
+					f = abs
+				}
+				entries = append(entries, playlists.Entry{URL: f})
+			}
+		}
+		writePlaylist(cmd, args[len(args)-1], entries)
+	},
+}
+
+var playlistConvertCmd = &cobra.Command{
+	Use:   "convert <input> <output>",
+	Short: "Convert a playlist file to another format",
+	Long: `Convert a playlist file to another format. The input can be a local file or a
+url in any format that can be played, and the output format is taken from the
+extension of the output file, or set with --format. The output is written to
+stdout as m3u when it is '-'.
+
+Relative paths in the input are written as absolute paths.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, err := playlists.ReadAll(args[0])
+		if err != nil {
+			exit("unable to read playlist: %v", err)
+		}
+		writePlaylist(cmd, args[1], entries)
+	},
+}
+
+// playlistFiles returns the playable media in dir, using the directory
+// flags to decide which files and in what order. The seed used to shuffle
+// is returned, so that the order can be repeated.
+func playlistFiles(cmd *cobra.Command, dir string, playable func(filename string) bool) ([]string, int64, error) {
+	opts := playlists.DirOptions{Playable: playable}
+	opts.Recursive, _ = cmd.Flags().GetBool("recursive")
+	opts.Include, _ = cmd.Flags().GetStringArray("include")
+	opts.Exclude, _ = cmd.Flags().GetStringArray("exclude")
+	opts.Sort, _ = cmd.Flags().GetString("sort")
+	opts.Seed, _ = cmd.Flags().GetInt64("seed")
+	if forcePlay, _ := cmd.Flags().GetBool("force-play"); forcePlay {
+		opts.Playable = nil
+	}
+	if shuffle, _ := cmd.Flags().GetBool("shuffle"); shuffle {
+		opts.Sort = playlists.SortRandom
+	}
+	if opts.Sort == playlists.SortRandom && !cmd.Flags().Changed("seed") {
+		opts.Seed = time.Now().UnixNano()
+	}
+	filenames, err := playlists.ListDir(dir, opts)
+	return filenames, opts.Seed, err
+}
+
+func shuffled(cmd *cobra.Command) bool {
+	sort, _ := cmd.Flags().GetString("sort")
+	shuffle, _ := cmd.Flags().GetBool("shuffle")
+	return shuffle || sort == playlists.SortRandom
+}
+
+// addDirectoryFlags adds the flags for choosing the media in a directory.
+func addDirectoryFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("recursive", false, "include media in subdirectories")
+	cmd.Flags().StringArray("include", nil, "only include media whose name or relative path matches the glob, ie: '*.flac'. Can be repeated")
+	cmd.Flags().StringArray("exclude", nil, "exclude media and directories whose name or relative path matches the glob, ie: 'Extras'. Can be repeated")
+	cmd.Flags().String("sort", playlists.SortNatural, "order to play the media in, one of: "+strings.Join(playlists.SortStrategies, ", "))
+	cmd.Flags().Bool("shuffle", false, "play the media in a random order, the same as --sort random")
+	cmd.Flags().Int64("seed", 0, "seed for the random order, so that it can be repeated (default random)")
+	cmd.Flags().Int("limit", 0, "maximum number of media to play, 0 for no limit")
+}
+
+// limitFiles returns at most the --limit first filenames.
+func limitFiles(cmd *cobra.Command, filenames []string) []string {
+	if limit, _ := cmd.Flags().GetInt("limit"); limit > 0 && limit < len(filenames) {
+		return filenames[:limit]
+	}
+	return filenames
+}
+
+// queueEntries returns the queue playing on the device, with media served
+// by go-chromecast as the local files.
+func queueEntries(cmd *cobra.Command, args []string) []playlists.Entry {
+	app, err := castApplication(cmd, args)
+	if err != nil {
+		exit("unable to get cast application: %v", err)
+	}
+	items, err := app.QueueItems()
+	if err != nil {
+		exit("unable to get queue: %v", err)
+	}
+	entries := make([]playlists.Entry, len(items))
+	for i, item := range items {
+		e := playlists.Entry{
+			URL:      item.Media.ContentId,
+			Title:    item.Media.Metadata.Title,
+			Artist:   item.Media.Metadata.Artist,
+			Duration: time.Duration(item.Media.Duration * float32(time.Second)),
+		}
+		if filename, ok := application.LocalFile(e.URL); ok {
+			e.URL = filename
+		}
+		if len(item.Media.Metadata.Images) > 0 {
+			e.Image = item.Media.Metadata.Images[0].URL
+		}
+		entries[i] = e
+	}
+	return entries
+}
+
+// historyEntries returns the filtered watch history, oldest first.
+func historyEntries(cmd *cobra.Command) []playlists.Entry {
+	items, err := filteredHistory(cmd)
+	if err != nil {
+		exit("%v", err)
+	}
+	entries := make([]playlists.Entry, len(items))
+	for i, pi := range items {
+		entries[len(items)-1-i] = playlists.Entry{
+			URL:      pi.ContentID,
+			Duration: time.Duration(pi.Duration * float32(time.Second)),
+		}
+	}
+	return entries
+}
+
+// writePlaylist writes the entries to output, or stdout if it is '-', in
+// the format from --format or the output extension, defaulting to m3u for
+// stdout.
+func writePlaylist(cmd *cobra.Command, output string, entries []playlists.Entry) {
+	format, _ := cmd.Flags().GetString("format")
+	switch {
+	case format != "":
+	case output == "-":
+		format = "m3u"
+	default:
+		format = playlists.FormatFor(output)
+	}
+	if format == "" {
+		exit("unable to tell the playlist format from %q, use --format", output)
+	}
+	var buf bytes.Buffer
+	if err := playlists.Write(&buf, format, entries); err != nil {
+		exit("%v", err)
+	}
+	if output == "-" {
+		os.Stdout.Write(buf.Bytes())
+		return
+	}
+	if err := os.WriteFile(output, buf.Bytes(), 0644); err != nil {
+		exit("unable to write playlist: %v", err)
+	}
+	outputInfo("wrote %d entries to %s", len(entries), output)
+}
+
 func init() {
 	rootCmd.AddCommand(playlistCmd)
 	playlistCmd.Flags().Bool("continue", true, "continue playing from the last known media")
 	playlistCmd.Flags().Bool("select", false, "choose which media to start the playlist from")
+	playlistCmd.Flags().Bool("resume", false, "start the first media from where it was last played up to, or the next media if it was finished")
+	playlistCmd.Flags().Duration("resume-threshold", application.DefaultResumeThreshold, "media played to within this of the end counts as finished, and isn't resumed")
 	playlistCmd.Flags().Bool("transcode", true, "transcode the media to mp4 if media type is unrecognised")
 	playlistCmd.Flags().Bool("force-play", false, "attempt to play a media type even if it is unrecognised")
+	addDirectoryFlags(playlistCmd)
 	playlistCmd.Flags().StringP("content-type", "c", "", "content-type to serve the media file as")
+
+	formatUsage := "playlist format, one of: " + strings.Join(playlists.WriteFormats, ", ") + " (default from the output extension)"
+	playlistExportCmd.Flags().Bool("queue", false, "export the queue playing on the device")
+	playlistExportCmd.Flags().Bool("history", false, "export the watch history")
+	playlistExportCmd.Flags().Bool("force-play", false, "include media in the directory even if its type is unrecognised")
+	addDirectoryFlags(playlistExportCmd)
+	playlistExportCmd.Flags().String("since", "", "with --history, only media played since a date (2006-01-02), time (RFC3339) or duration ago (48h)")
+	playlistExportCmd.Flags().String("until", "", "with --history, only media played before a date (2006-01-02), time (RFC3339) or duration ago (48h)")
+	playlistExportCmd.Flags().String("prefix", "", "with --history, only media whose path or url starts with the prefix")
+	playlistExportCmd.Flags().String("format", "", formatUsage)
+	playlistConvertCmd.Flags().String("format", "", formatUsage)
+	playlistCmd.AddCommand(playlistExportCmd, playlistConvertCmd)
 }
@@ -0,0 +1,169 @@
+// Copyright © 2018 Jonathan Pentecost <pentecostjonathan@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
+	"github.com/vishen/go-chromecast/podcast"
+)
+
+// podcastCmd represents the podcast command
+var podcastCmd = &cobra.Command{
+	Use:   "podcast <feed_file_or_url>",
+	Short: "Play an episode of a podcast on the chromecast",
+	Long: `Play an episode of a podcast on the chromecast, from an RSS or Atom feed that
+is either a url or a local file. The newest episode that hasn't been played
+to the end is played, or the one given with --episode, which is its number
+in 'podcast list'. An episode that was stopped part way through carries on
+from where it was up to.
+
+go-chromecast keeps running while the episode plays, to record how far it
+has been played.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		feed := loadPodcast(args[0])
+		number, _ := cmd.Flags().GetInt("episode")
+		resumeThreshold, _ := cmd.Flags().GetDuration("resume-threshold")
+
+		var episode podcast.Episode
+		if number != 0 {
+			if number < 1 || number > len(feed.Episodes) {
+				exit("there is no episode %d, %s has %d episodes", number, feed.Title, len(feed.Episodes))
+			}
+			episode = feed.Episodes[number-1]
+		} else {
+			played := playedEpisodes(cmd)
+			found := false
+			for _, e := range feed.Episodes {
+				if pi, ok := played[e.URL]; !ok || !pi.IsFinished(resumeThreshold) {
+					episode, found = e, true
+					break
+				}
+			}
+			if !found {
+				exit("every episode of %s has been played, choose one with --episode", feed.Title)
+			}
+		}
+
+		app, err := castApplication(cmd, args)
+		if err != nil {
+			exit("unable to get cast application: %v", err)
+		}
+		position, _ := app.ResumePosition(episode.URL, resumeThreshold)
+		if position > 0 {
+			outputInfo("Resuming %s from %s", episode.Title, time.Duration(position)*time.Second)
+		} else {
+			outputInfo("Playing %s", episode.Title)
+		}
+		if err := app.PlayEpisode(feed, episode, position); err != nil {
+			exit("unable to play episode: %v", err)
+		}
+	},
+}
+
+var podcastListCmd = &cobra.Command{
+	Use:   "list <feed_file_or_url>",
+	Short: "List the episodes of a podcast",
+	Long: `List the episodes of a podcast, newest first, with how far each has been
+played.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		feed := loadPodcast(args[0])
+		limit, _ := cmd.Flags().GetInt("limit")
+		resumeThreshold, _ := cmd.Flags().GetDuration("resume-threshold")
+		played := playedEpisodes(cmd)
+
+		episodes := feed.Episodes
+		if limit > 0 && limit < len(episodes) {
+			episodes = episodes[:limit]
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "#\tPUBLISHED\tDURATION\tPLAYED\tTITLE")
+		for i, e := range episodes {
+			published, duration, progress := "-", "-", "-"
+			if !e.Published.IsZero() {
+				published = e.Published.Local().Format("2006-01-02")
+			}
+			if e.Duration > 0 {
+				duration = e.Duration.Round(time.Second).String()
+			}
+			if pi, ok := played[e.URL]; ok {
+				switch {
+				case pi.IsFinished(resumeThreshold):
+					progress = "finished"
+				case pi.Duration > 0:
+					progress = formatSeconds(pi.Position) + "/" + formatSeconds(pi.Duration)
+				default:
+					progress = formatSeconds(pi.Position)
+				}
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", i+1, published, duration, progress, e.Title)
+		}
+		w.Flush()
+	},
+}
+
+// loadPodcast loads the podcast feed, exiting if it has no episodes.
+func loadPodcast(uri string) *podcast.Feed {
+	feed, err := podcast.Load(uri)
+	if err != nil {
+		exit("unable to load podcast: %v", err)
+	}
+	if len(feed.Episodes) == 0 {
+		exit("%s has no episodes", uri)
+	}
+	if feed.Title == "" {
+		feed.Title = uri
+	}
+	return feed
+}
+
+// playedEpisodes returns the played items by their content id, which is
+// the url of the episode. Nothing has been played when the cache is
+// disabled or can't be read.
+func playedEpisodes(cmd *cobra.Command) map[string]application.PlayedItem {
+	played := map[string]application.PlayedItem{}
+	if disableCache, _ := cmd.Flags().GetBool("disable-cache"); disableCache {
+		return played
+	}
+	store, err := openStore(cmd)
+	if err != nil {
+		outputError("%v", err)
+		return played
+	}
+	items, err := application.History(store)
+	if err != nil {
+		outputError("%v", err)
+		return played
+	}
+	for _, pi := range items {
+		played[pi.ContentID] = pi
+	}
+	return played
+}
+
+func init() {
+	rootCmd.AddCommand(podcastCmd)
+	podcastCmd.AddCommand(podcastListCmd)
+	podcastCmd.PersistentFlags().Duration("resume-threshold", application.DefaultResumeThreshold, "episodes played to within this of the end count as finished")
+	podcastCmd.Flags().Int("episode", 0, "number of the episode to play, as shown by 'podcast list', 1 is the newest (default the newest that hasn't been played)")
+	podcastListCmd.Flags().Int("limit", 20, "maximum number of episodes to list, 0 for all")
+}
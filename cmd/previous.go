@@ -15,7 +15,10 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
 )
 
 // previousCmd represents the previous command
@@ -23,13 +26,12 @@ var previousCmd = &cobra.Command{
 	Use:   "previous",
 	Short: "Play the previous available media",
 	Run: func(cmd *cobra.Command, args []string) {
-		app, err := castApplication(cmd, args)
-		if err != nil {
-			exit("unable to get cast application: %v", err)
-		}
-		if err := app.Previous(); err != nil {
-			exit("unable to play previous media: %v", err)
-		}
+		runOnDevices(cmd, args, func(app application.App) (string, error) {
+			if err := app.Previous(); err != nil {
+				return "", fmt.Errorf("unable to play previous media: %w", err)
+			}
+			return "", nil
+		})
 	},
 }
 
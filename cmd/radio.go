@@ -0,0 +1,247 @@
+// Copyright © 2018 Jonathan Pentecost <pentecostjonathan@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// radioStation is a saved internet radio station.
+type radioStation struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+}
+
+// radioStations are the saved stations, kept in a yaml file:
+//
+//	stations:
+//	  - name: indiepop
+//	    url: https://somafm.com/indiepop64.pls
+type radioStations struct {
+	Stations []radioStation `yaml:"stations"`
+}
+
+// radioCmd represents the radio command
+var radioCmd = &cobra.Command{
+	Use:   "radio <station_or_url>",
+	Short: "Play an internet radio station on the chromecast",
+	Long: `Play an internet radio station on the chromecast, either a saved station or
+the url of an Icecast or Shoutcast stream, or of a playlist (ie: .pls or .m3u)
+of streams which are tried in order.
+
+The stream is played through a streaming server started locally, so that the
+title of what is playing, sent by the station, can be shown on the device as
+it changes. go-chromecast needs to keep running for the station to play.
+
+Stations are saved with 'radio save', and are kept in radio.yaml in the
+go-chromecast config directory, or the file given with --stations.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		stations, err := loadStations(cmd)
+		if err != nil {
+			exit("unable to load stations: %v", err)
+		}
+		name, _ := cmd.Flags().GetString("name")
+		stationURL := args[0]
+		if station, ok := stations.find(args[0]); ok {
+			stationURL = station.URL
+			if name == "" {
+				name = station.Name
+			}
+		} else if !isStationURL(stationURL) {
+			exit("no station named %q, see 'radio list'", args[0])
+		}
+
+		app, err := castApplication(cmd, args)
+		if err != nil {
+			exit("unable to get cast application: %v", err)
+		}
+		err = app.PlayRadio(stationURL, name, func(title string) {
+			outputInfo("Now playing: %s", title)
+		})
+		if err != nil {
+			exit("unable to play radio: %v", err)
+		}
+	},
+}
+
+var radioSaveCmd = &cobra.Command{
+	Use:   "save <name> <url>",
+	Short: "Save an internet radio station",
+	Long: `Save an internet radio station to play with 'radio <name>'. The url is that of
+a stream or of a playlist of streams, which can be a local file, and replaces
+the url of a station that is already saved with the name.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		name, stationURL := args[0], args[1]
+		if !isStationURL(stationURL) {
+			exit("%q is not an http url or a playlist", stationURL)
+		}
+		if !strings.Contains(stationURL, "://") {
+			if abs, err := filepath.Abs(stationURL); err == nil {
+				stationURL = abs
+			}
+		}
+		stations, err := loadStations(cmd)
+		if err != nil {
+			exit("unable to load stations: %v", err)
+		}
+		i := stations.index(name)
+		if i < 0 {
+			stations.Stations = append(stations.Stations, radioStation{Name: name, URL: stationURL})
+		} else {
+			stations.Stations[i].URL = stationURL
+		}
+		if err := saveStations(cmd, stations); err != nil {
+			exit("unable to save stations: %v", err)
+		}
+		outputInfo("Saved station %s", name)
+	},
+}
+
+var radioListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the saved internet radio stations",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		stations, err := loadStations(cmd)
+		if err != nil {
+			exit("unable to load stations: %v", err)
+		}
+		if len(stations.Stations) == 0 {
+			outputInfo("no saved stations")
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tURL")
+		for _, s := range stations.Stations {
+			fmt.Fprintf(w, "%s\t%s\n", s.Name, s.URL)
+		}
+		w.Flush()
+	},
+}
+
+var radioRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a saved internet radio station",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		stations, err := loadStations(cmd)
+		if err != nil {
+			exit("unable to load stations: %v", err)
+		}
+		i := stations.index(args[0])
+		if i < 0 {
+			exit("no station named %q", args[0])
+		}
+		stations.Stations = append(stations.Stations[:i], stations.Stations[i+1:]...)
+		if err := saveStations(cmd, stations); err != nil {
+			exit("unable to save stations: %v", err)
+		}
+		outputInfo("Removed station %s", args[0])
+	},
+}
+
+// index returns the index of the station with the name, ignoring case, or
+// -1 if there isn't one.
+func (s *radioStations) index(name string) int {
+	for i, station := range s.Stations {
+		if strings.EqualFold(station.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *radioStations) find(name string) (radioStation, bool) {
+	if i := s.index(name); i >= 0 {
+		return s.Stations[i], true
+	}
+	return radioStation{}, false
+}
+
+// isStationURL returns whether a station can be played from the url, an
+// http url or a local playlist of them.
+func isStationURL(stationURL string) bool {
+	if strings.HasPrefix(stationURL, "http://") || strings.HasPrefix(stationURL, "https://") {
+		return true
+	}
+	fi, err := os.Stat(stationURL)
+	return err == nil && fi.Mode().IsRegular()
+}
+
+// stationsFile returns the file the saved stations are kept in.
+func stationsFile(cmd *cobra.Command) (string, error) {
+	if filename, _ := cmd.Flags().GetString("stations"); filename != "" {
+		return filename, nil
+	}
+	dir, err := storage.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "radio.yaml"), nil
+}
+
+// loadStations reads the saved stations, there are none when the file
+// doesn't exist yet.
+func loadStations(cmd *cobra.Command) (*radioStations, error) {
+	filename, err := stationsFile(cmd)
+	if err != nil {
+		return nil, err
+	}
+	stations := &radioStations{}
+	b, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return stations, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(b, stations); err != nil {
+		return nil, fmt.Errorf("unable to parse stations %q: %w", filename, err)
+	}
+	return stations, nil
+}
+
+func saveStations(cmd *cobra.Command, stations *radioStations) error {
+	filename, err := stationsFile(cmd)
+	if err != nil {
+		return err
+	}
+	b, err := yaml.Marshal(stations)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, b, 0644)
+}
+
+func init() {
+	rootCmd.AddCommand(radioCmd)
+	radioCmd.PersistentFlags().String("stations", "", "File the saved stations are kept in (default is radio.yaml in the go-chromecast config directory)")
+	radioCmd.Flags().String("name", "", "name of the station to show on the device (default is the saved name, or the name sent by the station)")
+	radioCmd.AddCommand(radioSaveCmd, radioListCmd, radioRemoveCmd)
+}
@@ -0,0 +1,63 @@
+// Copyright © 2018 Jonathan Pentecost <pentecostjonathan@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
+)
+
+// rateCmd represents the rate command
+var rateCmd = &cobra.Command{
+	Use:   "rate [<0.5 - 2>]",
+	Short: "Get or set the playback rate of the media",
+	Long: `Get or set the playback rate of the playing media, from 0.5 to 2 times the
+normal rate, ie: 1.5 or 1.5x.
+
+The rate is kept by the go-chromecast process that loaded the media, ie: load
+or playlist, for the playlist it is playing or for the device, and the media it
+plays afterwards plays at the same rate. Media loaded by anything else only
+has its rate changed.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var rate float32
+		if len(args) == 1 {
+			var err error
+			if rate, err = application.ParsePlaybackRate(args[0]); err != nil {
+				exit("invalid playback rate: %v", err)
+			}
+		}
+		runOnDevices(cmd, args, func(app application.App) (string, error) {
+			if rate != 0 {
+				if err := app.SetPlaybackRate(rate); err != nil {
+					return "", fmt.Errorf("failed to set playback rate: %w", err)
+				}
+				return fmt.Sprintf("%gx", rate), nil
+			}
+
+			_, media, _ := app.Status()
+			if media == nil {
+				return "", application.ErrNoMediaPlaybackRate
+			}
+			return fmt.Sprintf("%gx", application.MediaPlaybackRate(media)), nil
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rateCmd)
+}
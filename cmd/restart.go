@@ -15,7 +15,10 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
 )
 
 // restartCmd represents the restart command
@@ -23,13 +26,12 @@ var restartCmd = &cobra.Command{
 	Use:   "restart",
 	Short: "Restart the currently playing media",
 	Run: func(cmd *cobra.Command, args []string) {
-		app, err := castApplication(cmd, args)
-		if err != nil {
-			exit("unable to get cast application: %v", err)
-		}
-		if err := app.SeekFromStart(0); err != nil {
-			exit("unable to restart media: %v", err)
-		}
+		runOnDevices(cmd, args, func(app application.App) (string, error) {
+			if err := app.SeekFromStart(0); err != nil {
+				return "", fmt.Errorf("unable to restart media: %w", err)
+			}
+			return "", nil
+		})
 	},
 }
 
@@ -15,9 +15,14 @@
 package cmd
 
 import (
+	"strings"
 	"time"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+
+	"github.com/vishen/go-chromecast/discovery"
+	"github.com/vishen/go-chromecast/storage"
 )
 
 var (
@@ -32,6 +37,11 @@ var rootCmd = &cobra.Command{
 	Short: "CLI for interacting with the Google Chromecast",
 	Long: `Control your Google Chromecast or Google Home Mini from the
 command line.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if debug, _ := cmd.Flags().GetBool("debug"); debug {
+			log.SetLevel(log.DebugLevel)
+		}
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		printVersion, _ := cmd.Flags().GetBool("version")
 		if printVersion {
@@ -68,8 +78,13 @@ func init() {
 	rootCmd.PersistentFlags().BoolP("debug", "v", false, "debug logging")
 	rootCmd.PersistentFlags().Bool("verbose", false, "verbose logging")
 	rootCmd.PersistentFlags().Bool("disable-cache", false, "disable the cache")
+	rootCmd.PersistentFlags().String("cache-dir", "", "Directory to keep the cache in (default is go-chromecast in $XDG_CACHE_HOME or the platform's cache directory)")
+	rootCmd.PersistentFlags().Duration("cache-ttl", discovery.DefaultCacheTTL, "How long a cached device is used for before it has to be found again")
+	rootCmd.PersistentFlags().String("cache-validate", "tcp", "How a cached device is checked before it is used, one of: none, tcp (connect to the device), info (check the device at the address has the same uuid)")
+	rootCmd.PersistentFlags().String("cache-backend", "file", "Where to keep the cache, one of: "+strings.Join(storage.Backends, ", "))
 	rootCmd.PersistentFlags().Bool("with-ui", false, "run with a UI")
-	rootCmd.PersistentFlags().StringP("device", "d", "", "chromecast device, ie: 'Chromecast' or 'Google Home Mini'")
+	rootCmd.PersistentFlags().StringArrayP("device", "d", nil, "chromecast device selector: an alias or device model, ie: 'Chromecast' or 'Google Home Mini', or one of 'alias:<alias>', 'tag:<tag>', 'name:<name>', 'uuid:<uuid>', 'model:<model>'. Can be repeated to select several devices")
+	rootCmd.PersistentFlags().Bool("all", false, "run the command on every cast device found")
 	rootCmd.PersistentFlags().StringP("device-name", "n", "", "chromecast device name")
 	rootCmd.PersistentFlags().StringP("uuid", "u", "", "chromecast device uuid")
 	rootCmd.PersistentFlags().StringP("addr", "a", "", "Address of the chromecast device")
@@ -78,4 +93,8 @@ func init() {
 	rootCmd.PersistentFlags().IntP("server-port", "s", 0, "Listening port for the http server")
 	rootCmd.PersistentFlags().Int("dns-timeout", 3, "Multicast DNS timeout in seconds when searching for chromecast DNS entries")
 	rootCmd.PersistentFlags().Bool("first", false, "Use first cast device found")
+	rootCmd.PersistentFlags().String("volume-config", "", "File (yaml) of volume limits, steps and presets for devices (default is volume.yaml in the go-chromecast config directory)")
+	rootCmd.PersistentFlags().String("inventory", "", "File (yaml or json) listing cast devices that are always known about, ie: devices not reachable by multicast dns")
+	rootCmd.PersistentFlags().StringSlice("discovery", []string{"inventory", "cache", "mdns"}, "Sources to find cast devices with, in priority order. Any of: inventory, cache, mdns, scan")
+	rootCmd.PersistentFlags().String("scan-cidr", "", "CIDR expression of the subnet to search when using the 'scan' discovery source")
 }
@@ -0,0 +1,251 @@
+// Copyright © 2018 Jonathan Pentecost <pentecostjonathan@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
+	"github.com/vishen/go-chromecast/hooks"
+	"github.com/vishen/go-chromecast/rules"
+	"github.com/vishen/go-chromecast/storage"
+)
+
+// rulesCmd represents the rules command
+var rulesCmd = &cobra.Command{
+	Use:   "rules [<rules_file>]",
+	Short: "Run actions on cast devices when their state meets conditions",
+	Long: `Watch cast devices and run actions on them when an event from them meets
+the conditions of a rule, until interrupted. The rules are a yaml file, by
+default rules.yaml in the go-chromecast config directory:
+
+  rules:
+    - name: quiet youtube at night
+      when:
+        events: [media_start, volume_change]
+        devices: [Living room TV]
+        apps: [YouTube]
+        between: "22:00-07:00"
+        volume_above: 0.3
+      then:
+        action: volume
+        volume: 0.3
+    - name: kitchen idle
+      when:
+        devices: [Kitchen speaker]
+        idle_for: 10m
+      then:
+        action: stop
+
+The conditions are:
+
+  events         the types of event, the same as for 'hooks'
+  devices        the names or uuids of the devices
+  apps           the ids or names of the application on the device
+  player_states  PLAYING, PAUSED, BUFFERING or IDLE, when there is no media
+  between        a time of day window, which can go over midnight
+  volume_above   the volume is above this
+  volume_below   the volume is below this
+  idle_for       the device hasn't been playing for this long, which runs
+                 the rule once each time the device stops playing
+
+Conditions that aren't given match everything. The actions are volume, mute,
+unmute, pause, unpause, stop_media and stop, which closes the application.
+
+--record writes every event to a file, one JSON event a line, that 'rules
+replay' can run rules against.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadRules(args)
+		devices, err := findDevices(cmd, deviceFilterFromFlags(cmd))
+		if err != nil {
+			exit("unable to find devices: %v", err)
+		}
+		interval, _ := cmd.Flags().GetDuration("interval")
+
+		var record *json.Encoder
+		if filename, _ := cmd.Flags().GetString("record"); filename != "" {
+			f, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+			if err != nil {
+				exit("unable to open event log: %v", err)
+			}
+			defer f.Close()
+			record = json.NewEncoder(f)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		r := &ruleRunner{engine: rules.NewEngine(cfg), apps: map[string]application.App{}, last: map[string]chan struct{}{}, record: record}
+		go func() {
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case t := <-ticker.C:
+					r.run(r.engine.Advance(t))
+				}
+			}
+		}()
+
+		var wg sync.WaitGroup
+		for _, device := range devices {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				watchDevice(ctx, cmd, device, interval, r.connected, r.event)
+			}()
+		}
+		wg.Wait()
+		r.wg.Wait()
+	},
+}
+
+var rulesReplayCmd = &cobra.Command{
+	Use:   "replay <event_log> [<rules_file>]",
+	Short: "Print the rules that would have run for a log of events",
+	Long: `Print the rules that would have run for a log of events, without connecting to
+any device. The log has a JSON event a line, as written by 'rules --record'
+or by a hook with the command "cat >> events.jsonl". Rules with idle_for are
+run up to the time of the last event.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadRules(args[1:])
+		f, err := os.Open(args[0])
+		if err != nil {
+			exit("unable to open event log: %v", err)
+		}
+		defer f.Close()
+		events, err := rules.ReadEvents(f)
+		if err != nil {
+			exit("unable to read event log: %v", err)
+		}
+
+		firings := rules.Replay(cfg, events)
+		if len(firings) == 0 {
+			outputInfo("no rules would have run for %d events", len(events))
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tRULE\tDEVICE\tEVENT\tACTION")
+		for _, f := range firings {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", f.Event.Time.Local().Format("2006-01-02 15:04:05"), f.Rule.Name, f.Event.Device.Name, f.Event.Type, f.Rule.Then)
+		}
+		w.Flush()
+	},
+}
+
+// loadRules loads the rules given as an argument, or the default ones in
+// the config directory.
+func loadRules(args []string) *rules.Config {
+	var filename string
+	if len(args) == 1 {
+		filename = args[0]
+	} else {
+		dir, err := storage.ConfigDir()
+		if err != nil {
+			exit("unable to find rules: %v", err)
+		}
+		filename = filepath.Join(dir, "rules.yaml")
+	}
+	cfg, err := rules.Load(filename)
+	if err != nil {
+		exit("unable to load rules: %v", err)
+	}
+	return cfg
+}
+
+// ruleRunner runs the actions of rules on the devices they are for.
+type ruleRunner struct {
+	engine *rules.Engine
+	record *json.Encoder
+
+	mu   sync.Mutex
+	apps map[string]application.App
+	// last is closed once the last action started on a device has
+	// finished, keyed like apps.
+	last map[string]chan struct{}
+	wg   sync.WaitGroup
+}
+
+func (r *ruleRunner) connected(app application.App, state hooks.Event) {
+	r.mu.Lock()
+	r.apps[state.Device.Key()] = app
+	r.mu.Unlock()
+	r.run(r.engine.Event(state))
+}
+
+func (r *ruleRunner) event(e hooks.Event) {
+	r.mu.Lock()
+	if r.record != nil {
+		if err := r.record.Encode(e); err != nil {
+			outputError("unable to record event: %v", err)
+		}
+	}
+	if e.Type == hooks.EventDisconnect {
+		delete(r.apps, e.Device.Key())
+	}
+	r.mu.Unlock()
+	r.run(r.engine.Event(e))
+}
+
+// run runs the actions of the rules, each in its own goroutine. The
+// actions on a device run one at a time, in the order the rules fired.
+func (r *ruleRunner) run(firings []rules.Firing) {
+	for _, f := range firings {
+		key := f.Event.Device.Key()
+		r.mu.Lock()
+		app, ok := r.apps[key]
+		previous, done := r.last[key], make(chan struct{})
+		if ok {
+			r.last[key] = done
+		}
+		r.mu.Unlock()
+		if !ok {
+			outputError("%s: %s isn't connected", f.Rule.Name, f.Event.Device.Name)
+			continue
+		}
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			defer close(done)
+			if previous != nil {
+				<-previous
+			}
+			if err := f.Rule.Then.Do(app); err != nil {
+				outputError("%s: unable to %s on %s: %v", f.Rule.Name, f.Rule.Then, f.Event.Device.Name, err)
+				return
+			}
+			outputInfo("%s: %s on %s", f.Rule.Name, f.Rule.Then, f.Event.Device.Name)
+		}()
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(rulesCmd)
+	rulesCmd.AddCommand(rulesReplayCmd)
+	rulesCmd.Flags().String("record", "", "append every event to this file, for 'rules replay'")
+	rulesCmd.Flags().Duration("interval", 30*time.Second, "how often to check the connection to the devices")
+}
@@ -15,14 +15,11 @@
 package cmd
 
 import (
-	"fmt"
-	"net"
-	"sync"
+	"context"
 	"time"
 
-	"github.com/seancfoley/ipaddress-go/ipaddr"
 	"github.com/spf13/cobra"
-	"github.com/vishen/go-chromecast/application"
+	"github.com/vishen/go-chromecast/discovery"
 )
 
 // scanCmd triggers a scan
@@ -30,57 +27,25 @@ var scanCmd = &cobra.Command{
 	Use:   "scan",
 	Short: "Scan for chromecast devices",
 	Run: func(cmd *cobra.Command, args []string) {
-		var (
-			cidrAddr, _  = cmd.Flags().GetString("cidr")
-			port, _      = cmd.Flags().GetInt("port")
-			wg           sync.WaitGroup
-			ipCh         = make(chan *ipaddr.IPAddress)
-			logged       = time.Unix(0, 0)
-			start        = time.Now()
-			count        int
-			ipRange, err = ipaddr.NewIPAddressString(cidrAddr).ToSequentialRange()
-		)
+		cidrAddr, _ := cmd.Flags().GetString("cidr")
+		port, _ := cmd.Flags().GetInt("port")
+		start := time.Now()
+
+		src, err := discovery.NewScanSource(cidrAddr, port)
+		if err != nil {
+			exit("%v", err)
+		}
+		outputInfo("Scanning %s...", cidrAddr)
+		devices, err := src.Discover(context.Background())
 		if err != nil {
-			exit("could not parse cidr address expression: %v", err)
+			exit("unable to scan: %v", err)
 		}
-		// Use one goroutine to send URIs over a channel
-		go func() {
-			it := ipRange.Iterator()
-			for it.HasNext() {
-				ip := it.Next()
-				if time.Since(logged) > 8*time.Second {
-					outputInfo("Scanning...  scanned %d, current %v\n", count, ip.String())
-					logged = time.Now()
-				}
-				ipCh <- ip
-				count++
-			}
-			close(ipCh)
-		}()
-		// Use a bunch of goroutines to do connect-attempts.
-		for i := 0; i < 64; i++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				dialer := &net.Dialer{
-					Timeout: 400 * time.Millisecond,
-				}
-				for ip := range ipCh {
-					conn, err := dialer.Dial("tcp", fmt.Sprintf("%v:%d", ip, port))
-					if err != nil {
-						continue
-					}
-					conn.Close()
-					if info, err := application.GetInfo(ip.String()); err != nil {
-						outputInfo("  - Device at %v:%d errored during discovery: %v", ip, port, err)
-					} else {
-						outputInfo("  - '%v' at %v:%d\n", info.Name, ip, port)
-					}
-				}
-			}()
+		count := 0
+		for d := range devices {
+			outputInfo("  - '%v' at %v:%d uuid=%q\n", d.Name, d.Addr, d.Port, d.UUID)
+			count++
 		}
-		wg.Wait()
-		outputInfo("Scanned %d uris in %v\n", count, time.Since(start))
+		outputInfo("Found %d devices in %v\n", count, time.Since(start))
 	},
 }
 
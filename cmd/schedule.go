@@ -0,0 +1,219 @@
+// Copyright © 2018 Jonathan Pentecost <pentecostjonathan@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
+	"github.com/vishen/go-chromecast/discovery"
+	"github.com/vishen/go-chromecast/schedule"
+	"github.com/vishen/go-chromecast/storage"
+)
+
+// scheduleCmd represents the schedule command
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule [<schedule_file>]",
+	Short: "Run actions on cast devices at scheduled times",
+	Long: `Run actions on cast devices at the times given by cron expressions, until
+interrupted. The schedule is a yaml file, by default schedule.yaml in the
+go-chromecast config directory, of jobs that each have a cron expression, the
+devices to run on and an action, one of:
+
+  load    load a url, local file or playlist, optionally at a volume that it
+          fades in to over fade_in, ie: for an alarm
+  stop    stop casting
+  volume  set the volume, optionally fading to it over fade_in
+  tts     announce text with text-to-speech, which needs a google service
+          account
+
+  google_service_account: tts.json
+  jobs:
+    - name: alarm
+      cron: "30 6 * * 1-5"
+      devices: [Bedroom speaker]
+      action: load
+      url: https://somafm.com/indiepop64.pls
+      volume: 0.4
+      fade_in: 10m
+    - name: dinner
+      cron: "0 18 * * *"
+      devices: [tag:speakers]
+      action: tts
+      text: Dinner is ready
+
+Cron expressions have five fields, minute hour day-of-month month day-of-week,
+with an optional seconds field first, or are a descriptor like @daily or
+@every 1h30m. A timezone can be given with a prefix, ie: "CRON_TZ=Europe/London
+30 6 * * *". Devices are selectors like those taken by '-d', and jobs without
+devices run on the devices picked by the command flags.
+
+Connections to devices are kept open between jobs. --dry-run prints when the
+jobs will next run, without connecting to any device.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		filename, err := scheduleFile(args)
+		if err != nil {
+			exit("unable to find schedule: %v", err)
+		}
+		cfg, err := schedule.Load(filename)
+		if err != nil {
+			exit("unable to load schedule: %v", err)
+		}
+
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			count, _ := cmd.Flags().GetInt("count")
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "TIME\tJOB\tDEVICES\tACTION")
+			for _, f := range cfg.Upcoming(time.Now(), count) {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.Time.Format("Mon 2006-01-02 15:04:05 MST"), f.Job.Name, jobDevices(f.Job), f.Job)
+			}
+			w.Flush()
+			return
+		}
+
+		outputInfo("Running %d jobs from %s", len(cfg.Jobs), filename)
+		s := &scheduler{cmd: cmd, apps: map[string]application.App{}}
+		defer s.close()
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if err := cfg.Run(ctx, s.fire); err != nil && !errors.Is(err, context.Canceled) {
+			exit("%v", err)
+		}
+	},
+}
+
+// scheduleFile returns the schedule given as an argument, or the default
+// one in the config directory.
+func scheduleFile(args []string) (string, error) {
+	if len(args) == 1 {
+		return args[0], nil
+	}
+	dir, err := storage.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "schedule.yaml"), nil
+}
+
+func jobDevices(j schedule.Job) string {
+	if len(j.Devices) == 0 {
+		return "(flags)"
+	}
+	return strings.Join(j.Devices, ", ")
+}
+
+// scheduler runs jobs on devices, keeping the devices connected between
+// jobs.
+type scheduler struct {
+	cmd *cobra.Command
+
+	mu   sync.Mutex
+	apps map[string]application.App
+}
+
+// fire runs a job on each of its devices.
+func (s *scheduler) fire(ctx context.Context, j schedule.Job) {
+	filter := deviceFilterFromFlags(s.cmd)
+	if len(j.Devices) > 0 {
+		filter = deviceFilter{}
+		for _, device := range j.Devices {
+			filter.selectors = append(filter.selectors, discovery.ParseSelector(device))
+		}
+	}
+
+	s.mu.Lock()
+	devices, err := findDevices(s.cmd, filter)
+	s.mu.Unlock()
+	if err != nil {
+		outputError("%s: unable to find devices: %v", j.Name, err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, d := range devices {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			app, err := s.app(d)
+			if err != nil {
+				outputError("%s: unable to connect to %s: %v", j.Name, deviceName(d), err)
+				return
+			}
+			if err := j.Do(ctx, app); err != nil {
+				outputError("%s: unable to %s on %s: %v", j.Name, j, deviceName(d), err)
+				return
+			}
+			outputInfo("%s: %s on %s", j.Name, j, deviceName(d))
+		}()
+	}
+	wg.Wait()
+}
+
+// app returns the connected application for the device, connecting again
+// if the connection has been lost.
+func (s *scheduler) app(d discovery.Device) (application.App, error) {
+	key := d.UUID
+	if key == "" {
+		key = fmt.Sprintf("%s:%d", d.Addr, d.Port)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if app, ok := s.apps[key]; ok {
+		if err := app.Update(); err == nil {
+			return app, nil
+		}
+		app.Close(false)
+		delete(s.apps, key)
+	}
+	app, err := connectApplication(s.cmd, d)
+	if err != nil {
+		return nil, err
+	}
+	s.apps[key] = app
+	return app, nil
+}
+
+func (s *scheduler) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, app := range s.apps {
+		app.Close(false)
+	}
+}
+
+func deviceName(d discovery.Device) string {
+	if d.Name != "" {
+		return d.Name
+	}
+	return d.GetAddr()
+}
+
+func init() {
+	rootCmd.AddCommand(scheduleCmd)
+	scheduleCmd.Flags().Bool("dry-run", false, "print when the jobs will next run, without running them")
+	scheduleCmd.Flags().Int("count", 10, "with --dry-run, how many of the next runs to print")
+}
@@ -15,9 +15,11 @@
 package cmd
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
 )
 
 // seekCmd represents the seek command
@@ -32,13 +34,12 @@ var seekCmd = &cobra.Command{
 		if err != nil {
 			exit("unable to parse %q to an integer", args[0])
 		}
-		app, err := castApplication(cmd, args)
-		if err != nil {
-			exit("unable to get cast application: %v", err)
-		}
-		if err := app.Seek(value); err != nil {
-			exit("unable to seek current media: %v", err)
-		}
+		runOnDevices(cmd, args, func(app application.App) (string, error) {
+			if err := app.Seek(value); err != nil {
+				return "", fmt.Errorf("unable to seek current media: %w", err)
+			}
+			return "", nil
+		})
 	},
 }
 
@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
 )
 
 // skipadCmd represents the unpause command
@@ -9,15 +12,12 @@ var skipadCmd = &cobra.Command{
 	Use:   "skipad",
 	Short: "Skip the currently playing ad on the chromecast",
 	Run: func(cmd *cobra.Command, args []string) {
-		app, err := castApplication(cmd, args)
-		if err != nil {
-			exit("unable to get cast application: %v\n", err)
-			return
-		}
-		if err := app.Skipad(); err != nil {
-			exit("unable to skip current ad: %v\n", err)
-		}
-
+		runOnDevices(cmd, args, func(app application.App) (string, error) {
+			if err := app.Skipad(); err != nil {
+				return "", fmt.Errorf("unable to skip current ad: %w", err)
+			}
+			return "", nil
+		})
 	},
 }
 
@@ -0,0 +1,81 @@
+// Copyright © 2018 Jonathan Pentecost <pentecostjonathan@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
+)
+
+// sleepCmd represents the sleep command
+var sleepCmd = &cobra.Command{
+	Use:   "sleep <duration>",
+	Short: "Stop the playing media after a time, fading the volume out",
+	Long: `Stop the media playing on the chromecast after a time, ie: 30m or 1h15m. The
+volume is lowered gradually over the last --fade of it, and put back to where
+it was once the media has stopped, so whatever plays next isn't silent.
+
+Changing the volume, muting, pausing or stopping the media while the volume
+fades out cancels the timer. go-chromecast needs to keep running until the
+media is stopped, and interrupting it cancels the timer and puts the volume
+back.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		after, err := time.ParseDuration(args[0])
+		if err != nil {
+			exit("invalid duration %q, ie: 30m or 1h15m", args[0])
+		}
+		fade, _ := cmd.Flags().GetDuration("fade")
+
+		app, err := castApplication(cmd, args)
+		if err != nil {
+			exit("unable to get cast application: %v", err)
+		}
+		timer, err := application.StartSleepTimer(app, after, fade)
+		if err != nil {
+			exit("unable to start sleep timer: %v", err)
+		}
+		outputInfo("Stopping media at %s, fading out over %s", timer.Ends().Format(time.Kitchen), timer.Fade())
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		select {
+		case <-timer.Done():
+		case <-ctx.Done():
+			timer.Cancel()
+		}
+		switch err := timer.Err(); {
+		case err == nil:
+			outputInfo("Stopped media")
+		case errors.Is(err, context.Canceled):
+			outputInfo("Sleep timer cancelled")
+		case errors.Is(err, application.ErrSleepInterrupted):
+			outputInfo("Sleep timer cancelled, the device was used")
+		default:
+			exit("sleep timer failed: %v", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sleepCmd)
+	sleepCmd.Flags().Duration("fade", 2*time.Minute, "how long before the end to start fading the volume out")
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
 )
 
 // statusCmd represents the status command
@@ -25,59 +26,63 @@ var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Current chromecast status",
 	Run: func(cmd *cobra.Command, args []string) {
-		app, err := castApplication(cmd, args)
-		if err != nil {
-			exit("unable to get cast application: %v", err)
-		}
-		castApplication, castMedia, castVolume := app.Status()
-		volumeLevel := castVolume.Level
-		volumeMuted := castVolume.Muted
-
 		contentId, _ := cmd.Flags().GetBool("content-id")
 
-		scriptMode := contentId
+		runOnDevices(cmd, args, func(app application.App) (string, error) {
+			return statusLine(app, contentId), nil
+		})
+	},
+}
+
+// statusLine describes what the cast application is currently doing.
+func statusLine(app application.App, contentId bool) string {
+	castApplication, castMedia, castVolume := app.Status()
+	volumeLevel := castVolume.Level
+	volumeMuted := castVolume.Muted
 
-		if scriptMode {
-			if contentId {
-				if castMedia != nil {
-					outputInfo(castMedia.Media.ContentId)
-				} else {
-					outputInfo("not available")
-				}
-			}
-		} else if castApplication == nil {
-			outputInfo("Idle, volume=%0.2f muted=%t", volumeLevel, volumeMuted)
-		} else {
-			displayName := castApplication.DisplayName
-			if castApplication.IsIdleScreen {
-				outputInfo("Idle (%s), volume=%0.2f muted=%t", displayName, volumeLevel, volumeMuted)
-			} else if castMedia == nil {
-				outputInfo("Idle (%s), volume=%0.2f muted=%t", displayName, volumeLevel, volumeMuted)
-			} else {
-				var metadata string
-				var usefulID string
-				switch castMedia.Media.ContentType {
-				case "x-youtube/video":
-					usefulID = fmt.Sprintf("[%s] ", castMedia.Media.ContentId)
-				}
-				if castMedia.Media.Metadata.Title != "" {
-					md := castMedia.Media.Metadata
-					metadata = fmt.Sprintf("title=%q, artist=%q", md.Title, md.Artist)
-				}
-				if castMedia.Media.ContentId != "" {
-					if metadata != "" {
-						metadata += ", "
-					}
-					metadata += fmt.Sprintf("[%s]", castMedia.Media.ContentId)
-				}
-				if metadata == "" {
-					metadata = "unknown"
+	scriptMode := contentId
 
-				}
-				outputInfo("%s%s (%s), %s, time remaining=%.0fs/%.0fs, volume=%0.2f, muted=%t", usefulID, displayName, castMedia.PlayerState, metadata, castMedia.CurrentTime, castMedia.Media.Duration, volumeLevel, volumeMuted)
-			}
+	if scriptMode {
+		if castMedia != nil {
+			return castMedia.Media.ContentId
 		}
-	},
+		return "not available"
+	} else if castApplication == nil {
+		return fmt.Sprintf("Idle, volume=%0.2f muted=%t", volumeLevel, volumeMuted)
+	}
+
+	displayName := castApplication.DisplayName
+	if castApplication.IsIdleScreen {
+		return fmt.Sprintf("Idle (%s), volume=%0.2f muted=%t", displayName, volumeLevel, volumeMuted)
+	} else if castMedia == nil {
+		return fmt.Sprintf("Idle (%s), volume=%0.2f muted=%t", displayName, volumeLevel, volumeMuted)
+	}
+
+	var metadata string
+	var usefulID string
+	switch castMedia.Media.ContentType {
+	case "x-youtube/video":
+		usefulID = fmt.Sprintf("[%s] ", castMedia.Media.ContentId)
+	}
+	if castMedia.Media.Metadata.Title != "" {
+		md := castMedia.Media.Metadata
+		metadata = fmt.Sprintf("title=%q, artist=%q", md.Title, md.Artist)
+	}
+	if castMedia.Media.ContentId != "" {
+		if metadata != "" {
+			metadata += ", "
+		}
+		metadata += fmt.Sprintf("[%s]", castMedia.Media.ContentId)
+	}
+	if metadata == "" {
+		metadata = "unknown"
+
+	}
+	status := fmt.Sprintf("%s%s (%s), %s, time remaining=%.0fs/%.0fs, volume=%0.2f, muted=%t", usefulID, displayName, castMedia.PlayerState, metadata, castMedia.CurrentTime, castMedia.Media.Duration, volumeLevel, volumeMuted)
+	if rate := application.MediaPlaybackRate(castMedia); rate != 1 {
+		status += fmt.Sprintf(", rate=%gx", rate)
+	}
+	return status
 }
 
 func init() {
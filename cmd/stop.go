@@ -15,7 +15,10 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
 )
 
 // stopCmd represents the stop command
@@ -23,13 +26,12 @@ var stopCmd = &cobra.Command{
 	Use:   "stop",
 	Short: "Stop casting",
 	Run: func(cmd *cobra.Command, args []string) {
-		app, err := castApplication(cmd, args)
-		if err != nil {
-			exit("unable to get cast application: %v", err)
-		}
-		if err := app.Stop(); err != nil {
-			exit("unable to stop casting: %v", err)
-		}
+		runOnDevices(cmd, args, func(app application.App) (string, error) {
+			if err := app.Stop(); err != nil {
+				return "", fmt.Errorf("unable to stop casting: %w", err)
+			}
+			return "", nil
+		})
 	},
 }
 
@@ -0,0 +1,106 @@
+// Copyright © 2018 Jonathan Pentecost <pentecostjonathan@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
+)
+
+// syncPlayCmd represents the sync-play command
+var syncPlayCmd = &cobra.Command{
+	Use:   "sync-play <filename_or_url>",
+	Short: "Play the same media on several devices in sync",
+	Long: `Play the same media on several devices at the same time, for
+devices that can't be put in a cast group together, ie: a Chromecast
+and a Nest Audio. Select the devices with a tag, repeated '-d' flags or
+'--all'.
+
+The media is loaded paused on every device, then started on each of them
+together, allowing for how long each device takes to respond. While it
+plays the devices are periodically compared and any device that drifts
+is brought back in sync.
+
+A local file is served once and every device streams it from the same
+url.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			exit("requires exactly one argument, should be the media file to load")
+		}
+		contentType, _ := cmd.Flags().GetString("content-type")
+		transcode, _ := cmd.Flags().GetBool("transcode")
+		startTime, _ := cmd.Flags().GetInt("start-time")
+		threshold, _ := cmd.Flags().GetDuration("threshold")
+		seekThreshold, _ := cmd.Flags().GetDuration("seek-threshold")
+		interval, _ := cmd.Flags().GetDuration("interval")
+
+		filter := deviceFilterFromFlags(cmd)
+		if !filter.multiple() {
+			exit("sync-play needs more than one device, select them with a tag, repeated '-d' flags or '--all'")
+		}
+		devices, err := findDevices(cmd, filter)
+		if err != nil {
+			exit("unable to find cast devices: %v", err)
+		}
+		apps, err := connectDevices(cmd, devices)
+		if err != nil {
+			exit("%v", err)
+		}
+		defer func() {
+			for _, app := range apps {
+				app.Close(false)
+			}
+		}()
+
+		group := application.NewSyncGroup(apps,
+			application.WithSyncThreshold(threshold),
+			application.WithSyncSeekThreshold(seekThreshold),
+			application.WithSyncInterval(interval),
+		)
+		if err := group.Load(args[0], startTime, contentType, transcode); err != nil {
+			exit("unable to load media: %v", err)
+		}
+		if err := group.MeasureLatency(); err != nil {
+			exit("unable to measure device latency: %v", err)
+		}
+		for i, latency := range group.Latency() {
+			outputInfo("%s: latency %v", devices[i].Name, latency)
+		}
+		if err := group.Play(); err != nil {
+			exit("unable to play media: %v", err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if err := group.Run(ctx); err != nil {
+			exit("unable to keep devices in sync: %v", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(syncPlayCmd)
+	syncPlayCmd.Flags().Bool("transcode", true, "transcode the media to mp4 if media type is unrecognised")
+	syncPlayCmd.Flags().StringP("content-type", "c", "", "content-type to serve the media file as")
+	syncPlayCmd.Flags().Int("start-time", 0, "start time to play media, in seconds")
+	syncPlayCmd.Flags().Duration("threshold", 250*time.Millisecond, "how far a device can drift before it is corrected")
+	syncPlayCmd.Flags().Duration("seek-threshold", 3*time.Second, "how far apart the devices can drift before they are all seeked back together")
+	syncPlayCmd.Flags().Duration("interval", 5*time.Second, "how often to check the devices are in sync")
+}
@@ -15,7 +15,10 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
 )
 
 // togglepauseCmd represents the togglepause command
@@ -24,13 +27,12 @@ var togglepauseCmd = &cobra.Command{
 	Aliases: []string{"tpause", "playpause"},
 	Short:   "Toggle paused/unpaused state. Aliases: tpause, playpause",
 	Run: func(cmd *cobra.Command, args []string) {
-		app, err := castApplication(cmd, args)
-		if err != nil {
-			exit("unable to get cast application: %v", err)
-		}
-		if err := app.TogglePause(); err != nil {
-			exit("unable to (un)pause cast application: %v", err)
-		}
+		runOnDevices(cmd, args, func(app application.App) (string, error) {
+			if err := app.TogglePause(); err != nil {
+				return "", fmt.Errorf("unable to (un)pause cast application: %w", err)
+			}
+			return "", nil
+		})
 	},
 }
 
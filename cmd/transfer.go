@@ -0,0 +1,93 @@
+// Copyright © 2018 Jonathan Pentecost <pentecostjonathan@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
+	"github.com/vishen/go-chromecast/discovery"
+)
+
+// transferCmd represents the transfer command
+var transferCmd = &cobra.Command{
+	Use:   "transfer --from <device> --to <device>",
+	Short: "Move the currently playing media to another device",
+	Long: `Move the currently playing media from one device to another. The
+media is loaded on the new device at the same position, with the same
+metadata and tracks, and then stopped on the old device.
+
+--from and --to take the same device selectors as '-d'.
+
+If the media is a local file served by go-chromecast, this will serve
+it again and wait until it has finished playing.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+		withVolume, _ := cmd.Flags().GetBool("volume")
+		if from == "" || to == "" {
+			exit("both --from and --to are required")
+		}
+
+		var devices []discovery.Device
+		for _, selector := range []string{from, to} {
+			found, err := findDevices(cmd, deviceFilter{selectors: []discovery.Selector{discovery.ParseSelector(selector)}})
+			if err != nil {
+				exit("unable to find cast device %q: %v", selector, err)
+			}
+			if len(found) > 1 {
+				exit("%q selected %d devices, but only one can be used", selector, len(found))
+			}
+			devices = append(devices, found[0])
+		}
+		if devices[0].SameAs(devices[1]) {
+			exit("--from and --to are the same device, %s", deviceName(devices[0]))
+		}
+		apps, err := connectDevices(cmd, devices)
+		if err != nil {
+			exit("%v", err)
+		}
+		defer apps[0].Close(false)
+		defer apps[1].Close(false)
+
+		served, err := application.Transfer(apps[0], apps[1], withVolume)
+		if err != nil {
+			exit("unable to transfer media: %v", err)
+		}
+		outputInfo("transferred media from %s to %s", devices[0].Name, devices[1].Name)
+		if served {
+			waitForMedia(apps[1])
+		}
+	},
+}
+
+// waitForMedia blocks until the device has finished playing its media.
+func waitForMedia(app application.App) {
+	for {
+		time.Sleep(5 * time.Second)
+		media, err := app.MediaStatus()
+		if err != nil || media == nil || media.PlayerState == "IDLE" {
+			return
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(transferCmd)
+	transferCmd.Flags().String("from", "", "device to move the media from")
+	transferCmd.Flags().String("to", "", "device to move the media to")
+	transferCmd.Flags().Bool("volume", false, "set the volume of the new device to that of the old device")
+}
@@ -15,7 +15,10 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
 )
 
 // unmuteCmd represents the unmute command
@@ -23,13 +26,12 @@ var unmuteCmd = &cobra.Command{
 	Use:   "unmute",
 	Short: "Unmute the chromecast",
 	Run: func(cmd *cobra.Command, args []string) {
-		app, err := castApplication(cmd, args)
-		if err != nil {
-			exit("unable to get cast application: %v", err)
-		}
-		if err := app.SetMuted(false); err != nil {
-			exit("unable to unmute cast application: %v", err)
-		}
+		runOnDevices(cmd, args, func(app application.App) (string, error) {
+			if err := app.SetMuted(false); err != nil {
+				return "", fmt.Errorf("unable to unmute cast application: %w", err)
+			}
+			return "", nil
+		})
 	},
 }
 
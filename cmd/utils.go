@@ -3,153 +3,402 @@ package cmd
 import (
 	"bufio"
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"os"
+	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"text/tabwriter"
 	"time"
 
 	"github.com/pkg/errors"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/vishen/go-chromecast/application"
+	"github.com/vishen/go-chromecast/discovery"
 	castdns "github.com/vishen/go-chromecast/dns"
 	"github.com/vishen/go-chromecast/storage"
 )
 
 var (
-	cache = storage.NewStorage()
+	// The store is opened once from the command flags and shared by
+	// everything in the process, see openStore.
+	store     storage.Store
+	storeErr  error
+	storeOnce sync.Once
 
 	// Set up a global dns entry so we can attempt reconnects
 	entry castdns.CastDNSEntry
 )
 
-type CachedDNSEntry struct {
-	UUID string `json:"uuid"`
-	Name string `json:"name"`
-	Addr string `json:"addr"`
-	Port int    `json:"port"`
+// deviceFilter is how the command flags select devices.
+type deviceFilter struct {
+	uuid      string
+	name      string
+	selectors []discovery.Selector
+	all       bool
 }
 
-func (e CachedDNSEntry) GetUUID() string {
-	return e.UUID
+func deviceFilterFromFlags(cmd *cobra.Command) deviceFilter {
+	f := deviceFilter{}
+	f.uuid, _ = cmd.Flags().GetString("uuid")
+	f.name, _ = cmd.Flags().GetString("device-name")
+	f.all, _ = cmd.Flags().GetBool("all")
+	devices, _ := cmd.Flags().GetStringArray("device")
+	for _, device := range devices {
+		if device != "" {
+			f.selectors = append(f.selectors, discovery.ParseSelector(device))
+		}
+	}
+	return f
+}
+
+func (f deviceFilter) empty() bool {
+	return f.uuid == "" && f.name == "" && len(f.selectors) == 0 && !f.all
 }
 
-func (e CachedDNSEntry) GetName() string {
-	return e.Name
+// multiple returns whether the filter can select more than one device.
+func (f deviceFilter) multiple() bool {
+	if f.all || len(f.selectors) > 1 {
+		return true
+	}
+	for _, selector := range f.selectors {
+		if selector.Multiple() {
+			return true
+		}
+	}
+	return false
+}
+
+func (f deviceFilter) match(inventory *discovery.Inventory, d discovery.Device) bool {
+	if f.all || (f.uuid != "" && d.UUID == f.uuid) || (f.name != "" && d.Name == f.name) {
+		return true
+	}
+	for _, selector := range f.selectors {
+		if inventory.Match(selector, d) {
+			return true
+		}
+	}
+	return false
 }
 
-func (e CachedDNSEntry) GetAddr() string {
-	return e.Addr
+// unmatched returns the selectors that didn't pick any of the devices.
+func (f deviceFilter) unmatched(inventory *discovery.Inventory, devices []discovery.Device) []discovery.Selector {
+	var unmatched []discovery.Selector
+	for _, selector := range f.selectors {
+		if !slices.ContainsFunc(devices, func(d discovery.Device) bool { return inventory.Match(selector, d) }) {
+			unmatched = append(unmatched, selector)
+		}
+	}
+	return unmatched
 }
 
-func (e CachedDNSEntry) GetPort() int {
-	return e.Port
+func (f deviceFilter) String() string {
+	if f.all {
+		return "all devices"
+	}
+	var parts []string
+	for _, selector := range f.selectors {
+		parts = append(parts, selector.String())
+	}
+	return strings.Join(parts, ", ")
 }
 
 func castApplication(cmd *cobra.Command, args []string) (application.App, error) {
-	deviceName, _ := cmd.Flags().GetString("device-name")
-	deviceUuid, _ := cmd.Flags().GetString("uuid")
-	device, _ := cmd.Flags().GetString("device")
-	debug, _ := cmd.Flags().GetBool("debug")
-	disableCache, _ := cmd.Flags().GetBool("disable-cache")
-	addr, _ := cmd.Flags().GetString("addr")
-	port, _ := cmd.Flags().GetString("port")
-	ifaceName, _ := cmd.Flags().GetString("iface")
-	serverPort, _ := cmd.Flags().GetInt("server-port")
-	dnsTimeoutSeconds, _ := cmd.Flags().GetInt("dns-timeout")
-	useFirstDevice, _ := cmd.Flags().GetBool("first")
+	filter := deviceFilterFromFlags(cmd)
 
 	// Used to try and reconnect
-	if deviceUuid == "" && entry != nil {
-		deviceUuid = entry.GetUUID()
+	if filter.uuid == "" && entry != nil && entry.GetUUID() != "" {
+		filter = deviceFilter{uuid: entry.GetUUID()}
 		entry = nil
 	}
 
-	if debug {
-		log.SetLevel(log.DebugLevel)
+	devices, err := findDevices(cmd, filter)
+	if err != nil {
+		return nil, err
 	}
-
-	applicationOptions := []application.ApplicationOption{
-		application.WithServerPort(serverPort),
-		application.WithDebug(debug),
-		application.WithCacheDisabled(disableCache),
+	if len(devices) > 1 {
+		return nil, fmt.Errorf("%d devices were selected, but this command can only be run against one device", len(devices))
 	}
+	entry = devices[0]
+	return connectApplication(cmd, devices[0])
+}
 
-	// If we need to look on a specific network interface for mdns or
-	// for finding a network ip to host from, ensure that the network
-	// interface exists.
-	var iface *net.Interface
-	if ifaceName != "" {
-		var err error
-		if iface, err = net.InterfaceByName(ifaceName); err != nil {
-			return nil, errors.Wrap(err, fmt.Sprintf("unable to find interface %q", ifaceName))
+// findDevices returns the devices selected by filter, or the device given
+// by the 'addr' flag.
+func findDevices(cmd *cobra.Command, filter deviceFilter) ([]discovery.Device, error) {
+	debug, _ := cmd.Flags().GetBool("debug")
+	disableCache, _ := cmd.Flags().GetBool("disable-cache")
+	addr, _ := cmd.Flags().GetString("addr")
+	port, _ := cmd.Flags().GetString("port")
+	useFirstDevice, _ := cmd.Flags().GetBool("first")
+
+	if addr != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			return nil, errors.Wrap(err, "port needs to be a number")
 		}
-		applicationOptions = append(applicationOptions, application.WithIface(iface))
+		return []discovery.Device{{Addr: addr, Port: p}}, nil
 	}
 
 	// If no address was specified, attempt to determine the address of any
 	// local chromecast devices.
-	if addr == "" {
-		// If a device name or uuid was specified, check the cache for the ip+port
-		found := false
-		if !disableCache && (deviceName != "" || deviceUuid != "") {
-			entry = findCachedCastDNS(deviceName, deviceUuid)
-			found = entry.GetAddr() != ""
+	iface, err := interfaceFromFlags(cmd)
+	if err != nil {
+		return nil, err
+	}
+	inventory, err := loadInventory(cmd)
+	if err != nil {
+		return nil, err
+	}
+	// The cache is only useful when looking for specific devices, otherwise
+	// we could end up with any previously used device.
+	var exclude []string
+	if filter.empty() || filter.all {
+		exclude = append(exclude, "cache")
+	}
+	sources, err := discoverySources(cmd, iface, exclude...)
+	if err != nil {
+		return nil, err
+	}
+	src := discovery.Multi(sources...)
+
+	var devices []discovery.Device
+	switch {
+	case filter.empty():
+		found, err := findCastDNS(src, nil, useFirstDevice)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to find cast dns entry")
 		}
-		if !found {
-			var err error
-			if entry, err = findCastDNS(iface, dnsTimeoutSeconds, device, deviceName, deviceUuid, useFirstDevice); err != nil {
-				return nil, errors.Wrap(err, "unable to find cast dns entry")
+		devices = append(devices, found)
+	case filter.multiple():
+		// Every source needs to be exhausted to find all the devices.
+		all, err := discovery.All(context.Background(), src)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to find cast dns entries")
+		}
+		for _, d := range all {
+			if filter.match(inventory, d) {
+				devices = append(devices, d)
 			}
 		}
+		if len(devices) == 0 {
+			return nil, errors.Wrapf(discovery.ErrNotFound, "unable to find cast dns entries for %q", filter)
+		}
+		sort.Slice(devices, func(i, j int) bool { return devices[i].Name < devices[j].Name })
+	default:
+		found, err := findCastDNS(src, func(d discovery.Device) bool { return filter.match(inventory, d) }, useFirstDevice)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to find cast dns entry")
+		}
+		devices = append(devices, found)
+	}
+
+	for _, d := range devices {
 		if !disableCache {
-			cachedEntry := CachedDNSEntry{
-				UUID: entry.GetUUID(),
-				Name: entry.GetName(),
-				Addr: entry.GetAddr(),
-				Port: entry.GetPort(),
-			}
-			cachedEntryJson, _ := json.Marshal(cachedEntry)
-			if err := cache.Save(getCacheKey(cachedEntry.UUID), cachedEntryJson); err != nil {
-				outputError("Failed to save UUID cache entry\n")
-			}
-			if err := cache.Save(getCacheKey(cachedEntry.Name), cachedEntryJson); err != nil {
-				outputError("Failed to save name cache entry\n")
+			if err := deviceCache(cmd).Save(d); err != nil {
+				outputError("Failed to save cache entry: %v\n", err)
 			}
 		}
 		if debug {
-			outputInfo("using device name=%s addr=%s port=%d uuid=%s", entry.GetName(), entry.GetAddr(), entry.GetPort(), entry.GetUUID())
+			outputInfo("using device name=%s addr=%s port=%d uuid=%s source=%s", d.Name, d.Addr, d.Port, d.UUID, d.Source)
 		}
-	} else {
-		p, err := strconv.Atoi(port)
+	}
+	return devices, nil
+}
+
+// connectApplication starts a cast application connected to the device.
+func connectApplication(cmd *cobra.Command, device discovery.Device) (application.App, error) {
+	debug, _ := cmd.Flags().GetBool("debug")
+	disableCache, _ := cmd.Flags().GetBool("disable-cache")
+	serverPort, _ := cmd.Flags().GetInt("server-port")
+
+	volumeConfig, err := loadVolumeConfig(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	applicationOptions := []application.ApplicationOption{
+		application.WithServerPort(serverPort),
+		application.WithDebug(debug),
+		application.WithCacheDisabled(disableCache),
+		application.WithDevice(device.UUID, device.Name),
+		application.WithVolumeSettings(volumeConfig.For(device.UUID, device.Name)),
+	}
+	if !disableCache {
+		store, err := openStore(cmd)
 		if err != nil {
-			return nil, errors.Wrap(err, "port needs to be a number")
-		}
-		entry = CachedDNSEntry{
-			Addr: addr,
-			Port: p,
+			return nil, err
 		}
+		applicationOptions = append(applicationOptions, application.WithStore(store))
 	}
+
+	// If we need to look on a specific network interface for finding a
+	// network ip to host from, ensure that the network interface exists.
+	iface, err := interfaceFromFlags(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if iface != nil {
+		applicationOptions = append(applicationOptions, application.WithIface(iface))
+	}
+
 	app := application.NewApplication(applicationOptions...)
-	if err := app.Start(entry.GetAddr(), entry.GetPort()); err != nil {
+	if err := app.Start(device.GetAddr(), device.GetPort()); err != nil {
 		// NOTE: currently we delete the dns cache every time we get
 		// an error, this is to make sure that if the device gets a new
 		// ipaddress we will invalidate the cache.
-		if err := cache.Save(getCacheKey(entry.GetUUID()), []byte{}); err != nil {
-			fmt.Printf("Failed to save UUID cache entry: %v\n", err)
-		}
-		if err := cache.Save(getCacheKey(entry.GetName()), []byte{}); err != nil {
-			fmt.Printf("Failed to save name cache entry: %v\n", err)
+		if err := deviceCache(cmd).Invalidate(device); err != nil {
+			fmt.Printf("Failed to invalidate cache entry: %v\n", err)
 		}
 		return nil, err
 	}
 	return app, nil
 }
 
+// connectDevices connects to every device concurrently. If any of them
+// fail the rest are closed again.
+func connectDevices(cmd *cobra.Command, devices []discovery.Device) ([]application.App, error) {
+	apps := make([]application.App, len(devices))
+	errs := make([]error, len(devices))
+	var wg sync.WaitGroup
+	for i, d := range devices {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			apps[i], errs[i] = connectApplication(cmd, d)
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		for _, app := range apps {
+			if app != nil {
+				app.Close(false)
+			}
+		}
+		return nil, errors.Wrapf(err, "unable to connect to %q", devices[i].Name)
+	}
+	return apps, nil
+}
+
+// deviceAction is run against a connected device, it can return some
+// output to show the user.
+type deviceAction func(app application.App) (string, error)
+
+// Exit codes used when running against more than one device.
+const (
+	exitAllFailed     = 1
+	exitPartialFailed = 2
+)
+
+// runOnDevices runs the action against every device selected by the
+// command flags. When more than one device is selected, each is connected
+// to and run concurrently, and a table with the result for each device is
+// shown. The exit code is exitAllFailed if every device failed, and
+// exitPartialFailed if only some did.
+func runOnDevices(cmd *cobra.Command, args []string, action deviceAction) {
+	filter := deviceFilterFromFlags(cmd)
+	if !filter.multiple() {
+		app, err := castApplication(cmd, args)
+		if err != nil {
+			exit("unable to get cast application: %v", err)
+		}
+		output, err := action(app)
+		if err != nil {
+			exit("%v", err)
+		}
+		if output != "" {
+			outputInfo("%s", output)
+		}
+		return
+	}
+
+	devices, err := findDevices(cmd, filter)
+	if err != nil {
+		exit("unable to find cast devices: %v", err)
+	}
+
+	results := make([]deviceResult, len(devices))
+	var wg sync.WaitGroup
+	for i, d := range devices {
+		results[i].device = d
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			app, err := connectApplication(cmd, d)
+			if err != nil {
+				results[i].err = fmt.Errorf("unable to get cast application: %w", err)
+				return
+			}
+			defer app.Close(false)
+			results[i].output, results[i].err = action(app)
+		}()
+	}
+	wg.Wait()
+
+	// Selectors that didn't find anything are failures as well, otherwise
+	// a typo in one of several '-d' flags would go unnoticed.
+	inventory, _ := loadInventory(cmd)
+	for _, selector := range filter.unmatched(inventory, devices) {
+		results = append(results, deviceResult{
+			device: discovery.Device{Name: selector.String()},
+			err:    discovery.ErrNotFound,
+		})
+	}
+
+	failed := printDeviceResults(os.Stdout, results)
+	switch {
+	case failed == len(results):
+		os.Exit(exitAllFailed)
+	case failed > 0:
+		os.Exit(exitPartialFailed)
+	}
+}
+
+// deviceResult is the outcome of running a deviceAction on a device.
+type deviceResult struct {
+	device discovery.Device
+	output string
+	err    error
+}
+
+// printDeviceResults writes a table of the results and returns how many
+// of them failed.
+func printDeviceResults(w io.Writer, results []deviceResult) int {
+	failed := 0
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DEVICE\tADDRESS\tRESULT")
+	for _, r := range results {
+		name := r.device.Name
+		if name == "" {
+			name = r.device.Addr
+		}
+		addr := "-"
+		if r.device.Addr != "" {
+			addr = fmt.Sprintf("%s:%d", r.device.Addr, r.device.Port)
+		}
+		result := r.output
+		switch {
+		case r.err != nil:
+			result = fmt.Sprintf("%serror%s: %v", RED, NC, r.err)
+			failed++
+		case result == "":
+			result = "ok"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", name, addr, result)
+	}
+	tw.Flush()
+	return failed
+}
+
 // reconnect will attempt to reconnect to the cast device
 // TODO: This is all very hacky, currently a global dns entry is set which
 // contains the device UUID, and this is then used to reconnect. This should
@@ -160,53 +409,175 @@ func reconnect(cmd *cobra.Command, args []string) (application.App, error) {
 	return castApplication(cmd, args)
 }
 
-func getCacheKey(suffix string) string {
-	return fmt.Sprintf("cmd/utils/dns/%s", suffix)
-}
+// discoverySources returns the sources to find cast devices with, in the
+// priority order given by the 'discovery' flag. Any source named in exclude
+// is skipped.
+func discoverySources(cmd *cobra.Command, iface *net.Interface, exclude ...string) ([]discovery.Source, error) {
+	order, _ := cmd.Flags().GetStringSlice("discovery")
+	scanCIDR, _ := cmd.Flags().GetString("scan-cidr")
+	dnsTimeoutSeconds, _ := cmd.Flags().GetInt("dns-timeout")
+	disableCache, _ := cmd.Flags().GetBool("disable-cache")
 
-func findCachedCastDNS(deviceName, deviceUuid string) castdns.CastDNSEntry {
-	for _, s := range []string{deviceName, deviceUuid} {
-		cacheKey := getCacheKey(s)
-		if b, err := cache.Load(cacheKey); err == nil {
-			cachedEntry := CachedDNSEntry{}
-			if err := json.Unmarshal(b, &cachedEntry); err == nil {
-				return cachedEntry
+	var sources []discovery.Source
+	for _, name := range order {
+		if slices.Contains(exclude, name) {
+			continue
+		}
+		switch name {
+		case "inventory":
+			inventory, err := loadInventory(cmd)
+			if err != nil {
+				return nil, err
+			}
+			if inventory == nil {
+				continue
+			}
+			sources = append(sources, discovery.NewStaticSource(inventory.Devices))
+		case "cache":
+			if disableCache {
+				continue
+			}
+			if _, err := openStore(cmd); err != nil {
+				return nil, err
+			}
+			validate, _ := cmd.Flags().GetString("cache-validate")
+			if _, ok := discovery.Validators[validate]; !ok {
+				return nil, fmt.Errorf("unknown cache validation %q, expected one of none, tcp or info", validate)
+			}
+			sources = append(sources, deviceCache(cmd))
+		case "mdns":
+			sources = append(sources, discovery.NewMDNSSource(iface, time.Second*time.Duration(dnsTimeoutSeconds)))
+		case "scan":
+			if scanCIDR == "" {
+				continue
 			}
+			scanSource, err := discovery.NewScanSource(scanCIDR, 0)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, scanSource)
+		default:
+			return nil, fmt.Errorf("unknown discovery source %q, expected one of inventory, cache, mdns or scan", name)
 		}
 	}
-	return CachedDNSEntry{}
+	return sources, nil
 }
 
-func findCastDNS(iface *net.Interface, dnsTimeoutSeconds int, device, deviceName, deviceUuid string, first bool) (castdns.CastDNSEntry, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(dnsTimeoutSeconds))
-	defer cancel()
-	castEntryChan, err := castdns.DiscoverCastDNSEntries(ctx, iface)
+// openStore opens the store given by the 'cache-backend' and 'cache-dir'
+// flags. It is only opened once, so every command shares the same store.
+func openStore(cmd *cobra.Command) (storage.Store, error) {
+	storeOnce.Do(func() {
+		backend, _ := cmd.Flags().GetString("cache-backend")
+		dir, _ := cmd.Flags().GetString("cache-dir")
+		store, storeErr = storage.Open(backend, dir)
+		if storeErr != nil {
+			storeErr = errors.Wrap(storeErr, "unable to open cache")
+		}
+	})
+	return store, storeErr
+}
+
+// deviceCache returns the cache of previously found devices, using the
+// ttl and validation from the 'cache-ttl' and 'cache-validate' flags. If
+// the store can't be opened the cache is kept in memory, so failing to
+// cache a device never stops a command.
+func deviceCache(cmd *cobra.Command) *discovery.Cache {
+	ttl, _ := cmd.Flags().GetDuration("cache-ttl")
+	validate, _ := cmd.Flags().GetString("cache-validate")
+
+	s, err := openStore(cmd)
+	if err != nil {
+		s = storage.NewMemoryStore()
+	}
+	opts := []discovery.CacheOption{discovery.WithCacheTTL(ttl)}
+	if v, ok := discovery.Validators[validate]; ok {
+		opts = append(opts, discovery.WithValidator(v))
+	}
+	return discovery.NewCache(s, opts...)
+}
+
+// loadInventory returns the inventory given by the 'inventory' flag, or
+// nil if there isn't one.
+func loadInventory(cmd *cobra.Command) (*discovery.Inventory, error) {
+	inventoryFile, _ := cmd.Flags().GetString("inventory")
+	if inventoryFile == "" {
+		return nil, nil
+	}
+	inventory, err := discovery.LoadInventory(inventoryFile)
 	if err != nil {
-		return castdns.CastEntry{}, err
+		return nil, errors.Wrap(err, "unable to load inventory")
 	}
+	return inventory, nil
+}
 
-	isDeviceFilter := deviceUuid != "" || deviceName != "" || device != ""
+// loadVolumeConfig loads the volume limits, steps and presets given by the
+// 'volume-config' flag, or the ones in the config directory if there are
+// any.
+func loadVolumeConfig(cmd *cobra.Command) (*application.VolumeConfig, error) {
+	filename, _ := cmd.Flags().GetString("volume-config")
+	if filename != "" {
+		if _, err := os.Stat(filename); err != nil {
+			return nil, errors.Wrap(err, "unable to load volume config")
+		}
+	} else {
+		dir, err := storage.ConfigDir()
+		if err != nil {
+			return nil, nil
+		}
+		filename = filepath.Join(dir, "volume.yaml")
+	}
+	cfg, err := application.LoadVolumeConfig(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load volume config")
+	}
+	return cfg, nil
+}
+
+// interfaceFromFlags returns the network interface given by the 'iface'
+// flag, or nil if there isn't one.
+func interfaceFromFlags(cmd *cobra.Command) (*net.Interface, error) {
+	ifaceName, _ := cmd.Flags().GetString("iface")
+	if ifaceName == "" {
+		return nil, nil
+	}
+	iface, err := net.InterfaceByName(ifaceName)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("unable to find interface %q", ifaceName))
+	}
+	return iface, nil
+}
+
+// findCastDNS returns the first device that match returns true for. If
+// match is nil, the user is asked to select from all the found devices,
+// unless first is set.
+func findCastDNS(src discovery.Source, match func(discovery.Device) bool, first bool) (discovery.Device, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	castEntryChan, err := src.Discover(ctx)
+	if err != nil {
+		return discovery.Device{}, err
+	}
 
-	foundEntries := []castdns.CastEntry{}
+	foundEntries := []discovery.Device{}
 	for entry := range castEntryChan {
-		if first && !isDeviceFilter {
+		if first && match == nil {
 			return entry, nil
-		} else if (deviceUuid != "" && entry.UUID == deviceUuid) || (deviceName != "" && entry.DeviceName == deviceName) || (device != "" && entry.Device == device) {
+		} else if match != nil && match(entry) {
 			return entry, nil
 		}
 		foundEntries = append(foundEntries, entry)
 	}
 
-	if len(foundEntries) == 0 || isDeviceFilter {
-		return castdns.CastEntry{}, fmt.Errorf("no cast devices found on network")
+	if len(foundEntries) == 0 || match != nil {
+		return discovery.Device{}, discovery.ErrNotFound
 	}
 
 	// Always return entries in deterministic order.
-	sort.Slice(foundEntries, func(i, j int) bool { return foundEntries[i].DeviceName < foundEntries[j].DeviceName })
+	sort.Slice(foundEntries, func(i, j int) bool { return foundEntries[i].Name < foundEntries[j].Name })
 
 	outputInfo("Found %d cast dns entries, select one:", len(foundEntries))
 	for i, d := range foundEntries {
-		outputInfo("%d) device=%q device_name=%q address=\"%s:%d\" uuid=%q", i+1, d.Device, d.DeviceName, d.AddrV4, d.Port, d.UUID)
+		outputInfo("%d) device=%q device_name=%q address=\"%s:%d\" uuid=%q", i+1, d.Device, d.Name, d.Addr, d.Port, d.UUID)
 	}
 	reader := bufio.NewReader(os.Stdin)
 	for {
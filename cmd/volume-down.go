@@ -15,9 +15,11 @@
 package cmd
 
 import (
+	"fmt"
 	"math"
 
 	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
 )
 
 // volumeDownCmd represents the volume-down command
@@ -25,33 +27,29 @@ var volumeDownCmd = &cobra.Command{
 	Use:   "volume-down",
 	Short: "Turn down volume",
 	Run: func(cmd *cobra.Command, args []string) {
-		app, err := castApplication(cmd, args)
-		if err != nil {
-			exit("unable to get cast application: %v", err)
-		}
-
-		volumeStep, _ := cmd.Flags().GetFloat32("step")
-
-		if err = app.Update(); err != nil {
-			exit("unable to update cast info: %v", err)
-		}
-		_, _, castVolume := app.Status()
-
-		nextVolume := max(castVolume.Level-volumeStep, math.SmallestNonzeroFloat32)
-		if err = app.SetVolume(float32(nextVolume)); err != nil {
-			exit("failed to set volume: %v", err)
-		}
-
-		if err = app.Update(); err != nil {
-			exit("unable to update cast info: %v", err)
-		}
-		_, _, turnedCastVolume := app.Status()
-
-		outputInfo("%0.2f", turnedCastVolume.Level)
+		runOnDevices(cmd, args, func(app application.App) (string, error) {
+			if err := app.Update(); err != nil {
+				return "", fmt.Errorf("unable to update cast info: %w", err)
+			}
+			_, _, castVolume := app.Status()
+			step := volumeStep(cmd, app)
+
+			nextVolume := max(app.VolumeSettings().Clamp(castVolume.Level-step), math.SmallestNonzeroFloat32)
+			if err := app.SetVolume(nextVolume); err != nil {
+				return "", fmt.Errorf("failed to set volume: %w", err)
+			}
+
+			if err := app.Update(); err != nil {
+				return "", fmt.Errorf("unable to update cast info: %w", err)
+			}
+			_, _, turnedCastVolume := app.Status()
+
+			return fmt.Sprintf("%0.2f", turnedCastVolume.Level), nil
+		})
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(volumeDownCmd)
-	volumeDownCmd.Flags().Float32("step", 0.05, "step value for turning down volume")
+	volumeDownCmd.Flags().Float32("step", application.DefaultVolumeStep, "step value for turning down volume, instead of the step in the volume config")
 }
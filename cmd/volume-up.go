@@ -15,7 +15,10 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
 )
 
 // volumeUpCmd represents the volume-up command
@@ -23,33 +26,29 @@ var volumeUpCmd = &cobra.Command{
 	Use:   "volume-up",
 	Short: "Turn up volume",
 	Run: func(cmd *cobra.Command, args []string) {
-		app, err := castApplication(cmd, args)
-		if err != nil {
-			exit("unable to get cast application: %v", err)
-		}
-
-		volumeStep, _ := cmd.Flags().GetFloat32("step")
-
-		if err = app.Update(); err != nil {
-			exit("unable to update cast info: %v", err)
-		}
-		_, _, castVolume := app.Status()
-
-		nextVolume := min(castVolume.Level+volumeStep, 1)
-		if err = app.SetVolume(float32(nextVolume)); err != nil {
-			exit("failed to set volume: %v", err)
-		}
-
-		if err = app.Update(); err != nil {
-			exit("unable to update cast info: %v", err)
-		}
-		_, _, turnedCastVolume := app.Status()
-
-		outputInfo("%0.2f", turnedCastVolume.Level)
+		runOnDevices(cmd, args, func(app application.App) (string, error) {
+			if err := app.Update(); err != nil {
+				return "", fmt.Errorf("unable to update cast info: %w", err)
+			}
+			_, _, castVolume := app.Status()
+			step := volumeStep(cmd, app)
+
+			nextVolume := app.VolumeSettings().Clamp(castVolume.Level + step)
+			if err := app.SetVolume(nextVolume); err != nil {
+				return "", fmt.Errorf("failed to set volume: %w", err)
+			}
+
+			if err := app.Update(); err != nil {
+				return "", fmt.Errorf("unable to update cast info: %w", err)
+			}
+			_, _, turnedCastVolume := app.Status()
+
+			return fmt.Sprintf("%0.2f", turnedCastVolume.Level), nil
+		})
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(volumeUpCmd)
-	volumeUpCmd.Flags().Float32("step", 0.05, "step value for turning up volume")
+	volumeUpCmd.Flags().Float32("step", application.DefaultVolumeStep, "step value for turning up volume, instead of the step in the volume config")
 }
@@ -15,41 +15,115 @@
 package cmd
 
 import (
-	"strconv"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/vishen/go-chromecast/application"
 )
 
 // volumeCmd represents the volume command
 var volumeCmd = &cobra.Command{
-	Use:   "volume [<0.00 - 1.00>]",
+	Use:   "volume [<0.00 - 1.00> | <preset>]",
 	Short: "Get or set volume",
-	Long:  "Get or set volume (float in range from 0 to 1)",
+	Long: `Get or set volume (float in range from 0 to 1), or set it to a preset.
+
+Presets, the step used by volume-up and volume-down, and limits on the volume
+of devices are read from the --volume-config file, by default volume.yaml in
+the go-chromecast config directory:
+
+  step: 0.05
+  presets:
+    night: 0.15
+    party: 0.8
+  devices:
+    Kitchen speaker:
+      min: 0.05
+      max: 0.6
+      presets:
+        party: 0.6
+
+The volume of a device is always kept within its limits.`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		app, err := castApplication(cmd, args)
-		if err != nil {
-			exit("unable to get cast application: %v", err)
+		setVolume := len(args) == 1 && args[0] != ""
+		runOnDevices(cmd, args, func(app application.App) (string, error) {
+			if setVolume {
+				level, err := app.VolumeSettings().Level(args[0])
+				if err != nil {
+					return "", fmt.Errorf("invalid volume: %w", err)
+				}
+				if err := app.SetVolume(level); err != nil {
+					return "", fmt.Errorf("failed to set volume: %w", err)
+				}
+			}
+
+			if err := app.Update(); err != nil {
+				return "", fmt.Errorf("unable to update cast info: %w", err)
+			}
+			_, _, castVolume := app.Status()
+
+			return fmt.Sprintf("%0.2f", castVolume.Level), nil
+		})
+	},
+}
+
+var volumeRampCmd = &cobra.Command{
+	Use:   "ramp",
+	Short: "Change the volume gradually",
+	Long: `Change the volume gradually from where it is to --to, which is a volume or a
+preset, over --over.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		to, _ := cmd.Flags().GetString("to")
+		over, _ := cmd.Flags().GetDuration("over")
+		if to == "" {
+			exit("--to is needed")
+		}
+		if over < 0 {
+			exit("--over must not be negative")
 		}
 
-		if len(args) == 1 && args[0] != "" {
-			newVolume, err := strconv.ParseFloat(args[0], 32)
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		runOnDevices(cmd, args, func(app application.App) (string, error) {
+			level, err := app.VolumeSettings().Level(to)
 			if err != nil {
-				exit("invalid volume: %v", err)
+				return "", fmt.Errorf("invalid volume: %w", err)
 			}
-			if err = app.SetVolume(float32(newVolume)); err != nil {
-				exit("failed to set volume: %v", err)
+			if err := app.Update(); err != nil {
+				return "", fmt.Errorf("unable to update cast info: %w", err)
+			}
+			_, _, castVolume := app.Status()
+			if err := application.FadeVolume(ctx, app, castVolume.Level, level, over); err != nil {
+				return "", fmt.Errorf("failed to ramp volume: %w", err)
 			}
-		}
-
-		if err = app.Update(); err != nil {
-			exit("unable to update cast info: %v", err)
-		}
-		_, _, castVolume := app.Status()
 
-		outputInfo("%0.2f", castVolume.Level)
+			if err := app.Update(); err != nil {
+				return "", fmt.Errorf("unable to update cast info: %w", err)
+			}
+			_, _, castVolume = app.Status()
+			return fmt.Sprintf("%0.2f", castVolume.Level), nil
+		})
 	},
 }
 
+// volumeStep returns the step given by the 'step' flag, or the step of
+// the device.
+func volumeStep(cmd *cobra.Command, app application.App) float32 {
+	if cmd.Flags().Changed("step") {
+		step, _ := cmd.Flags().GetFloat32("step")
+		return step
+	}
+	return app.VolumeSettings().Step
+}
+
 func init() {
 	rootCmd.AddCommand(volumeCmd)
+	volumeCmd.AddCommand(volumeRampCmd)
+	volumeRampCmd.Flags().String("to", "", "volume (0 - 1) or preset to ramp to")
+	volumeRampCmd.Flags().Duration("over", 5*time.Second, "how long to take to change the volume")
 }
@@ -0,0 +1,291 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vishen/go-chromecast/application"
+	"github.com/vishen/go-chromecast/storage"
+)
+
+const (
+	// DefaultCacheTTL is how long a cached device is used for before it
+	// has to be found again by another source.
+	DefaultCacheTTL = 7 * 24 * time.Hour
+
+	defaultProbeTimeout = 500 * time.Millisecond
+)
+
+// CacheEntry is a cached device along with when it was last found.
+type CacheEntry struct {
+	Device
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// Expired returns whether the entry is older than ttl. Entries cached
+// before they had a timestamp have always expired.
+func (e CacheEntry) Expired(ttl time.Duration) bool {
+	return e.SavedAt.IsZero() || time.Since(e.SavedAt) > ttl
+}
+
+// Validator checks a cached device can still be used before it is
+// returned from the cache.
+type Validator func(ctx context.Context, d Device) error
+
+// ProbeTCP returns a validator that checks the device's cast port accepts
+// connections.
+func ProbeTCP(timeout time.Duration) Validator {
+	return func(ctx context.Context, d Device) error {
+		dialer := &net.Dialer{Timeout: timeout}
+		conn, err := dialer.DialContext(ctx, "tcp", fmt.Sprintf("%s:%d", d.Addr, d.Port))
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// ProbeInfo returns a validator that asks the device at the cached
+// address for its eureka_info, and checks it is still the same device.
+func ProbeInfo(timeout time.Duration) Validator {
+	return func(ctx context.Context, d Device) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		info, err := application.GetInfoContext(ctx, d.Addr)
+		if err != nil {
+			return err
+		}
+		if uuid := strings.ReplaceAll(info.SsdpUdn, "-", ""); d.UUID != "" && uuid != d.UUID {
+			return fmt.Errorf("address is now used by device %q", uuid)
+		}
+		return nil
+	}
+}
+
+// Validators are the named validators that can be used with the cache.
+var Validators = map[string]Validator{
+	"none": nil,
+	"tcp":  ProbeTCP(defaultProbeTimeout),
+	"info": ProbeInfo(defaultProbeTimeout),
+}
+
+// Cache remembers devices that have previously been found so they can be
+// connected to without waiting on a slower source. Devices are only
+// returned from the cache until they are older than the ttl, and if they
+// pass the validator.
+type Cache struct {
+	store    storage.Store
+	ttl      time.Duration
+	validate Validator
+}
+
+type CacheOption func(*Cache)
+
+// WithCacheTTL sets how long cached devices are used for.
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(c *Cache) {
+		c.ttl = ttl
+	}
+}
+
+// WithValidator sets how cached devices are checked before they are
+// used, nil skips the check.
+func WithValidator(validate Validator) CacheOption {
+	return func(c *Cache) {
+		c.validate = validate
+	}
+}
+
+// NewCache returns a device cache backed by store.
+func NewCache(store storage.Store, opts ...CacheOption) *Cache {
+	c := &Cache{
+		store:    store,
+		ttl:      DefaultCacheTTL,
+		validate: ProbeTCP(defaultProbeTimeout),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *Cache) Name() string { return "cache" }
+
+// Discover returns the cached devices that haven't expired and are still
+// valid. Invalid devices are removed from the cache.
+func (c *Cache) Discover(ctx context.Context) (<-chan Device, error) {
+	entries, err := c.Entries()
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan Device, len(entries))
+	var wg sync.WaitGroup
+	for _, e := range entries {
+		if e.Expired(c.ttl) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.check(ctx, e.Device); err != nil {
+				return
+			}
+			out <- e.Device
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out, nil
+}
+
+// Lookup returns the cached device for the given name or uuid, if it
+// hasn't expired and is still valid.
+func (c *Cache) Lookup(ctx context.Context, nameOrUUID string) (Device, bool) {
+	if nameOrUUID == "" {
+		return Device{}, false
+	}
+	entries, err := c.Entries()
+	if err != nil {
+		return Device{}, false
+	}
+	for _, e := range entries {
+		if e.Expired(c.ttl) || (e.UUID != nameOrUUID && e.Name != nameOrUUID) {
+			continue
+		}
+		if err := c.check(ctx, e.Device); err != nil {
+			return Device{}, false
+		}
+		return e.Device, true
+	}
+	return Device{}, false
+}
+
+// check validates the device, removing it from the cache if it isn't
+// valid.
+func (c *Cache) check(ctx context.Context, d Device) error {
+	if c.validate == nil {
+		return nil
+	}
+	err := c.validate(ctx, d)
+	if err == nil {
+		return nil
+	}
+	log.WithField("package", "discovery").WithError(err).Debugf("cached device %q is no longer valid", d.Name)
+	if ctx.Err() == nil {
+		if err := c.Invalidate(d); err != nil {
+			log.WithField("package", "discovery").WithError(err).Debug("unable to invalidate cached device")
+		}
+	}
+	return err
+}
+
+// Entries returns every cached device, including expired ones, sorted by
+// name.
+func (c *Cache) Entries() ([]CacheEntry, error) {
+	keys, err := c.store.Keys(storage.BucketDevices, "")
+	if err != nil {
+		return nil, err
+	}
+	var entries []CacheEntry
+	seen := map[string]bool{}
+	for _, k := range keys {
+		b, err := c.store.Load(storage.BucketDevices, k)
+		if err != nil {
+			return nil, err
+		}
+		e := CacheEntry{}
+		if err := json.Unmarshal(b, &e); err != nil || e.Addr == "" {
+			continue
+		}
+		// Older versions cached each device under both its uuid and name.
+		if seen[e.key()] {
+			continue
+		}
+		seen[e.key()] = true
+		e.Source = c.Name()
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// Save caches the device, marking it as just found.
+func (c *Cache) Save(d Device) error {
+	b, err := json.Marshal(CacheEntry{
+		Device:  Device{UUID: d.UUID, Name: d.Name, Device: d.Device, Addr: d.Addr, Port: d.Port},
+		SavedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	return c.store.Save(storage.BucketDevices, d.key(), b)
+}
+
+// Invalidate removes the device from the cache, this should be called
+// when a cached device can no longer be connected to as it has likely
+// changed address.
+func (c *Cache) Invalidate(d Device) error {
+	for _, k := range []string{d.key(), d.UUID, d.Name} {
+		if k == "" {
+			continue
+		}
+		if err := c.store.Delete(storage.BucketDevices, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Prune removes the expired devices from the cache, and when validate is
+// true the devices that fail validation too. The removed devices are
+// returned.
+func (c *Cache) Prune(ctx context.Context, validate bool) ([]CacheEntry, error) {
+	entries, err := c.Entries()
+	if err != nil {
+		return nil, err
+	}
+	var pruned []CacheEntry
+	for _, e := range entries {
+		switch {
+		case e.Expired(c.ttl):
+			if err := c.Invalidate(e.Device); err != nil {
+				return pruned, err
+			}
+		case validate && c.validate != nil:
+			// check removes the device itself.
+			if err := c.check(ctx, e.Device); err == nil {
+				continue
+			} else if ctx.Err() != nil {
+				return pruned, ctx.Err()
+			}
+		default:
+			continue
+		}
+		pruned = append(pruned, e)
+	}
+	return pruned, nil
+}
+
+// Clear removes every device from the cache.
+func (c *Cache) Clear() error {
+	keys, err := c.store.Keys(storage.BucketDevices, "")
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if err := c.store.Delete(storage.BucketDevices, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
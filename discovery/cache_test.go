@@ -0,0 +1,101 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/vishen/go-chromecast/storage"
+)
+
+func TestCacheTTL(t *testing.T) {
+	store := storage.NewMemoryStore()
+	c := NewCache(store, WithValidator(nil), WithCacheTTL(time.Hour))
+	if err := c.Save(Device{UUID: "a", Name: "TV", Addr: "10.0.0.1", Port: 8009}); err != nil {
+		t.Fatal(err)
+	}
+	old, _ := json.Marshal(CacheEntry{
+		Device:  Device{UUID: "b", Name: "Speaker", Addr: "10.0.0.2", Port: 8009},
+		SavedAt: time.Now().Add(-2 * time.Hour),
+	})
+	if err := store.Save(storage.BucketDevices, "b", old); err != nil {
+		t.Fatal(err)
+	}
+
+	devices, err := All(context.Background(), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 1 || devices[0].UUID != "a" {
+		t.Fatalf("have %+v, want only the device within the ttl", devices)
+	}
+	if _, ok := c.Lookup(context.Background(), "Speaker"); ok {
+		t.Fatal("expired device was looked up")
+	}
+
+	pruned, err := c.Prune(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pruned) != 1 || pruned[0].UUID != "b" {
+		t.Fatalf("have pruned %+v, want the expired device", pruned)
+	}
+	if entries, _ := c.Entries(); len(entries) != 1 {
+		t.Fatalf("have %d entries after prune, want 1", len(entries))
+	}
+
+	if err := c.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := c.Entries(); len(entries) != 0 {
+		t.Fatalf("have %d entries after clear, want 0", len(entries))
+	}
+}
+
+func TestCacheValidation(t *testing.T) {
+	invalid := errors.New("invalid")
+	c := NewCache(storage.NewMemoryStore(), WithValidator(func(_ context.Context, d Device) error {
+		if d.UUID == "gone" {
+			return invalid
+		}
+		return nil
+	}))
+	for _, d := range []Device{
+		{UUID: "here", Name: "TV", Addr: "10.0.0.1", Port: 8009},
+		{UUID: "gone", Name: "Speaker", Addr: "10.0.0.2", Port: 8009},
+	} {
+		if err := c.Save(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	devices, err := All(context.Background(), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 1 || devices[0].UUID != "here" {
+		t.Fatalf("have %+v, want only the valid device", devices)
+	}
+	if entries, _ := c.Entries(); len(entries) != 1 {
+		t.Fatalf("have %d entries, the invalid device should have been removed", len(entries))
+	}
+}
+
+func TestProbeTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().(*net.TCPAddr)
+	probe := ProbeTCP(time.Second)
+	if err := probe(context.Background(), Device{Addr: "127.0.0.1", Port: addr.Port}); err != nil {
+		t.Fatalf("probe of a listening port failed: %v", err)
+	}
+	l.Close()
+	if err := probe(context.Background(), Device{Addr: "127.0.0.1", Port: addr.Port}); err == nil {
+		t.Fatal("probe of a closed port succeeded")
+	}
+}
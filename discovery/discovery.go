@@ -0,0 +1,141 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrNotFound is returned when no source found a matching device.
+var ErrNotFound = errors.New("no cast devices found on network")
+
+// Device is a cast device found by a Source. It satisfies the
+// dns.CastDNSEntry interface so it can be used anywhere a dns entry is.
+type Device struct {
+	UUID   string   `json:"uuid" yaml:"uuid"`
+	Name   string   `json:"name" yaml:"name"`
+	Device string   `json:"device,omitempty" yaml:"device,omitempty"`
+	Addr   string   `json:"addr" yaml:"addr"`
+	Port   int      `json:"port" yaml:"port"`
+	Host   string   `json:"host,omitempty" yaml:"host,omitempty"`
+	Tags   []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+
+	InfoFields map[string]string `json:"-" yaml:"-"`
+	// Source is the name of the source that found the device.
+	Source string `json:"-" yaml:"-"`
+}
+
+// GetUUID returns the unique id of the device.
+func (d Device) GetUUID() string { return d.UUID }
+
+// GetName returns the friendly name of the device.
+func (d Device) GetName() string { return d.Name }
+
+// GetAddr returns the address of the device.
+func (d Device) GetAddr() string { return d.Addr }
+
+// GetPort returns the port of the device.
+func (d Device) GetPort() int { return d.Port }
+
+// key identifies a device across sources, devices without a uuid
+// (ie: from a static inventory) fall back to their address.
+func (d Device) key() string {
+	if d.UUID != "" {
+		return d.UUID
+	}
+	return fmt.Sprintf("%s:%d", d.Addr, d.Port)
+}
+
+// SameAs returns whether d and other are the same device, by their uuid
+// when both have one or otherwise by their address.
+func (d Device) SameAs(other Device) bool {
+	if d.UUID != "" && other.UUID != "" {
+		return d.UUID == other.UUID
+	}
+	return d.Addr == other.Addr && d.Port == other.Port
+}
+
+// Source finds cast devices. Discover returns a channel that is closed
+// once the source has finished looking, or when ctx is done.
+type Source interface {
+	Name() string
+	Discover(ctx context.Context) (<-chan Device, error)
+}
+
+type multiSource struct {
+	sources []Source
+}
+
+// Multi composes sources in priority order. Each source is asked in turn
+// and a device found by an earlier source hides the same device found by
+// a later one.
+func Multi(sources ...Source) Source {
+	return &multiSource{sources: sources}
+}
+
+func (m *multiSource) Name() string { return "multi" }
+
+func (m *multiSource) Discover(ctx context.Context) (<-chan Device, error) {
+	out := make(chan Device, 5)
+	go func() {
+		defer close(out)
+		seen := map[string]bool{}
+		for _, s := range m.sources {
+			if ctx.Err() != nil {
+				return
+			}
+			ch, err := s.Discover(ctx)
+			if err != nil {
+				log.WithField("package", "discovery").WithError(err).Warnf("unable to discover devices from %s", s.Name())
+				continue
+			}
+			for d := range ch {
+				if seen[d.key()] || ctx.Err() != nil {
+					// Keep draining so the source can clean up.
+					continue
+				}
+				seen[d.key()] = true
+				if d.Source == "" {
+					d.Source = s.Name()
+				}
+				select {
+				case out <- d:
+				case <-ctx.Done():
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Find returns the first device from src that match returns true for.
+func Find(ctx context.Context, src Source, match func(Device) bool) (Device, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch, err := src.Discover(ctx)
+	if err != nil {
+		return Device{}, err
+	}
+	for d := range ch {
+		if match(d) {
+			return d, nil
+		}
+	}
+	return Device{}, ErrNotFound
+}
+
+// All returns every device found by src.
+func All(ctx context.Context, src Source) ([]Device, error) {
+	ch, err := src.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var devices []Device
+	for d := range ch {
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
@@ -0,0 +1,156 @@
+package discovery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadInventory(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "inventory.yaml")
+	contents := `
+devices:
+  - name: Lab TV
+    uuid: b380c5847b3182e4fb2eb0d0e270bf16
+    addr: 10.0.10.5
+    tags: [lab, tv]
+  - name: Lab Speaker
+    addr: 10.0.10.6
+    port: 8010
+`
+	if err := os.WriteFile(filename, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	inv, err := LoadInventory(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inv.Devices) != 2 {
+		t.Fatalf("have %d devices, want 2", len(inv.Devices))
+	}
+	if d := inv.Devices[0]; d.Port != defaultCastPort || d.UUID != "b380c5847b3182e4fb2eb0d0e270bf16" || len(d.Tags) != 2 {
+		t.Fatalf("unexpected device %+v", d)
+	}
+	if d := inv.Devices[1]; d.Port != 8010 || d.Addr != "10.0.10.6" {
+		t.Fatalf("unexpected device %+v", d)
+	}
+}
+
+func TestLoadInventoryMissingAddr(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "inventory.json")
+	if err := os.WriteFile(filename, []byte(`{"devices": [{"name": "Lab TV"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadInventory(filename); err == nil {
+		t.Fatal("expected an error for a device without an address")
+	}
+}
+
+func TestMultiPriority(t *testing.T) {
+	first := NewStaticSource([]Device{
+		{UUID: "a", Name: "TV", Addr: "10.0.0.1", Port: 8009},
+	})
+	second := NewStaticSource([]Device{
+		{UUID: "a", Name: "TV", Addr: "10.0.0.99", Port: 8009},
+		{UUID: "b", Name: "Speaker", Addr: "10.0.0.2", Port: 8009},
+		{Name: "No UUID", Addr: "10.0.0.3", Port: 8009},
+	})
+
+	devices, err := All(context.Background(), Multi(first, second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 3 {
+		t.Fatalf("have %d devices, want 3: %+v", len(devices), devices)
+	}
+	if devices[0].Addr != "10.0.0.1" {
+		t.Fatalf("device from the first source should win, have %+v", devices[0])
+	}
+
+	d, err := Find(context.Background(), Multi(first, second), func(d Device) bool { return d.Name == "Speaker" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.UUID != "b" {
+		t.Fatalf("found the wrong device %+v", d)
+	}
+
+	if _, err := Find(context.Background(), Multi(first, second), func(d Device) bool { return false }); err != ErrNotFound {
+		t.Fatalf("have err %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestSelectorMatch(t *testing.T) {
+	inv := &Inventory{
+		Aliases: map[string]string{
+			"tv":      "Living Room TV",
+			"kitchen": "b87d86bed423a6feb8b91a7d2778b55c",
+		},
+		Tags: map[string][]string{
+			"speakers":   {"kitchen", "Living Room speaker 2"},
+			"downstairs": {"tv", "kitchen"},
+		},
+	}
+	tv := Device{UUID: "b380c5847b3182e4fb2eb0d0e270bf16", Name: "Living Room TV", Device: "Chromecast"}
+	kitchen := Device{UUID: "b87d86bed423a6feb8b91a7d2778b55c", Name: "Kitchen", Device: "Google Home Mini"}
+	speaker := Device{UUID: "c1", Name: "Living Room speaker 2", Device: "Google Home Mini"}
+	lab := Device{UUID: "c2", Name: "Lab TV", Device: "Chromecast", Tags: []string{"lab"}}
+
+	testCases := []struct {
+		selector string
+		device   Device
+		expected bool
+	}{
+		{"tv", tv, true},
+		{"tv", kitchen, false},
+		{"kitchen", kitchen, true},
+		{"alias:kitchen", kitchen, true},
+		{"tag:speakers", kitchen, true},
+		{"tag:speakers", speaker, true},
+		{"tag:speakers", tv, false},
+		{"tag:downstairs", tv, true},
+		{"tag:lab", lab, true},
+		{"name:Lab TV", lab, true},
+		{"uuid:c1", speaker, true},
+		{"Chromecast", lab, true},
+		{"model:Google Home Mini", kitchen, true},
+		{"Google Home Mini", tv, false},
+		// Unknown kinds are part of the value.
+		{"Стас: Колонка", Device{Device: "Стас: Колонка"}, true},
+	}
+	for _, tt := range testCases {
+		if matched := inv.Match(ParseSelector(tt.selector), tt.device); matched != tt.expected {
+			t.Errorf("selector %q against %q: have %t, want %t", tt.selector, tt.device.Name, matched, tt.expected)
+		}
+	}
+
+	if tags := inv.TagsFor(kitchen); !slices.Equal(tags, []string{"downstairs", "speakers"}) {
+		t.Errorf("have tags %v for kitchen", tags)
+	}
+
+	var nilInventory *Inventory
+	if !nilInventory.Match(ParseSelector("Chromecast"), tv) {
+		t.Error("a nil inventory should still match device models")
+	}
+}
+
+func TestDeviceSameAs(t *testing.T) {
+	kitchen := Device{UUID: "a1", Addr: "192.168.0.10", Port: 8009}
+	testCases := []struct {
+		other    Device
+		expected bool
+	}{
+		{Device{UUID: "a1", Addr: "192.168.0.11", Port: 8009}, true},
+		{Device{UUID: "b2", Addr: "192.168.0.10", Port: 8009}, false},
+		// Devices from a static inventory don't have a uuid.
+		{Device{Addr: "192.168.0.10", Port: 8009}, true},
+		{Device{Addr: "192.168.0.10", Port: 8010}, false},
+	}
+	for _, tt := range testCases {
+		if same := kitchen.SameAs(tt.other); same != tt.expected {
+			t.Errorf("%+v: have %t, want %t", tt.other, same, tt.expected)
+		}
+	}
+}
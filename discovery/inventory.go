@@ -0,0 +1,77 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultCastPort = 8009
+
+// Inventory is a static list of known cast devices, useful for devices
+// that can't be found with multicast dns (ie: behind a router), along
+// with aliases and tags for addressing any device. Aliases and tags
+// refer to devices by name or uuid, tags can also use an alias.
+//
+//	devices:
+//	  - name: Lab TV
+//	    uuid: b380c5847b3182e4fb2eb0d0e270bf16
+//	    addr: 10.0.10.5
+//	    port: 8009
+//	    tags: [lab, tv]
+//	aliases:
+//	  tv: Living Room TV
+//	  kitchen: b87d86bed423a6feb8b91a7d2778b55c
+//	tags:
+//	  speakers: [kitchen, Living Room speaker 2]
+//	  downstairs: [tv, kitchen]
+type Inventory struct {
+	Devices []Device            `yaml:"devices"`
+	Aliases map[string]string   `yaml:"aliases"`
+	Tags    map[string][]string `yaml:"tags"`
+}
+
+// LoadInventory reads an inventory file. As YAML is a superset of JSON
+// the file can be in either format.
+func LoadInventory(filename string) (*Inventory, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	inv := &Inventory{}
+	if err := yaml.Unmarshal(b, inv); err != nil {
+		return nil, fmt.Errorf("unable to parse inventory %q: %w", filename, err)
+	}
+	for i, d := range inv.Devices {
+		if d.Addr == "" {
+			return nil, fmt.Errorf("inventory %q: device %d (%q) is missing 'addr'", filename, i+1, d.Name)
+		}
+		if d.Port == 0 {
+			inv.Devices[i].Port = defaultCastPort
+		}
+	}
+	return inv, nil
+}
+
+type staticSource struct {
+	devices []Device
+}
+
+// NewStaticSource returns a source that always finds the given devices.
+func NewStaticSource(devices []Device) Source {
+	return &staticSource{devices: devices}
+}
+
+func (s *staticSource) Name() string { return "inventory" }
+
+func (s *staticSource) Discover(ctx context.Context) (<-chan Device, error) {
+	out := make(chan Device, len(s.devices))
+	for _, d := range s.devices {
+		d.Source = s.Name()
+		out <- d
+	}
+	close(out)
+	return out, nil
+}
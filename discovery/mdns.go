@@ -0,0 +1,57 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"time"
+
+	castdns "github.com/vishen/go-chromecast/dns"
+)
+
+type mdnsSource struct {
+	iface   *net.Interface
+	timeout time.Duration
+}
+
+// NewMDNSSource returns a source that browses for cast devices using
+// multicast dns for up to timeout.
+func NewMDNSSource(iface *net.Interface, timeout time.Duration) Source {
+	return &mdnsSource{iface: iface, timeout: timeout}
+}
+
+func (s *mdnsSource) Name() string { return "mdns" }
+
+func (s *mdnsSource) Discover(ctx context.Context) (<-chan Device, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	entries, err := castdns.DiscoverCastDNSEntries(ctx, s.iface)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	out := make(chan Device, 5)
+	go func() {
+		defer cancel()
+		defer close(out)
+		for e := range entries {
+			select {
+			case out <- FromCastEntry(e):
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return out, nil
+}
+
+// FromCastEntry converts a multicast dns entry to a Device.
+func FromCastEntry(e castdns.CastEntry) Device {
+	return Device{
+		UUID:       e.UUID,
+		Name:       e.DeviceName,
+		Device:     e.Device,
+		Addr:       e.GetAddr(),
+		Port:       e.Port,
+		Host:       e.Host,
+		InfoFields: e.InfoFields,
+		Source:     "mdns",
+	}
+}
@@ -0,0 +1,96 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seancfoley/ipaddress-go/ipaddr"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vishen/go-chromecast/application"
+)
+
+const (
+	scanWorkers     = 64
+	scanDialTimeout = 400 * time.Millisecond
+)
+
+type scanSource struct {
+	ipRange *ipaddr.IPAddressSeqRange
+	port    int
+}
+
+// NewScanSource returns a source that tries to connect to port on every
+// address in the cidr range, and asks anything that answers for its
+// device info. This is slow but works when multicast dns doesn't.
+func NewScanSource(cidr string, port int) (Source, error) {
+	ipRange, err := ipaddr.NewIPAddressString(cidr).ToSequentialRange()
+	if err != nil {
+		return nil, fmt.Errorf("could not parse cidr address expression: %w", err)
+	}
+	if port == 0 {
+		port = defaultCastPort
+	}
+	return &scanSource{ipRange: ipRange, port: port}, nil
+}
+
+func (s *scanSource) Name() string { return "scan" }
+
+func (s *scanSource) Discover(ctx context.Context) (<-chan Device, error) {
+	ipCh := make(chan string)
+	out := make(chan Device, 5)
+
+	// Use one goroutine to send addresses over a channel.
+	go func() {
+		defer close(ipCh)
+		it := s.ipRange.Iterator()
+		for it.HasNext() {
+			select {
+			case ipCh <- it.Next().String():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Use a bunch of goroutines to do connect-attempts.
+	var wg sync.WaitGroup
+	for i := 0; i < scanWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dialer := &net.Dialer{Timeout: scanDialTimeout}
+			for ip := range ipCh {
+				conn, err := dialer.DialContext(ctx, "tcp", fmt.Sprintf("%s:%d", ip, s.port))
+				if err != nil {
+					continue
+				}
+				conn.Close()
+				info, err := application.GetInfo(ip)
+				if err != nil {
+					log.WithField("package", "discovery").WithError(err).Debugf("device at %s:%d errored during discovery", ip, s.port)
+					continue
+				}
+				select {
+				case out <- Device{
+					UUID:   strings.ReplaceAll(info.SsdpUdn, "-", ""),
+					Name:   info.Name,
+					Addr:   ip,
+					Port:   s.port,
+					Source: s.Name(),
+				}:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out, nil
+}
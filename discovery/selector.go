@@ -0,0 +1,124 @@
+package discovery
+
+import (
+	"slices"
+	"strings"
+)
+
+// Selector kinds.
+const (
+	SelectAlias = "alias"
+	SelectTag   = "tag"
+	SelectName  = "name"
+	SelectUUID  = "uuid"
+	SelectModel = "model"
+)
+
+// Selector picks one or more devices. It is written as '<kind>:<value>',
+// ie: 'tag:speakers' or 'uuid:b380c5847b3182e4fb2eb0d0e270bf16'. A value
+// without a known kind is an alias if the inventory has one by that
+// name, otherwise a device model such as 'Chromecast'.
+type Selector struct {
+	Kind  string
+	Value string
+}
+
+// ParseSelector parses a selector. As device names can contain a ':' an
+// unknown kind is treated as part of the value.
+func ParseSelector(s string) Selector {
+	if kind, value, ok := strings.Cut(s, ":"); ok {
+		switch kind {
+		case SelectAlias, SelectTag, SelectName, SelectUUID, SelectModel:
+			return Selector{Kind: kind, Value: value}
+		}
+	}
+	return Selector{Value: s}
+}
+
+func (s Selector) String() string {
+	if s.Kind == "" {
+		return s.Value
+	}
+	return s.Kind + ":" + s.Value
+}
+
+// Multiple returns whether the selector can match more than one device.
+func (s Selector) Multiple() bool {
+	return s.Kind == SelectTag
+}
+
+// Resolve returns the selector with a bare value resolved to either an
+// alias or a device model.
+func (inv *Inventory) Resolve(s Selector) Selector {
+	if s.Kind != "" {
+		return s
+	}
+	if _, ok := inv.aliases()[s.Value]; ok {
+		return Selector{Kind: SelectAlias, Value: s.Value}
+	}
+	return Selector{Kind: SelectModel, Value: s.Value}
+}
+
+// Match returns whether the device is picked by the selector, using the
+// aliases and tags in the inventory. A nil inventory has neither.
+func (inv *Inventory) Match(s Selector, d Device) bool {
+	s = inv.Resolve(s)
+	switch s.Kind {
+	case SelectAlias:
+		ref, ok := inv.aliases()[s.Value]
+		return ok && refersTo(ref, d)
+	case SelectTag:
+		if slices.Contains(d.Tags, s.Value) {
+			return true
+		}
+		for _, ref := range inv.tags()[s.Value] {
+			if alias, ok := inv.aliases()[ref]; ok {
+				ref = alias
+			}
+			if refersTo(ref, d) {
+				return true
+			}
+		}
+		return false
+	case SelectName:
+		return d.Name == s.Value
+	case SelectUUID:
+		return d.UUID == s.Value
+	case SelectModel:
+		return d.Device == s.Value
+	}
+	return false
+}
+
+// TagsFor returns all the tags the device has, both from the device
+// itself and the inventory tags.
+func (inv *Inventory) TagsFor(d Device) []string {
+	tags := slices.Clone(d.Tags)
+	for tag := range inv.tags() {
+		if !slices.Contains(tags, tag) && inv.Match(Selector{Kind: SelectTag, Value: tag}, d) {
+			tags = append(tags, tag)
+		}
+	}
+	slices.Sort(tags)
+	return tags
+}
+
+func (inv *Inventory) aliases() map[string]string {
+	if inv == nil {
+		return nil
+	}
+	return inv.Aliases
+}
+
+func (inv *Inventory) tags() map[string][]string {
+	if inv == nil {
+		return nil
+	}
+	return inv.Tags
+}
+
+// refersTo returns whether a device reference, which is either a device
+// name or uuid, refers to the device.
+func refersTo(ref string, d Device) bool {
+	return ref != "" && (ref == d.Name || ref == d.UUID)
+}
@@ -0,0 +1,377 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The events sent to hooks.
+const (
+	// EventMediaStart is sent when media starts playing, including when
+	// it is unpaused or the queue moves on to the next media.
+	EventMediaStart = "media_start"
+	// EventMediaPause is sent when the media is paused.
+	EventMediaPause = "media_pause"
+	// EventMediaFinish is sent when the media stops playing, with the
+	// reason the device gave in the idle_reason of the media.
+	EventMediaFinish = "media_finish"
+	// EventAppChange is sent when the application running on the device
+	// changes, or closes.
+	EventAppChange = "app_change"
+	// EventVolumeChange is sent when the volume is changed or muted.
+	EventVolumeChange = "volume_change"
+	// EventDisconnect is sent when the connection to the device is lost.
+	EventDisconnect = "disconnect"
+)
+
+// Events are the events sent to hooks.
+var Events = []string{EventMediaStart, EventMediaPause, EventMediaFinish, EventAppChange, EventVolumeChange, EventDisconnect}
+
+// defaultTimeout is how long a hook can run for when it has no timeout.
+const defaultTimeout = 10 * time.Second
+
+// Event is a change to the state of a device, along with the state of the
+// device after it.
+type Event struct {
+	Type   string    `json:"type"`
+	Time   time.Time `json:"time"`
+	Device Device    `json:"device"`
+	App    *App      `json:"app,omitempty"`
+	Media  *Media    `json:"media,omitempty"`
+	Volume *Volume   `json:"volume,omitempty"`
+	// Error is why the device was disconnected.
+	Error string `json:"error,omitempty"`
+}
+
+// Device is the device that an event is for.
+type Device struct {
+	Name string `json:"name"`
+	UUID string `json:"uuid,omitempty"`
+	Addr string `json:"addr"`
+	Port int    `json:"port"`
+}
+
+// Key identifies the device, devices without a uuid fall back to their
+// address.
+func (d Device) Key() string {
+	if d.UUID != "" {
+		return d.UUID
+	}
+	return fmt.Sprintf("%s:%d", d.Addr, d.Port)
+}
+
+// App is the application running on a device.
+type App struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	IdleScreen bool   `json:"idle_screen,omitempty"`
+}
+
+// Media is the media loaded on a device.
+type Media struct {
+	ContentID   string  `json:"content_id,omitempty"`
+	ContentType string  `json:"content_type,omitempty"`
+	Title       string  `json:"title,omitempty"`
+	Artist      string  `json:"artist,omitempty"`
+	PlayerState string  `json:"player_state"`
+	IdleReason  string  `json:"idle_reason,omitempty"`
+	CurrentTime float32 `json:"current_time"`
+	Duration    float32 `json:"duration,omitempty"`
+}
+
+// Volume is the volume of a device.
+type Volume struct {
+	Level float32 `json:"level"`
+	Muted bool    `json:"muted"`
+}
+
+// Config is the hooks to run, kept in a yaml file.
+//
+//	debounce: 2s
+//	hooks:
+//	  - name: lights
+//	    events: [media_start, media_pause, media_finish]
+//	    command: ~/bin/lights.sh
+//	  - name: home assistant
+//	    events: [volume_change]
+//	    webhook: http://homeassistant.local:8123/api/webhook/chromecast
+//	    debounce: 5s
+type Config struct {
+	// Debounce is the debounce of hooks that don't have their own.
+	Debounce time.Duration `yaml:"debounce"`
+	Hooks    []Hook        `yaml:"hooks"`
+}
+
+// Hook is a shell command run, or a webhook posted to, with events. The
+// event is written as JSON to the stdin of the command, and is in its
+// GO_CHROMECAST_EVENT_JSON environment variable, or is the body of the
+// webhook request.
+type Hook struct {
+	Name string `yaml:"name"`
+	// Events are the events the hook is run for, all of them when there
+	// are none.
+	Events  []string `yaml:"events"`
+	Command string   `yaml:"command"`
+	Webhook string   `yaml:"webhook"`
+	// Headers are extra headers sent with the webhook request, ie: for
+	// authorization.
+	Headers map[string]string `yaml:"headers"`
+	// Debounce is how long an event has to be the last of its type from
+	// a device before the hook is run, so that a burst of events, ie: from
+	// dragging a volume slider, runs the hook once with the last of them.
+	Debounce time.Duration `yaml:"debounce"`
+	// Timeout is how long the hook can run for, 10 seconds by default.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Load reads and checks a hooks file.
+func Load(filename string) (*Config, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := yaml.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("unable to parse hooks %q: %w", filename, err)
+	}
+	if err := cfg.init(); err != nil {
+		return nil, fmt.Errorf("hooks %q: %w", filename, err)
+	}
+	return cfg, nil
+}
+
+// init checks the hooks and fills in their defaults.
+func (c *Config) init() error {
+	if len(c.Hooks) == 0 {
+		return fmt.Errorf("there are no hooks")
+	}
+	if c.Debounce < 0 {
+		return fmt.Errorf("debounce must not be negative")
+	}
+	for i := range c.Hooks {
+		h := &c.Hooks[i]
+		if h.Name == "" {
+			h.Name = fmt.Sprintf("hook %d", i+1)
+		}
+		if err := h.init(c.Debounce); err != nil {
+			return fmt.Errorf("%s: %w", h.Name, err)
+		}
+	}
+	return nil
+}
+
+func (h *Hook) init(debounce time.Duration) error {
+	switch {
+	case h.Command == "" && h.Webhook == "":
+		return fmt.Errorf("needs a command or a webhook")
+	case h.Command != "" && h.Webhook != "":
+		return fmt.Errorf("can only have one of command and webhook")
+	case h.Webhook != "":
+		u, err := url.Parse(h.Webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook %q is not an http url", h.Webhook)
+		}
+	}
+	if h.Webhook == "" && len(h.Headers) > 0 {
+		return fmt.Errorf("headers only work with a webhook")
+	}
+	for _, e := range h.Events {
+		if !slices.Contains(Events, e) {
+			return fmt.Errorf("unknown event %q, one of: %s", e, strings.Join(Events, ", "))
+		}
+	}
+	if h.Debounce < 0 || h.Timeout < 0 {
+		return fmt.Errorf("debounce and timeout must not be negative")
+	}
+	if h.Debounce == 0 {
+		h.Debounce = debounce
+	}
+	if h.Timeout == 0 {
+		h.Timeout = defaultTimeout
+	}
+	return nil
+}
+
+// Wants returns whether the hook is run for the event type.
+func (h Hook) Wants(eventType string) bool {
+	return len(h.Events) == 0 || slices.Contains(h.Events, eventType)
+}
+
+// String describes what the hook runs.
+func (h Hook) String() string {
+	if h.Webhook != "" {
+		return "POST " + h.Webhook
+	}
+	return h.Command
+}
+
+// Run runs the hook with the event, output from a command is written to
+// out.
+func (h Hook) Run(ctx context.Context, e Event, out io.Writer) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.Timeout)
+	defer cancel()
+	if h.Webhook != "" {
+		return h.post(ctx, body)
+	}
+	return h.exec(ctx, e, body, out)
+}
+
+func (h Hook) exec(ctx context.Context, e Event, body []byte, out io.Writer) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", h.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", h.Command)
+	}
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Stdout, cmd.Stderr = out, out
+	// Don't wait on anything the command started that is still writing
+	// to its output once it has been killed.
+	cmd.WaitDelay = time.Second
+	cmd.Env = append(os.Environ(),
+		"GO_CHROMECAST_EVENT="+e.Type,
+		"GO_CHROMECAST_EVENT_JSON="+string(body),
+		"GO_CHROMECAST_DEVICE="+e.Device.Name,
+	)
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("command timed out after %s", h.Timeout)
+		}
+		return fmt.Errorf("command failed: %w", err)
+	}
+	return nil
+}
+
+func (h Hook) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-chromecast")
+	for k, v := range h.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Dispatcher runs hooks with events, debouncing them.
+type Dispatcher struct {
+	ctx   context.Context
+	hooks []Hook
+	// Output is where output from commands is written, it is thrown away
+	// when nil.
+	Output io.Writer
+	// Done is called, when not nil, after each hook has run.
+	Done func(h Hook, e Event, err error)
+
+	mu      sync.Mutex
+	pending map[string]*debounced
+	wg      sync.WaitGroup
+}
+
+// NewDispatcher returns a dispatcher of the hooks, that stops running them
+// once ctx is done.
+func NewDispatcher(ctx context.Context, cfg *Config) *Dispatcher {
+	return &Dispatcher{ctx: ctx, hooks: cfg.Hooks, pending: map[string]*debounced{}}
+}
+
+// debounced is a hook waiting for events to stop before it runs.
+type debounced struct {
+	timer *time.Timer
+}
+
+// Send runs the hooks that want the event. Hooks with a debounce are run
+// once events of the type from the device have stopped for the debounce,
+// with the last of them.
+func (d *Dispatcher) Send(e Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.send(e)
+}
+
+// send is Send with d.mu held.
+func (d *Dispatcher) send(e Event) {
+	if d.ctx.Err() != nil {
+		return
+	}
+	for i, h := range d.hooks {
+		if !h.Wants(e.Type) {
+			continue
+		}
+		if h.Debounce <= 0 {
+			d.run(h, e)
+			continue
+		}
+		key := fmt.Sprintf("%d %s %s", i, e.Device.Key(), e.Type)
+		if p, ok := d.pending[key]; ok && p.timer.Stop() {
+			d.wg.Done()
+		}
+		// A timer that fired but is still waiting for d.mu finds it has
+		// been replaced, and leaves the event to the new one.
+		p := &debounced{}
+		d.wg.Add(1)
+		p.timer = time.AfterFunc(h.Debounce, func() {
+			defer d.wg.Done()
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			if d.pending[key] != p {
+				return
+			}
+			delete(d.pending, key)
+			if d.ctx.Err() == nil {
+				d.run(h, e)
+			}
+		})
+		d.pending[key] = p
+	}
+}
+
+// run runs the hook in its own goroutine, d.mu must be held.
+func (d *Dispatcher) run(h Hook, e Event) {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		out := d.Output
+		if out == nil {
+			out = io.Discard
+		}
+		err := h.Run(d.ctx, e, out)
+		if d.Done != nil {
+			d.Done(h, e, err)
+		}
+	}()
+}
+
+// Wait waits for the hooks that are running, and any that are waiting for
+// their debounce, to finish.
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
@@ -0,0 +1,316 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vishen/go-chromecast/cast"
+	pb "github.com/vishen/go-chromecast/cast/proto"
+)
+
+func TestLoad(t *testing.T) {
+	assertions := require.New(t)
+	cfg, err := Load(filepath.Join("testdata", "hooks.yaml"))
+	assertions.NoError(err)
+
+	assertions.Len(cfg.Hooks, 3)
+	lights := cfg.Hooks[0]
+	assertions.Equal(time.Second, lights.Debounce)
+	assertions.Equal(defaultTimeout, lights.Timeout)
+	assertions.True(lights.Wants(EventMediaPause))
+	assertions.False(lights.Wants(EventVolumeChange))
+
+	webhook := cfg.Hooks[1]
+	assertions.Equal(5*time.Second, webhook.Debounce)
+	assertions.Equal(2*time.Second, webhook.Timeout)
+	assertions.Equal("POST http://homeassistant.local:8123/api/webhook/chromecast", webhook.String())
+
+	assertions.Equal("hook 3", cfg.Hooks[2].Name)
+	assertions.True(cfg.Hooks[2].Wants(EventDisconnect))
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for content, want := range map[string]string{
+		"hooks: []":                        "there are no hooks",
+		"hooks: [{events: [media_start]}]": "hook 1: needs a command or a webhook",
+		"hooks: [{command: x, webhook: 'http://localhost'}]": "can only have one of command and webhook",
+		"hooks: [{name: x, webhook: 'localhost:8000'}]":      `x: webhook "localhost:8000" is not an http url`,
+		"hooks: [{command: x, headers: {a: b}}]":             "headers only work with a webhook",
+		"hooks: [{command: x, events: [media_stop]}]":        `unknown event "media_stop"`,
+		"hooks: [{command: x, debounce: -1s}]":               "must not be negative",
+		"hooks: [{command: x, timeout: soon}]":               "unable to parse hooks",
+		"debounce: -1s\nhooks: [{command: x}]":               "debounce must not be negative",
+		"hooks: [{command: x}]\n}}":                          "unable to parse hooks",
+	} {
+		filename := filepath.Join(t.TempDir(), "hooks.yaml")
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+		_, err := Load(filename)
+		require.ErrorContains(t, err, want, content)
+	}
+}
+
+func message(payload string) *pb.CastMessage {
+	return &pb.CastMessage{PayloadUtf8: &payload}
+}
+
+func mediaStatus(state, idleReason, contentID string) *pb.CastMessage {
+	media := ""
+	if contentID != "" {
+		media = fmt.Sprintf(`, "media": {"contentId": %q, "contentType": "audio/mpeg", "duration": 180, "metadata": {"title": "Song"}}`, contentID)
+	}
+	return message(fmt.Sprintf(`{"type": "MEDIA_STATUS", "requestId": 0, "status": [{"mediaSessionId": 1, "playerState": %q, "idleReason": %q%s}]}`, state, idleReason, media))
+}
+
+func receiverStatus(appID string, level float32, muted bool) *pb.CastMessage {
+	apps := ""
+	if appID != "" {
+		apps = fmt.Sprintf(`{"appId": %q, "displayName": "App %s"}`, appID, appID)
+	}
+	return message(fmt.Sprintf(`{"type": "RECEIVER_STATUS", "requestId": 0, "status": {"applications": [%s], "volume": {"level": %v, "muted": %t}}}`, apps, level, muted))
+}
+
+func TestTracker(t *testing.T) {
+	assertions := require.New(t)
+	tracker := NewTracker(Device{Name: "Kitchen speaker", Addr: "192.168.1.10", Port: 8009})
+
+	var got []string
+	observe := func(events []Event) {
+		for _, e := range events {
+			s := e.Type
+			if e.Media != nil && strings.HasPrefix(e.Type, "media_") {
+				s += " " + e.Media.ContentID + " " + e.Media.IdleReason
+			}
+			if e.Type == EventAppChange && e.App != nil {
+				s += " " + e.App.Name
+			}
+			if e.Type == EventVolumeChange {
+				s += fmt.Sprintf(" %.1f %t", e.Volume.Level, e.Volume.Muted)
+			}
+			assertions.Equal("Kitchen speaker", e.Device.Name)
+			got = append(got, strings.TrimSpace(s))
+		}
+	}
+
+	// The first status is the starting state.
+	observe(tracker.Message(receiverStatus("", 0.5, false)))
+	observe(tracker.Message(receiverStatus("CC1AD845", 0.5, false)))
+	observe(tracker.Message(mediaStatus("BUFFERING", "", "a.mp3")))
+	observe(tracker.Message(mediaStatus("PLAYING", "", "")))
+	observe(tracker.Message(mediaStatus("BUFFERING", "", "")))
+	observe(tracker.Message(mediaStatus("PLAYING", "", "")))
+	observe(tracker.Message(mediaStatus("PAUSED", "", "")))
+	observe(tracker.Message(mediaStatus("BUFFERING", "", "")))
+	observe(tracker.Message(mediaStatus("PAUSED", "", "")))
+	observe(tracker.Message(receiverStatus("CC1AD845", 0.3, false)))
+	observe(tracker.Message(receiverStatus("CC1AD845", 0.3, true)))
+	observe(tracker.Message(mediaStatus("PLAYING", "", "")))
+	observe(tracker.Message(mediaStatus("PLAYING", "", "b.mp3")))
+	observe(tracker.Message(mediaStatus("IDLE", "FINISHED", "")))
+	observe(tracker.Message(mediaStatus("IDLE", "FINISHED", "")))
+	// Replies to requests are ignored.
+	observe(tracker.Message(message(`{"type": "MEDIA_STATUS", "requestId": 3, "status": [{"playerState": "PLAYING"}]}`)))
+	observe(tracker.Message(mediaStatus("PLAYING", "", "c.mp3")))
+	observe(tracker.Message(receiverStatus("", 0.3, true)))
+
+	assertions.Equal([]string{
+		"app_change App CC1AD845",
+		"media_start a.mp3",
+		"media_pause a.mp3",
+		"volume_change 0.3 false",
+		"volume_change 0.3 true",
+		"media_start a.mp3",
+		"media_start b.mp3",
+		"media_finish b.mp3 FINISHED",
+		"media_start c.mp3",
+		"media_finish c.mp3",
+		"app_change",
+	}, got)
+
+	e := tracker.Disconnect(io.EOF)
+	assertions.Equal(EventDisconnect, e.Type)
+	assertions.Equal("EOF", e.Error)
+	assertions.Empty(tracker.Message(receiverStatus("CC1AD845", 0.3, true)))
+
+	// Media playing when connecting to the device has already started.
+	tracker.Reset(&cast.Application{AppId: "CC1AD845"}, &cast.Media{PlayerState: "PLAYING", Media: cast.MediaItem{ContentId: "d.mp3"}}, &cast.Volume{Level: 0.3})
+	assertions.Empty(tracker.Message(mediaStatus("PLAYING", "", "")))
+	events := tracker.Message(mediaStatus("PAUSED", "", ""))
+	assertions.Len(events, 1)
+	assertions.Equal(EventMediaPause, events[0].Type)
+	assertions.Equal("d.mp3", events[0].Media.ContentID)
+	assertions.Equal("CC1AD845", events[0].App.ID)
+}
+
+// receiver is a webhook that keeps the events posted to it.
+type receiver struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	events  []Event
+	headers []http.Header
+	posted  chan struct{}
+}
+
+func newReceiver(t *testing.T, status int) *receiver {
+	r := &receiver{posted: make(chan struct{}, 10)}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		e := Event{}
+		if req.Method != http.MethodPost || json.NewDecoder(req.Body).Decode(&e) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.mu.Lock()
+		r.events = append(r.events, e)
+		r.headers = append(r.headers, req.Header)
+		r.mu.Unlock()
+		w.WriteHeader(status)
+		r.posted <- struct{}{}
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *receiver) wait(t *testing.T) {
+	select {
+	case <-r.posted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook wasn't posted to")
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	assertions := require.New(t)
+	r := newReceiver(t, http.StatusNoContent)
+
+	h := Hook{Webhook: r.URL + "/hook", Headers: map[string]string{"Authorization": "Bearer secret"}}
+	assertions.NoError(h.init(0))
+	e := Event{Type: EventMediaStart, Device: Device{Name: "Kitchen speaker"}, Media: &Media{ContentID: "a.mp3", PlayerState: "PLAYING"}}
+	assertions.NoError(h.Run(context.Background(), e, io.Discard))
+
+	assertions.Len(r.events, 1)
+	assertions.Equal(EventMediaStart, r.events[0].Type)
+	assertions.Equal("a.mp3", r.events[0].Media.ContentID)
+	assertions.Equal("Bearer secret", r.headers[0].Get("Authorization"))
+	assertions.Equal("application/json", r.headers[0].Get("Content-Type"))
+
+	failing := newReceiver(t, http.StatusInternalServerError)
+	h.Webhook = failing.URL
+	assertions.ErrorContains(h.Run(context.Background(), e, io.Discard), "webhook returned 500")
+}
+
+func TestCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the command uses sh")
+	}
+	assertions := require.New(t)
+	dir := t.TempDir()
+	h := Hook{Command: fmt.Sprintf(`cat > %s/event.json; echo "$GO_CHROMECAST_EVENT $GO_CHROMECAST_DEVICE"`, dir)}
+	assertions.NoError(h.init(0))
+
+	var out strings.Builder
+	e := Event{Type: EventVolumeChange, Device: Device{Name: "Kitchen speaker"}, Volume: &Volume{Level: 0.4}}
+	assertions.NoError(h.Run(context.Background(), e, &out))
+	assertions.Equal("volume_change Kitchen speaker\n", out.String())
+
+	b, err := os.ReadFile(filepath.Join(dir, "event.json"))
+	assertions.NoError(err)
+	got := Event{}
+	assertions.NoError(json.Unmarshal(b, &got))
+	assertions.Equal(float32(0.4), got.Volume.Level)
+
+	h = Hook{Command: "exit 3"}
+	assertions.NoError(h.init(0))
+	assertions.ErrorContains(h.Run(context.Background(), e, io.Discard), "command failed")
+
+	h = Hook{Command: "sleep 5", Timeout: 50 * time.Millisecond}
+	assertions.NoError(h.init(0))
+	assertions.ErrorContains(h.Run(context.Background(), e, io.Discard), "timed out")
+}
+
+func TestDispatcherDebounce(t *testing.T) {
+	assertions := require.New(t)
+	r := newReceiver(t, http.StatusOK)
+	cfg := &Config{Hooks: []Hook{
+		{Name: "volume", Events: []string{EventVolumeChange}, Webhook: r.URL, Debounce: 100 * time.Millisecond},
+		{Name: "media", Events: []string{EventMediaStart}, Webhook: r.URL},
+	}}
+	assertions.NoError(cfg.init())
+
+	var mu sync.Mutex
+	var done []string
+	d := NewDispatcher(context.Background(), cfg)
+	d.Done = func(h Hook, e Event, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		assertions.NoError(err)
+		done = append(done, h.Name+" "+e.Type)
+	}
+
+	kitchen := Device{Name: "Kitchen speaker", UUID: "kitchen"}
+	for _, level := range []float32{0.1, 0.2, 0.3} {
+		d.Send(Event{Type: EventVolumeChange, Device: kitchen, Volume: &Volume{Level: level}})
+	}
+	d.Send(Event{Type: EventVolumeChange, Device: Device{Name: "Bedroom speaker", UUID: "bedroom"}, Volume: &Volume{Level: 0.9}})
+	d.Send(Event{Type: EventMediaStart, Device: kitchen})
+	d.Send(Event{Type: EventMediaPause, Device: kitchen})
+	d.Wait()
+
+	assertions.ElementsMatch([]string{"volume volume_change", "volume volume_change", "media media_start"}, done)
+	levels := map[string]float32{}
+	for _, e := range r.events {
+		if e.Type == EventVolumeChange {
+			levels[e.Device.Name] = e.Volume.Level
+		}
+	}
+	assertions.Equal(map[string]float32{"Kitchen speaker": 0.3, "Bedroom speaker": 0.9}, levels)
+}
+
+func TestDispatcherDebounceBoundary(t *testing.T) {
+	assertions := require.New(t)
+	r := newReceiver(t, http.StatusOK)
+	cfg := &Config{Hooks: []Hook{{Webhook: r.URL, Debounce: 20 * time.Millisecond}}}
+	assertions.NoError(cfg.init())
+
+	d := NewDispatcher(context.Background(), cfg)
+	kitchen := Device{Name: "Kitchen speaker", UUID: "kitchen"}
+	d.Send(Event{Type: EventVolumeChange, Device: kitchen, Volume: &Volume{Level: 0.1}})
+
+	// The debounce ends while an event is being sent, so the timer has
+	// fired and is waiting to run the hook when it is replaced.
+	d.mu.Lock()
+	time.Sleep(100 * time.Millisecond)
+	d.send(Event{Type: EventVolumeChange, Device: kitchen, Volume: &Volume{Level: 0.2}})
+	d.mu.Unlock()
+	d.Wait()
+
+	assertions.Len(r.events, 1)
+	assertions.Equal(float32(0.2), r.events[0].Volume.Level)
+	assertions.Empty(d.pending)
+}
+
+func TestDispatcherCancel(t *testing.T) {
+	assertions := require.New(t)
+	r := newReceiver(t, http.StatusOK)
+	cfg := &Config{Hooks: []Hook{{Webhook: r.URL, Debounce: 50 * time.Millisecond}}}
+	assertions.NoError(cfg.init())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d := NewDispatcher(ctx, cfg)
+	d.Send(Event{Type: EventMediaStart})
+	cancel()
+	d.Send(Event{Type: EventMediaPause})
+	d.Wait()
+	assertions.Empty(r.events)
+}
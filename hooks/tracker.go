@@ -0,0 +1,226 @@
+package hooks
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/vishen/go-chromecast/cast"
+	pb "github.com/vishen/go-chromecast/cast/proto"
+)
+
+// Tracker follows the state of a device and turns changes to it into
+// events. It is safe to use from several goroutines.
+type Tracker struct {
+	device Device
+
+	mu       sync.Mutex
+	observed bool
+	app      *App
+	volume   *Volume
+	media    *Media
+	// state is the last of EventMediaStart or EventMediaPause sent for the
+	// media, or empty when nothing is playing.
+	state string
+}
+
+// NewTracker returns a tracker for the device.
+func NewTracker(device Device) *Tracker {
+	return &Tracker{device: device}
+}
+
+// Reset sets the state of the device, ie: from its status once connected
+// to it, without sending any events.
+func (t *Tracker) Reset(app *cast.Application, media *cast.Media, volume *cast.Volume) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.observed = false
+	t.receiver(app, volume)
+	t.media, t.state = nil, ""
+	if media != nil {
+		t.mediaStatus(media)
+	}
+}
+
+// State returns the state of the device as an event without a type.
+func (t *Tracker) State() Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.event("")
+}
+
+// Message updates the state from a message broadcast by the device. Replies
+// to requests are ignored.
+func (t *Tracker) Message(msg *pb.CastMessage) []Event {
+	payload := []byte(msg.GetPayloadUtf8())
+	if requestID, _ := jsonparser.GetInt(payload, "requestId"); requestID != 0 {
+		return nil
+	}
+	messageType, _ := jsonparser.GetString(payload, "type")
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch messageType {
+	case "RECEIVER_STATUS":
+		resp := cast.ReceiverStatusResponse{}
+		if err := json.Unmarshal(payload, &resp); err != nil {
+			return nil
+		}
+		var app *cast.Application
+		for _, a := range resp.Status.Applications {
+			app = &a
+		}
+		return t.receiver(app, &resp.Status.Volume)
+	case "MEDIA_STATUS":
+		resp := cast.MediaStatusResponse{}
+		if err := json.Unmarshal(payload, &resp); err != nil {
+			return nil
+		}
+		var media *cast.Media
+		for _, m := range resp.Status {
+			media = &m
+		}
+		return t.mediaStatus(media)
+	case "CLOSE":
+		return t.mediaStatus(nil)
+	}
+	return nil
+}
+
+// Disconnect returns the event for losing the connection to the device.
+// The next status after it is the starting state again.
+func (t *Tracker) Disconnect(err error) Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e := t.event(EventDisconnect)
+	if err != nil {
+		e.Error = err.Error()
+	}
+	t.observed = false
+	t.app, t.volume, t.media, t.state = nil, nil, nil, ""
+	return e
+}
+
+func (t *Tracker) receiver(app *cast.Application, volume *cast.Volume) []Event {
+	var newApp *App
+	if app != nil {
+		newApp = &App{ID: app.AppId, Name: app.DisplayName, IdleScreen: app.IsIdleScreen}
+	}
+	var newVolume *Volume
+	if volume != nil {
+		newVolume = &Volume{Level: volume.Level, Muted: volume.Muted}
+	}
+
+	if !t.observed {
+		t.observed = true
+		t.app, t.volume = newApp, newVolume
+		return nil
+	}
+
+	var events []Event
+	if appID(newApp) != appID(t.app) {
+		// Whatever the previous application was playing has gone with it.
+		if t.state != "" {
+			events = append(events, t.finish(""))
+		}
+		t.media = nil
+		t.app = newApp
+		events = append(events, t.event(EventAppChange))
+	} else {
+		t.app = newApp
+	}
+	if newVolume != nil && (t.volume == nil || *newVolume != *t.volume) {
+		t.volume = newVolume
+		events = append(events, t.event(EventVolumeChange))
+	}
+	return events
+}
+
+func (t *Tracker) mediaStatus(m *cast.Media) []Event {
+	if m == nil {
+		if t.state == "" {
+			return nil
+		}
+		return []Event{t.finish("")}
+	}
+
+	media := &Media{
+		PlayerState: m.PlayerState,
+		IdleReason:  m.IdleReason,
+		CurrentTime: m.CurrentTime,
+	}
+	// The media item is only sent when it changes.
+	if m.Media.ContentId != "" {
+		media.ContentID = m.Media.ContentId
+		media.ContentType = m.Media.ContentType
+		media.Title = m.Media.Metadata.Title
+		media.Artist = m.Media.Metadata.Artist
+		media.Duration = m.Media.Duration
+	} else if t.media != nil {
+		media.ContentID = t.media.ContentID
+		media.ContentType = t.media.ContentType
+		media.Title = t.media.Title
+		media.Artist = t.media.Artist
+		media.Duration = t.media.Duration
+	}
+	changed := t.media != nil && media.ContentID != t.media.ContentID
+	t.media = media
+
+	switch m.PlayerState {
+	case "PLAYING":
+		if t.state != EventMediaStart || changed {
+			t.state = EventMediaStart
+			return []Event{t.event(EventMediaStart)}
+		}
+	case "PAUSED":
+		if t.state != EventMediaPause {
+			t.state = EventMediaPause
+			return []Event{t.event(EventMediaPause)}
+		}
+	case "IDLE":
+		if t.state != "" {
+			return []Event{t.finish(m.IdleReason)}
+		}
+	}
+	// Buffering doesn't change whether the media is playing or paused.
+	return nil
+}
+
+// finish returns the event for the media finishing, for the reason given
+// by the device, ie: FINISHED, CANCELLED, INTERRUPTED or ERROR.
+func (t *Tracker) finish(reason string) Event {
+	t.state = ""
+	e := t.event(EventMediaFinish)
+	if e.Media == nil {
+		e.Media = &Media{}
+	}
+	e.Media.PlayerState = "IDLE"
+	e.Media.IdleReason = reason
+	return e
+}
+
+// event returns an event with a copy of the current state.
+func (t *Tracker) event(eventType string) Event {
+	e := Event{Type: eventType, Time: time.Now(), Device: t.device}
+	if t.app != nil {
+		app := *t.app
+		e.App = &app
+	}
+	if t.media != nil {
+		media := *t.media
+		e.Media = &media
+	}
+	if t.volume != nil {
+		volume := *t.volume
+		e.Volume = &volume
+	}
+	return e
+}
+
+func appID(app *App) string {
+	if app == nil {
+		return ""
+	}
+	return app.ID
+}
@@ -3,9 +3,11 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -15,7 +17,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"github.com/vishen/go-chromecast/application"
-	"github.com/vishen/go-chromecast/dns"
+	"github.com/vishen/go-chromecast/discovery"
 )
 
 type Handler struct {
@@ -23,6 +25,10 @@ type Handler struct {
 	apps map[string]application.App
 	mux  *http.ServeMux
 
+	// devices holds what is known about each connected device, keyed
+	// the same as apps, so they can be picked with a selector.
+	devices map[string]discovery.Device
+
 	verbose bool
 
 	autoconnectPeriod time.Duration
@@ -31,15 +37,36 @@ type Handler struct {
 	// autoupdatePeriodSec defines how frequently app.Update method is called in the background.
 	autoupdatePeriod time.Duration
 	autoupdateTicker *time.Ticker
+
+	// discoverySources are asked for devices, in order, before
+	// falling back to multicast dns.
+	discoverySources []discovery.Source
+
+	// inventory provides the aliases and tags used by device selectors.
+	inventory *discovery.Inventory
+
+	// sleepTimers are the running sleep timers, keyed by device uuid.
+	sleepTimers map[string]*application.SleepTimer
+
+	// volumeRamps are the volume ramps still running, keyed by the
+	// device's application.
+	volumeRamps map[application.App]*volumeRamp
+
+	// volumeConfig has the volume limits, steps and presets of devices.
+	volumeConfig *application.VolumeConfig
 }
 
 func NewHandler(verbose bool) *Handler {
 	handler := &Handler{
 		verbose: verbose,
 		apps:    map[string]application.App{},
+		devices: map[string]discovery.Device{},
 		mux:     http.NewServeMux(),
 		mu:      sync.Mutex{},
 
+		sleepTimers: map[string]*application.SleepTimer{},
+		volumeRamps: map[application.App]*volumeRamp{},
+
 		autoconnectPeriod: time.Duration(-1),
 		autoconnectTicker: nil,
 
@@ -92,6 +119,24 @@ func (h *Handler) AutoUpdate(period time.Duration) error {
 	return nil
 }
 
+// SetDiscoverySources sets the sources, in priority order, that are used to
+// find devices before falling back to multicast dns.
+func (h *Handler) SetDiscoverySources(sources ...discovery.Source) {
+	h.discoverySources = sources
+}
+
+// SetInventory sets the inventory whose aliases and tags can be used to
+// pick devices with the 'device' query parameter.
+func (h *Handler) SetInventory(inventory *discovery.Inventory) {
+	h.inventory = inventory
+}
+
+// SetVolumeConfig sets the volume limits, steps and presets of devices,
+// which apply to devices connected to after it is set.
+func (h *Handler) SetVolumeConfig(cfg *application.VolumeConfig) {
+	h.volumeConfig = cfg
+}
+
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.mux.ServeHTTP(w, r)
 }
@@ -103,8 +148,13 @@ func (h *Handler) Serve(addr string) error {
 
 func (h *Handler) registerHandlers() {
 	/*
+		Every endpoint taking 'uuid=<device_uuid>' also accepts
+		'device=<selector>', ie: 'device=tag:speakers', to act on all
+		matching connected devices at once.
+
 		GET /devices?wait=...&iface=...
 		POST /connect?uuid=<device_uuid>&addr=<device_addr>&port=<device_port>
+		POST /connect?device=<selector>&wait=...&iface=...
 		POST /connect-all?wait=...&iface=...
 		POST /disconnect?uuid=<device_uuid>
 		POST /disconnect-all
@@ -118,11 +168,17 @@ func (h *Handler) registerHandlers() {
 		POST /unmute?uuid=<device_uuid>
 		POST /stop?uuid=<device_uuid>
 		GET /volume?uuid=<device_uuid>
-		POST /volume?uuid=<device_uuid>&volume=<float>
+		POST /volume?uuid=<device_uuid>&volume=<float_or_preset>&over=<duration>
+		GET /rate?uuid=<device_uuid>
+		POST /rate?uuid=<device_uuid>&rate=<float>
 		POST /rewind?uuid=<device_uuid>&seconds=<int>
 		POST /seek?uuid=<device_uuid>&seconds=<int>
 		POST /seek-to?uuid=<device_uuid>&seconds=<float>
 		POST /load?uuid=<device_uuid>&path=<filepath_or_url>&content_type=<string>&start_time=<int>
+		POST /transfer?from=<device_uuid_or_selector>&to=<device_uuid_or_selector>&volume=<bool>
+		GET /sleep?uuid=<device_uuid>
+		POST /sleep?uuid=<device_uuid>&after=<duration>&fade=<duration>
+		DELETE /sleep?uuid=<device_uuid>
 	*/
 
 	h.mux.HandleFunc("/devices", h.listDevices)
@@ -140,10 +196,13 @@ func (h *Handler) registerHandlers() {
 	h.mux.HandleFunc("/unmute", h.unmute)
 	h.mux.HandleFunc("/stop", h.stop)
 	h.mux.HandleFunc("/volume", h.volume)
+	h.mux.HandleFunc("/rate", h.rate)
 	h.mux.HandleFunc("/rewind", h.rewind)
 	h.mux.HandleFunc("/seek", h.seek)
 	h.mux.HandleFunc("/seek-to", h.seekTo)
 	h.mux.HandleFunc("/load", h.load)
+	h.mux.HandleFunc("/transfer", h.transfer)
+	h.mux.HandleFunc("/sleep", h.sleep)
 }
 
 func (h *Handler) discoverDnsEntries(ctx context.Context, iface string, waitq string) (devices []device) {
@@ -163,10 +222,9 @@ func (h *Handler) discoverDnsEntries(ctx context.Context, iface string, waitq st
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, time.Duration(wait)*time.Second)
-	defer cancel()
-
-	devicesChan, err := dns.DiscoverCastDNSEntries(ctx, interf)
+	sources := append([]discovery.Source{}, h.discoverySources...)
+	sources = append(sources, discovery.NewMDNSSource(interf, time.Duration(wait)*time.Second))
+	devicesChan, err := discovery.Multi(sources...).Discover(ctx)
 	if err != nil {
 		h.log("error discovering entries: %v", err)
 		return
@@ -174,14 +232,14 @@ func (h *Handler) discoverDnsEntries(ctx context.Context, iface string, waitq st
 
 	for d := range devicesChan {
 		devices = append(devices, device{
-			Addr:       d.AddrV4.String(),
+			Addr:       d.Addr,
 			Port:       d.Port,
 			Name:       d.Name,
 			Host:       d.Host,
 			UUID:       d.UUID,
 			Device:     d.Device,
-			Status:     d.Status,
-			DeviceName: d.DeviceName,
+			DeviceName: d.Name,
+			Tags:       h.inventory.TagsFor(d),
 			InfoFields: d.InfoFields,
 		})
 	}
@@ -230,9 +288,14 @@ func (h *Handler) ConnectedDeviceUUIDs() []string {
 func (h *Handler) connect(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 
+	if selector := q.Get("device"); selector != "" && q.Get("uuid") == "" {
+		h.connectSelector(w, r, discovery.ParseSelector(selector))
+		return
+	}
+
 	deviceUUID := q.Get("uuid")
 	if deviceUUID == "" {
-		httpValidationError(w, "missing 'uuid' in query paramater")
+		httpValidationError(w, "missing 'uuid' or 'device' in query paramater")
 		return
 	}
 
@@ -245,6 +308,7 @@ func (h *Handler) connect(w http.ResponseWriter, r *http.Request) {
 	deviceAddr := q.Get("addr")
 	devicePort := q.Get("port")
 	deviceName := q.Get("name")
+	deviceModel := ""
 	iface := q.Get("interface")
 	wait := q.Get("wait")
 
@@ -260,6 +324,7 @@ func (h *Handler) connect(w http.ResponseWriter, r *http.Request) {
 				// we cast back to int a bit later.
 				devicePort = strconv.Itoa(device.Port)
 				deviceName = device.DeviceName
+				deviceModel = device.Device
 			}
 		}
 	}
@@ -278,7 +343,7 @@ func (h *Handler) connect(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	app, err := h.connectInternal(deviceAddr, devicePortI, deviceName)
+	app, err := h.connectInternal(deviceUUID, deviceAddr, devicePortI, deviceName)
 	if err != nil {
 		h.log("unable to start application: %v", err)
 		httpError(w, fmt.Errorf("unable to start application: %v", err))
@@ -286,6 +351,13 @@ func (h *Handler) connect(w http.ResponseWriter, r *http.Request) {
 	}
 	h.mu.Lock()
 	h.apps[deviceUUID] = app
+	h.devices[deviceUUID] = discovery.Device{
+		UUID:   deviceUUID,
+		Name:   deviceName,
+		Device: deviceModel,
+		Addr:   deviceAddr,
+		Port:   devicePortI,
+	}
 	h.mu.Unlock()
 
 	w.Header().Add("Content-Type", "application/json")
@@ -296,10 +368,80 @@ func (h *Handler) connect(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (h *Handler) connectInternal(deviceAddr string, devicePort int, deviceName string) (application.App, error) {
+// connectSelector connects to every discovered device matching the
+// selector that isn't already connected.
+func (h *Handler) connectSelector(w http.ResponseWriter, r *http.Request, selector discovery.Selector) {
+	q := r.URL.Query()
+	h.log("connecting to devices matching %q", selector)
+
+	var matched []discovery.Device
+	for _, d := range h.discoverDnsEntries(context.Background(), q.Get("interface"), q.Get("wait")) {
+		dd := d.discoveryDevice()
+		if h.inventory.Match(selector, dd) {
+			matched = append(matched, dd)
+		}
+	}
+	if len(matched) == 0 {
+		httpValidationError(w, "no devices found matching 'device'")
+		return
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		resp []connectResponse
+		errs []error
+	)
+	// The devices already connected are found before connecting to the
+	// rest, which add to resp from their own goroutines.
+	var connect []discovery.Device
+	for _, d := range matched {
+		if _, ok := h.app(d.UUID); ok {
+			resp = append(resp, connectResponse{DeviceUUID: d.UUID})
+			continue
+		}
+		connect = append(connect, d)
+	}
+	for _, d := range connect {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			app, err := h.connectInternal(d.UUID, d.Addr, d.Port, d.Name)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				h.log("unable to connect to %s:%d (%s): %v", d.Addr, d.Port, d.Name, err)
+				errs = append(errs, fmt.Errorf("%s: %w", d.Name, err))
+				return
+			}
+			h.mu.Lock()
+			h.apps[d.UUID] = app
+			h.devices[d.UUID] = d
+			h.mu.Unlock()
+			resp = append(resp, connectResponse{DeviceUUID: d.UUID})
+		}()
+	}
+	wg.Wait()
+
+	if len(resp) == 0 {
+		httpError(w, fmt.Errorf("unable to start application: %w", errors.Join(errs...)))
+		return
+	}
+	sort.Slice(resp, func(i, j int) bool { return resp[i].DeviceUUID < resp[j].DeviceUUID })
+
+	w.Header().Add("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.log("error encoding json: %v", err)
+		httpError(w, fmt.Errorf("unable to json encode devices: %v", err))
+		return
+	}
+}
+
+func (h *Handler) connectInternal(deviceUUID, deviceAddr string, devicePort int, deviceName string) (application.App, error) {
 	applicationOptions := []application.ApplicationOption{
 		application.WithDebug(h.verbose),
 		application.WithCacheDisabled(true),
+		application.WithVolumeSettings(h.volumeConfig.For(deviceUUID, deviceName)),
 	}
 	if deviceName != "" {
 		applicationOptions = append(applicationOptions, application.WithDeviceNameOverride(deviceName))
@@ -341,18 +483,22 @@ func (h *Handler) connectAll(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) connectAllInternal(iface string, waitSec string) error {
 	ctx := context.Background()
 	devices := h.discoverDnsEntries(ctx, iface, waitSec)
-	apps := make(chan *application.App, len(devices)+1)
+	type connected struct {
+		device device
+		app    application.App
+	}
+	apps := make(chan connected, len(devices)+1)
 	g, ctx := errgroup.WithContext(ctx)
 	for _, device := range devices {
 		g.Go(func() error {
 			log.Printf("Connecting to %s:%d (%s)", device.Addr, device.Port, device.DeviceName)
-			app, err := h.connectInternal(device.Addr, device.Port, device.DeviceName)
+			app, err := h.connectInternal(device.UUID, device.Addr, device.Port, device.DeviceName)
 			if err != nil {
 				log.Printf("Connection to %s:%d (%s) failed: %v", device.Addr, device.Port, device.DeviceName, err)
 				return err
 			}
 			log.Printf("Connected to %s:%d (%s)", device.Addr, device.Port, device.DeviceName)
-			apps <- &app
+			apps <- connected{device: device, app: app}
 			return nil
 		})
 	}
@@ -362,17 +508,21 @@ func (h *Handler) connectAllInternal(iface string, waitSec string) error {
 
 	// Even if we cannot connect to some of the devices, we still update the map for remaining devices.
 	uuidMap := map[string]application.App{}
-	for app := range apps {
-		info, err := (*app).Info()
+	deviceMap := map[string]discovery.Device{}
+	for c := range apps {
+		info, err := c.app.Info()
 		if err != nil {
-			log.Printf("Skipping device %v", app)
+			log.Printf("Skipping device %v", c.app)
 		} else {
-			uuidMap[strings.ReplaceAll(info.SsdpUdn, "-", "")] = *app
+			deviceUUID := strings.ReplaceAll(info.SsdpUdn, "-", "")
+			uuidMap[deviceUUID] = c.app
+			deviceMap[deviceUUID] = c.device.discoveryDevice()
 		}
 	}
 
 	h.mu.Lock()
 	h.apps = uuidMap
+	h.devices = deviceMap
 	h.mu.Unlock()
 	return err
 }
@@ -380,28 +530,29 @@ func (h *Handler) connectAllInternal(iface string, waitSec string) error {
 func (h *Handler) disconnect(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 
-	deviceUUID := q.Get("uuid")
-	if deviceUUID == "" {
-		httpValidationError(w, "missing 'uuid' in query paramater")
-		return
-	}
-
-	h.log("disconnecting device %s", deviceUUID)
-
-	app, ok := h.app(deviceUUID)
+	uuids, ok := h.uuidsForRequest(w, r)
 	if !ok {
-		httpValidationError(w, "device uuid is not connected")
 		return
 	}
 
 	stopMedia := q.Get("stop") == "true"
-	if err := app.Close(stopMedia); err != nil {
-		h.log("unable to close application: %v", err)
-	}
+	for _, deviceUUID := range uuids {
+		h.log("disconnecting device %s", deviceUUID)
 
-	h.mu.Lock()
-	delete(h.apps, deviceUUID)
-	h.mu.Unlock()
+		app, ok := h.app(deviceUUID)
+		if !ok {
+			continue
+		}
+		h.cancelSleep(deviceUUID)
+		if err := app.Close(stopMedia); err != nil {
+			h.log("unable to close application: %v", err)
+		}
+
+		h.mu.Lock()
+		delete(h.apps, deviceUUID)
+		delete(h.devices, deviceUUID)
+		h.mu.Unlock()
+	}
 }
 
 func (h *Handler) disconnectAll(w http.ResponseWriter, r *http.Request) {
@@ -413,41 +564,32 @@ func (h *Handler) disconnectAll(w http.ResponseWriter, r *http.Request) {
 			h.log("unable to close application %q: %v", deviceUUID, err)
 		}
 		delete(h.apps, deviceUUID)
+		delete(h.devices, deviceUUID)
 	}
 	h.mu.Unlock()
 }
 
 func (h *Handler) status(w http.ResponseWriter, r *http.Request) {
-	app, found := h.appForRequest(w, r)
+	apps, found := h.appsForRequest(w, r)
 	if !found {
 		return
 	}
 	h.log("status for device")
 	syncUpdate := r.URL.Query().Get("syncUpdate") == "true"
-	if syncUpdate {
-		if err := app.Update(); err != nil {
-			h.log("error updating  status: %v", err)
-			httpError(w, fmt.Errorf("error updating status: %w", err))
-			return
-		}
-	}
-	castApplication, castMedia, castVolume := app.Status()
-	info, err := app.Info()
+	statusResponses, err := h.collectStatuses(apps, syncUpdate)
 	if err != nil {
-		werr := fmt.Errorf("error getting device info: %v", err)
-		h.log("%v", werr)
-		httpError(w, werr)
+		h.log("%v", err)
+		httpError(w, err)
 		return
 	}
-	statusResponse := fromApplicationStatus(
-		info,
-		castApplication,
-		castMedia,
-		castVolume,
-	)
+
+	var resp interface{} = statusResponses
+	if uuid := r.URL.Query().Get("uuid"); uuid != "" {
+		resp = statusResponses[uuid]
+	}
 
 	w.Header().Add("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(statusResponse); err != nil {
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		h.log("error encoding json: %v", err)
 		httpError(w, fmt.Errorf("unable to json encode devices: %v", err))
 		return
@@ -479,47 +621,19 @@ func (h *Handler) statuses(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) statusAll(w http.ResponseWriter, r *http.Request) {
 	h.log("statuses for devices")
 	syncUpdate := r.URL.Query().Get("syncUpdate") == "true"
-	uuids := h.ConnectedDeviceUUIDs()
-	mapUUID2Ch := map[string]chan statusResponse{}
-	g := new(errgroup.Group)
-	for _, deviceUUID := range uuids {
-		app, ok := h.app(deviceUUID)
-		if ok {
-			ch := make(chan statusResponse, 1)
-			mapUUID2Ch[deviceUUID] = ch
-			g.Go(func() error {
-				if syncUpdate {
-					if err := app.Update(); err != nil {
-						return err
-					}
-				}
-				castApplication, castMedia, castVolume := app.Status()
-				info, err := app.Info()
-				if err != nil {
-					return fmt.Errorf("error getting device info: %v", err)
-				}
-				ch <- fromApplicationStatus(
-					info,
-					castApplication,
-					castMedia,
-					castVolume,
-				)
-				return nil
-			})
+	apps := map[string]application.App{}
+	for _, deviceUUID := range h.ConnectedDeviceUUIDs() {
+		if app, ok := h.app(deviceUUID); ok {
+			apps[deviceUUID] = app
 		}
 	}
-	if err := g.Wait(); err != nil {
+	statusResponses, err := h.collectStatuses(apps, syncUpdate)
+	if err != nil {
 		h.log("collecting statuses failed: %v", err)
 		httpError(w, fmt.Errorf("collecting statuses failed: %w", err))
 		return
 	}
 
-	statusResponses := map[string]statusResponse{}
-	for deviceUUID, ch := range mapUUID2Ch {
-		statusResponse := <-ch
-		statusResponses[deviceUUID] = statusResponse
-	}
-
 	w.Header().Add("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(statusResponses); err != nil {
 		h.log("error encoding json: %v", err)
@@ -528,104 +642,107 @@ func (h *Handler) statusAll(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (h *Handler) pause(w http.ResponseWriter, r *http.Request) {
-	app, found := h.appForRequest(w, r)
-	if !found {
-		return
+// collectStatuses concurrently gets the status for each of the apps,
+// keyed by device uuid.
+func (h *Handler) collectStatuses(apps map[string]application.App, syncUpdate bool) (map[string]statusResponse, error) {
+	mapUUID2Ch := map[string]chan statusResponse{}
+	g := new(errgroup.Group)
+	for deviceUUID, app := range apps {
+		ch := make(chan statusResponse, 1)
+		mapUUID2Ch[deviceUUID] = ch
+		g.Go(func() error {
+			if syncUpdate {
+				if err := app.Update(); err != nil {
+					return fmt.Errorf("error updating status: %w", err)
+				}
+			}
+			castApplication, castMedia, castVolume := app.Status()
+			info, err := app.Info()
+			if err != nil {
+				return fmt.Errorf("error getting device info: %v", err)
+			}
+			ch <- fromApplicationStatus(
+				info,
+				castApplication,
+				castMedia,
+				castVolume,
+			)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
 	}
-	h.log("pausing device")
 
-	if err := app.Pause(); err != nil {
-		h.log("unable to pause device: %v", err)
-		httpError(w, fmt.Errorf("unable to pause device: %w", err))
-		return
+	statusResponses := map[string]statusResponse{}
+	for deviceUUID, ch := range mapUUID2Ch {
+		statusResponses[deviceUUID] = <-ch
 	}
+	return statusResponses, nil
+}
+
+func (h *Handler) pause(w http.ResponseWriter, r *http.Request) {
+	h.log("pausing device")
+	h.forEachApp(w, r, "pause", func(app application.App) error {
+		return app.Pause()
+	})
 }
 
 func (h *Handler) unpause(w http.ResponseWriter, r *http.Request) {
-	app, found := h.appForRequest(w, r)
-	if !found {
-		return
-	}
 	h.log("unpausing device")
-
-	if err := app.Unpause(); err != nil {
-		h.log("unable to unpause device: %v", err)
-		httpError(w, fmt.Errorf("unable to unpause device: %w", err))
-		return
-	}
+	h.forEachApp(w, r, "unpause", func(app application.App) error {
+		return app.Unpause()
+	})
 }
 
 func (h *Handler) skipad(w http.ResponseWriter, r *http.Request) {
-	app, found := h.appForRequest(w, r)
-	if !found {
-		h.log("handlers.go.skipad: !found for skipad")
-		return
-	}
-
 	h.log("skipping ad")
-
-	if err := app.Skipad(); err != nil {
-		h.log("unable to skip ad for: %v", err)
-		httpError(w, fmt.Errorf("unable to skip ad for: %w", err))
-		return
-	}
+	h.forEachApp(w, r, "skip ad for", func(app application.App) error {
+		return app.Skipad()
+	})
 }
 
 func (h *Handler) mute(w http.ResponseWriter, r *http.Request) {
-	app, found := h.appForRequest(w, r)
-	if !found {
-		return
-	}
 	h.log("muting device")
-
-	if err := app.SetMuted(true); err != nil {
-		h.log("unable to mute device: %v", err)
-		httpError(w, fmt.Errorf("unable to mute device: %w", err))
-		return
-	}
+	h.forEachApp(w, r, "mute", func(app application.App) error {
+		return app.SetMuted(true)
+	})
 }
 
 func (h *Handler) unmute(w http.ResponseWriter, r *http.Request) {
-	app, found := h.appForRequest(w, r)
-	if !found {
-		return
-	}
 	h.log("unmuting device")
-
-	if err := app.SetMuted(false); err != nil {
-		h.log("unable to unmute device: %v", err)
-		httpError(w, fmt.Errorf("unable to unmute device: %w", err))
-		return
-	}
+	h.forEachApp(w, r, "unmute", func(app application.App) error {
+		return app.SetMuted(false)
+	})
 }
 
 func (h *Handler) stop(w http.ResponseWriter, r *http.Request) {
-	app, found := h.appForRequest(w, r)
-	if !found {
-		return
-	}
 	h.log("stopping device")
-
-	if err := app.Stop(); err != nil {
-		h.log("unable to stop device: %v", err)
-		httpError(w, fmt.Errorf("unable to stop device: %w", err))
-		return
-	}
+	h.forEachApp(w, r, "stop", func(app application.App) error {
+		return app.Stop()
+	})
 }
 
 func (h *Handler) volume(w http.ResponseWriter, r *http.Request) {
-	app, found := h.appForRequest(w, r)
-	if !found {
-		return
-	}
-
 	if r.Method == "GET" {
+		apps, found := h.appsForRequest(w, r)
+		if !found {
+			return
+		}
 		h.log("getting volume for device")
-		_, _, volume := app.Status()
+
+		volumes := map[string]volumeResponse{}
+		for deviceUUID, app := range apps {
+			_, _, volume := app.Status()
+			volumes[deviceUUID] = volumeResponse{Level: volume.Level, Muted: volume.Muted}
+		}
+		var resp interface{} = volumes
+		if uuid := r.URL.Query().Get("uuid"); uuid != "" {
+			resp = volumes[uuid]
+		}
 
 		w.Header().Add("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(volumeResponse{Level: volume.Level, Muted: volume.Muted}); err != nil {
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
 			h.log("error encoding json: %v", err)
 			httpError(w, fmt.Errorf("unable to json encode devices: %v", err))
 		}
@@ -641,26 +758,144 @@ func (h *Handler) volume(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	value, err := strconv.ParseFloat(volume, 32)
-	if err != nil {
-		h.log("volume %q is not a number: %v", volume, err)
-		httpValidationError(w, "'volume' is not a number")
-		return
+	var over time.Duration
+	if o := q.Get("over"); o != "" {
+		var err error
+		if over, err = time.ParseDuration(o); err != nil || over < 0 {
+			h.log("over %q is not a duration: %v", o, err)
+			httpValidationError(w, "'over' is not a duration")
+			return
+		}
 	}
 
-	if err := app.SetVolume(float32(value)); err != nil {
-		h.log("unable to set device volume: %v", err)
-		httpError(w, fmt.Errorf("unable to set device volume: %w", err))
+	if !h.validVolume(r, volume) {
+		h.log("volume %q is not a number or a preset", volume)
+		httpValidationError(w, "'volume' is not a number between 0 and 1 or a preset")
 		return
 	}
+
+	h.forEachApp(w, r, "set volume for", func(app application.App) error {
+		// Presets can be different for each device.
+		level, err := app.VolumeSettings().Level(volume)
+		if err != nil {
+			return err
+		}
+		if over == 0 {
+			return app.SetVolume(level)
+		}
+		_, _, current := app.Status()
+		if current == nil {
+			return fmt.Errorf("unable to get the volume of the device")
+		}
+		h.rampVolume(app, current.Level, level, over)
+		return nil
+	})
 }
 
-func (h *Handler) rewind(w http.ResponseWriter, r *http.Request) {
-	app, found := h.appForRequest(w, r)
-	if !found {
+// validVolume reports whether volume is a number between 0 and 1, or a
+// preset of one of the devices in the request.
+func (h *Handler) validVolume(r *http.Request, volume string) bool {
+	if level, err := strconv.ParseFloat(volume, 32); err == nil {
+		return level >= 0 && level <= 1
+	}
+	uuids := []string{r.URL.Query().Get("uuid")}
+	if uuids[0] == "" {
+		uuids = h.matchingUUIDs(discovery.ParseSelector(r.URL.Query().Get("device")))
+	}
+	for _, deviceUUID := range uuids {
+		if app, ok := h.app(deviceUUID); ok {
+			if _, err := app.VolumeSettings().Level(volume); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// volumeRamp is a volume change running in the background.
+type volumeRamp struct {
+	cancel context.CancelFunc
+}
+
+// rampVolume changes the volume of the device gradually in the
+// background, so the request doesn't wait for it. A new ramp replaces
+// the one the device already has.
+func (h *Handler) rampVolume(app application.App, from, to float32, over time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ramp := &volumeRamp{cancel: cancel}
+
+	h.mu.Lock()
+	if previous, ok := h.volumeRamps[app]; ok {
+		previous.cancel()
+	}
+	h.volumeRamps[app] = ramp
+	h.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		if err := application.FadeVolume(ctx, app, from, to, over); err != nil && !errors.Is(err, context.Canceled) {
+			h.log("unable to change volume gradually: %v", err)
+		}
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if h.volumeRamps[app] == ramp {
+			delete(h.volumeRamps, app)
+		}
+	}()
+}
+
+func (h *Handler) rate(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "GET" {
+		apps, found := h.appsForRequest(w, r)
+		if !found {
+			return
+		}
+		h.log("getting playback rate for device")
+
+		rates := map[string]rateResponse{}
+		for deviceUUID, app := range apps {
+			_, media, _ := app.Status()
+			rates[deviceUUID] = rateResponse{Rate: application.MediaPlaybackRate(media)}
+		}
+		var resp interface{} = rates
+		if uuid := r.URL.Query().Get("uuid"); uuid != "" {
+			resp = rates[uuid]
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			h.log("error encoding json: %v", err)
+			httpError(w, fmt.Errorf("unable to json encode devices: %v", err))
+		}
 		return
 	}
 
+	h.log("setting playback rate for device")
+
+	q := r.URL.Query()
+	rate := q.Get("rate")
+	if rate == "" {
+		httpValidationError(w, "missing 'rate' in query paramater")
+		return
+	}
+	value, err := application.ParsePlaybackRate(rate)
+	if err != nil {
+		h.log("rate %q is not a playback rate: %v", rate, err)
+		httpValidationError(w, "'rate' is not a playback rate between 0.5 and 2")
+		return
+	}
+
+	h.forEachApp(w, r, "set playback rate for", func(app application.App) error {
+		// The media may have changed since the device was connected to.
+		if err := app.Update(); err != nil {
+			return err
+		}
+		return app.SetPlaybackRate(value)
+	})
+}
+
+func (h *Handler) rewind(w http.ResponseWriter, r *http.Request) {
 	h.log("rewinding device")
 
 	q := r.URL.Query()
@@ -677,19 +912,12 @@ func (h *Handler) rewind(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := app.Seek(-value); err != nil {
-		h.log("unable to rewind device: %v", err)
-		httpError(w, fmt.Errorf("unable to rewind device: %w", err))
-		return
-	}
+	h.forEachApp(w, r, "rewind", func(app application.App) error {
+		return app.Seek(-value)
+	})
 }
 
 func (h *Handler) seek(w http.ResponseWriter, r *http.Request) {
-	app, found := h.appForRequest(w, r)
-	if !found {
-		return
-	}
-
 	h.log("seeking device")
 
 	q := r.URL.Query()
@@ -706,19 +934,12 @@ func (h *Handler) seek(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := app.Seek(value); err != nil {
-		h.log("unable to seek device: %v", err)
-		httpError(w, fmt.Errorf("unable to seek device: %w", err))
-		return
-	}
+	h.forEachApp(w, r, "seek", func(app application.App) error {
+		return app.Seek(value)
+	})
 }
 
 func (h *Handler) seekTo(w http.ResponseWriter, r *http.Request) {
-	app, found := h.appForRequest(w, r)
-	if !found {
-		return
-	}
-
 	h.log("seeking-to device")
 
 	q := r.URL.Query()
@@ -735,19 +956,12 @@ func (h *Handler) seekTo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := app.SeekToTime(float32(value)); err != nil {
-		h.log("unable to seek-to device: %v", err)
-		httpError(w, fmt.Errorf("unable to seek-to device: %w", err))
-		return
-	}
+	h.forEachApp(w, r, "seek-to", func(app application.App) error {
+		return app.SeekToTime(float32(value))
+	})
 }
 
 func (h *Handler) load(w http.ResponseWriter, r *http.Request) {
-	app, found := h.appForRequest(w, r)
-	if !found {
-		return
-	}
-
 	h.log("loading media for device")
 
 	q := r.URL.Query()
@@ -772,33 +986,297 @@ func (h *Handler) load(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := app.Load(path, startTimeInt, contentType, true, true, true); err != nil {
-		h.log("unable to load media for device: %v", err)
-		httpError(w, fmt.Errorf("unable to load media for device: %w", err))
+	h.forEachApp(w, r, "load media for", func(app application.App) error {
+		return app.Load(path, startTimeInt, contentType, true, true, true)
+	})
+}
+
+func (h *Handler) transfer(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	h.log("transferring media between devices")
+
+	from, err := h.connectedApp(q.Get("from"))
+	if err != nil {
+		httpValidationError(w, fmt.Sprintf("'from': %v", err))
+		return
+	}
+	to, err := h.connectedApp(q.Get("to"))
+	if err != nil {
+		httpValidationError(w, fmt.Sprintf("'to': %v", err))
+		return
+	}
+	if from == to {
+		httpValidationError(w, "'from' and 'to' are the same device")
+		return
+	}
+
+	// The handler keeps running, so any media served by the new device
+	// is served for as long as it needs to be.
+	if _, err := application.Transfer(from, to, q.Get("volume") == "true"); err != nil {
+		h.log("unable to transfer media: %v", err)
+		httpError(w, fmt.Errorf("unable to transfer media: %w", err))
+		return
+	}
+}
+
+// connectedApp returns the connected device with the uuid, or the single
+// connected device picked by the selector.
+func (h *Handler) connectedApp(uuidOrSelector string) (application.App, error) {
+	if uuidOrSelector == "" {
+		return nil, errors.New("missing device uuid or selector")
+	}
+	if app, ok := h.app(uuidOrSelector); ok {
+		return app, nil
+	}
+
+	uuids := h.matchingUUIDs(discovery.ParseSelector(uuidOrSelector))
+	switch len(uuids) {
+	case 0:
+		return nil, errors.New("no connected devices match")
+	case 1:
+		app, _ := h.app(uuids[0])
+		return app, nil
+	}
+	return nil, fmt.Errorf("%d connected devices match, only one can be used", len(uuids))
+}
+
+// sleep starts, cancels or lists the sleep timers of devices. Starting a
+// timer replaces any timer the device already has.
+func (h *Handler) sleep(w http.ResponseWriter, r *http.Request) {
+	uuids, ok := h.uuidsForRequest(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		h.log("getting sleep timers for device")
+	case "DELETE":
+		h.log("cancelling sleep timers for device")
+		for _, deviceUUID := range uuids {
+			h.cancelSleep(deviceUUID)
+		}
+		return
+	default:
+		h.log("starting sleep timers for device")
+		q := r.URL.Query()
+		after, err := time.ParseDuration(q.Get("after"))
+		if err != nil || after <= 0 {
+			httpValidationError(w, "'after' is not a duration, ie: 30m")
+			return
+		}
+		fade := 2 * time.Minute
+		if f := q.Get("fade"); f != "" {
+			if fade, err = time.ParseDuration(f); err != nil || fade < 0 {
+				httpValidationError(w, "'fade' is not a duration, ie: 2m")
+				return
+			}
+		}
+		if err := application.CheckSleepTimer(after, fade); err != nil {
+			httpValidationError(w, err.Error())
+			return
+		}
+		// StartSleepTimer only fails for times CheckSleepTimer rejects, so
+		// a request that fails leaves every device with the timer it
+		// already had. That timer is cancelled before the new one starts,
+		// so the volume it may be fading is put back before the new timer
+		// reads it.
+		var started []string
+		for _, deviceUUID := range uuids {
+			app, ok := h.app(deviceUUID)
+			if !ok {
+				continue
+			}
+			h.cancelSleep(deviceUUID)
+			timer, err := application.StartSleepTimer(app, after, fade)
+			if err != nil {
+				for _, deviceUUID := range started {
+					h.cancelSleep(deviceUUID)
+				}
+				h.log("unable to start sleep timer for device %s: %v", deviceUUID, err)
+				httpError(w, fmt.Errorf("unable to start sleep timer: %w", err))
+				return
+			}
+			h.mu.Lock()
+			h.sleepTimers[deviceUUID] = timer
+			h.mu.Unlock()
+			go h.waitSleep(deviceUUID, timer)
+			started = append(started, deviceUUID)
+		}
+	}
+
+	timers := map[string]sleepResponse{}
+	h.mu.Lock()
+	for _, deviceUUID := range uuids {
+		if timer, ok := h.sleepTimers[deviceUUID]; ok {
+			timers[deviceUUID] = fromSleepTimer(timer)
+		}
+	}
+	h.mu.Unlock()
+
+	var resp interface{} = timers
+	if uuid := r.URL.Query().Get("uuid"); uuid != "" {
+		timer, ok := timers[uuid]
+		if !ok {
+			http.Error(w, "device has no sleep timer", http.StatusNotFound)
+			return
+		}
+		resp = timer
+	}
+	w.Header().Add("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.log("error encoding json: %v", err)
+	}
+}
+
+// waitSleep forgets the sleep timer of a device once it has finished.
+func (h *Handler) waitSleep(deviceUUID string, timer *application.SleepTimer) {
+	<-timer.Done()
+	h.mu.Lock()
+	if h.sleepTimers[deviceUUID] == timer {
+		delete(h.sleepTimers, deviceUUID)
+	}
+	h.mu.Unlock()
+	if err := timer.Err(); err != nil && !errors.Is(err, context.Canceled) {
+		h.log("sleep timer for device %s finished: %v", deviceUUID, err)
+	}
+}
+
+// cancelSleep cancels the sleep timer of a device, if it has one.
+func (h *Handler) cancelSleep(deviceUUID string) {
+	h.mu.Lock()
+	timer, ok := h.sleepTimers[deviceUUID]
+	delete(h.sleepTimers, deviceUUID)
+	h.mu.Unlock()
+	if ok {
+		timer.Cancel()
+	}
+}
+
+// forEachApp runs fn concurrently against every device picked by the
+// request. A request using 'uuid' gets the same response as it always
+// has, while one using a 'device' selector gets a json list with the
+// result for each device.
+func (h *Handler) forEachApp(w http.ResponseWriter, r *http.Request, action string, fn func(application.App) error) {
+	apps, found := h.appsForRequest(w, r)
+	if !found {
+		return
+	}
+
+	results := make([]deviceResult, 0, len(apps))
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for deviceUUID, app := range apps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := deviceResult{DeviceUUID: deviceUUID}
+			if err := fn(app); err != nil {
+				h.log("unable to %s device %s: %v", action, deviceUUID, err)
+				result.Error = fmt.Sprintf("unable to %s device: %v", action, err)
+			}
+			mu.Lock()
+			results = append(results, result)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	sort.Slice(results, func(i, j int) bool { return results[i].DeviceUUID < results[j].DeviceUUID })
+
+	failed := false
+	for _, result := range results {
+		if result.Error != "" {
+			failed = true
+		}
+	}
+
+	if r.URL.Query().Get("uuid") != "" {
+		if failed {
+			httpError(w, errors.New(results[0].Error))
+		}
 		return
 	}
+
+	w.Header().Add("Content-Type", "application/json")
+	if failed {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	if err := json.NewEncoder(w).Encode(results); err != nil {
+		h.log("error encoding json: %v", err)
+	}
 }
 
-func (h *Handler) appForRequest(w http.ResponseWriter, r *http.Request) (application.App, bool) {
+// uuidsForRequest returns the uuids of the connected devices picked by
+// either the 'uuid' or the 'device' query parameter.
+func (h *Handler) uuidsForRequest(w http.ResponseWriter, r *http.Request) ([]string, bool) {
 	q := r.URL.Query()
 
-	deviceUUID := q.Get("uuid")
-	if deviceUUID == "" {
-		httpValidationError(w, "missing 'uuid' in query params")
+	if deviceUUID := q.Get("uuid"); deviceUUID != "" {
+		if _, ok := h.app(deviceUUID); !ok {
+			httpValidationError(w, "device uuid is not connected")
+			return nil, false
+		}
+		return []string{deviceUUID}, true
+	}
+
+	selector := q.Get("device")
+	if selector == "" {
+		httpValidationError(w, "missing 'uuid' or 'device' in query params")
+		return nil, false
+	}
+
+	uuids := h.matchingUUIDs(discovery.ParseSelector(selector))
+	if len(uuids) == 0 {
+		httpValidationError(w, "no connected devices match 'device'")
 		return nil, false
 	}
+	return uuids, true
+}
 
-	app, ok := h.app(deviceUUID)
+// matchingUUIDs returns the sorted uuids of the connected devices picked
+// by the selector.
+func (h *Handler) matchingUUIDs(sel discovery.Selector) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var uuids []string
+	for deviceUUID, d := range h.devices {
+		if h.inventory.Match(sel, d) {
+			uuids = append(uuids, deviceUUID)
+		}
+	}
+	sort.Strings(uuids)
+	return uuids
+}
+
+// appsForRequest returns the updated applications for the devices picked
+// by the request, keyed by device uuid.
+func (h *Handler) appsForRequest(w http.ResponseWriter, r *http.Request) (map[string]application.App, bool) {
+	uuids, ok := h.uuidsForRequest(w, r)
 	if !ok {
-		httpValidationError(w, "device uuid is not connected")
 		return nil, false
 	}
 
-	if err := app.Update(); err != nil {
+	apps := map[string]application.App{}
+	g := new(errgroup.Group)
+	for _, deviceUUID := range uuids {
+		app, ok := h.app(deviceUUID)
+		if !ok {
+			httpValidationError(w, "device uuid is not connected")
+			return nil, false
+		}
+		apps[deviceUUID] = app
+		g.Go(app.Update)
+	}
+	if err := g.Wait(); err != nil {
+		h.log("unable to update device: %v", err)
+		httpError(w, fmt.Errorf("unable to update device: %w", err))
 		return nil, false
 	}
 
-	return app, true
+	return apps, true
 }
 
 func (h *Handler) log(msg string, args ...interface{}) {
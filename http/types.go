@@ -1,16 +1,46 @@
 package http
 
-import "github.com/vishen/go-chromecast/cast"
+import (
+	"time"
+
+	"github.com/vishen/go-chromecast/application"
+	"github.com/vishen/go-chromecast/cast"
+	"github.com/vishen/go-chromecast/discovery"
+)
 
 type connectResponse struct {
 	DeviceUUID string `json:"device_uuid"`
 }
 
+type deviceResult struct {
+	DeviceUUID string `json:"device_uuid"`
+	Error      string `json:"error,omitempty"`
+}
+
 type volumeResponse struct {
 	Level float32 `json:"level"`
 	Muted bool    `json:"muted"`
 }
 
+type rateResponse struct {
+	// Rate is the playback rate of the media, 0 when there is no media.
+	Rate float32 `json:"rate"`
+}
+
+type sleepResponse struct {
+	Ends             time.Time `json:"ends"`
+	RemainingSeconds float64   `json:"remaining_seconds"`
+	FadeSeconds      float64   `json:"fade_seconds"`
+}
+
+func fromSleepTimer(timer *application.SleepTimer) sleepResponse {
+	return sleepResponse{
+		Ends:             timer.Ends(),
+		RemainingSeconds: timer.Remaining().Seconds(),
+		FadeSeconds:      timer.Fade().Seconds(),
+	}
+}
+
 type statusResponse struct {
 	Info   *cast.DeviceInfo  `json:"info,omitempty"`
 	App    *cast.Application `json:"app,omitempty"`
@@ -51,5 +81,19 @@ type device struct {
 	Device     string            `json:"device_type"`
 	Status     string            `json:"status"`
 	DeviceName string            `json:"device_name"`
+	Tags       []string          `json:"tags,omitempty"`
 	InfoFields map[string]string `json:"info_fields"`
 }
+
+func (d device) discoveryDevice() discovery.Device {
+	return discovery.Device{
+		UUID:       d.UUID,
+		Name:       d.DeviceName,
+		Device:     d.Device,
+		Addr:       d.Addr,
+		Port:       d.Port,
+		Host:       d.Host,
+		Tags:       d.Tags,
+		InfoFields: d.InfoFields,
+	}
+}
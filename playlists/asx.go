@@ -0,0 +1,108 @@
+package playlists
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseASX parses Advanced Stream Redirector playlists, see
+// https://en.wikipedia.org/wiki/Advanced_Stream_Redirector. ASX looks like
+// XML, but its elements and attributes are case-insensitive and it often
+// isn't well-formed, so it is read token by token rather than unmarshalled.
+// Each <entry> is played from its first <ref>, and an <entryref> is added
+// as an entry of its own.
+func parseASX(uri string, content []byte) (Iterator, error) {
+	d := xml.NewDecoder(bytes.NewReader(content))
+	d.Strict = false
+	d.AutoClose = []string{"ref", "entryref", "duration", "param"}
+
+	var (
+		entries []Entry
+		entry   *Entry
+		text    strings.Builder
+	)
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			// Keep the entries read before any malformed content.
+			if err != io.EOF && len(entries) == 0 {
+				return nil, fmt.Errorf("failed to parse file %v: %w", uri, err)
+			}
+			break
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			text.Reset()
+			switch strings.ToLower(tok.Name.Local) {
+			case "entry":
+				entry = &Entry{}
+			case "entryref":
+				if href := asxAttr(tok, "href"); href != "" {
+					entries = append(entries, Entry{URL: resolve(uri, href)})
+				}
+			case "ref":
+				if href := asxAttr(tok, "href"); entry != nil && entry.URL == "" {
+					entry.URL = resolve(uri, href)
+				}
+			case "duration":
+				if entry != nil {
+					entry.Duration = clock(asxAttr(tok, "value"))
+				}
+			case "param":
+				if name := asxAttr(tok, "name"); entry != nil && name != "" {
+					if entry.Attributes == nil {
+						entry.Attributes = map[string]string{}
+					}
+					entry.Attributes[name] = asxAttr(tok, "value")
+				}
+			}
+		case xml.CharData:
+			text.Write(tok)
+		case xml.EndElement:
+			switch strings.ToLower(tok.Name.Local) {
+			case "title":
+				if entry != nil {
+					entry.Title = strings.TrimSpace(text.String())
+				}
+			case "author":
+				if entry != nil {
+					entry.Artist = strings.TrimSpace(text.String())
+				}
+			case "entry":
+				if entry != nil && entry.URL != "" {
+					entries = append(entries, *entry)
+				}
+				entry = nil
+			}
+		}
+	}
+	return &entryIterator{entries: entries}, nil
+}
+
+// asxAttr returns the value of the attribute, ignoring its case.
+func asxAttr(el xml.StartElement, name string) string {
+	for _, a := range el.Attr {
+		if strings.EqualFold(a.Name.Local, name) {
+			return strings.TrimSpace(a.Value)
+		}
+	}
+	return ""
+}
+
+// clock parses a [[hh:]mm:]ss[.fff] duration.
+func clock(value string) time.Duration {
+	var d time.Duration
+	for _, part := range strings.Split(value, ":") {
+		s, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || s < 0 {
+			return 0
+		}
+		d = d*60 + time.Duration(s*float64(time.Second))
+	}
+	return d
+}
@@ -0,0 +1,273 @@
+package playlists
+
+import (
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dhowden/tag"
+)
+
+// The orders the media in a directory can be sorted in.
+const (
+	// SortNatural sorts by the numbers in the file names, so that
+	// '2 b.mp3' comes before '10 a.mp3'.
+	SortNatural = "natural"
+	// SortName sorts by the file names.
+	SortName = "name"
+	// SortModified sorts by when the files were last modified, oldest
+	// first.
+	SortModified = "mtime"
+	// SortTrack sorts by the disc and track numbers in the tags of the
+	// files, falling back to SortNatural.
+	SortTrack = "track"
+	// SortRandom shuffles the files.
+	SortRandom = "random"
+)
+
+// SortStrategies are the orders the media in a directory can be sorted in.
+var SortStrategies = []string{SortNatural, SortName, SortModified, SortTrack, SortRandom}
+
+// DirOptions configures how the media in a directory is listed.
+type DirOptions struct {
+	// Recursive lists the media in subdirectories too, other than hidden
+	// ones. Media in each directory is listed together.
+	Recursive bool
+	// Include and Exclude are glob patterns matched against both the file
+	// name and the path relative to the directory, ie: '*.flac' or
+	// 'Season 1/*'. When there are include patterns only the files that
+	// match one are listed. Excluded directories are skipped.
+	Include []string
+	Exclude []string
+	// Sort is one of SortStrategies, defaulting to SortNatural.
+	Sort string
+	// Seed seeds the order of SortRandom, so the same seed gives the
+	// same order.
+	Seed int64
+	// Playable filters the files to those that can be played, nil lists
+	// every file.
+	Playable func(filename string) bool
+}
+
+type dirFile struct {
+	path    string
+	dir     string
+	name    string
+	modTime time.Time
+	numbers []int
+	disc    int
+	track   int
+}
+
+// ListDir returns the media files in dir in the order they should play.
+func ListDir(dir string, opts DirOptions) ([]string, error) {
+	if opts.Sort == "" {
+		opts.Sort = SortNatural
+	}
+	if !contains(SortStrategies, opts.Sort) {
+		return nil, fmt.Errorf("unknown sort %q, expected one of %s", opts.Sort, strings.Join(SortStrategies, ", "))
+	}
+	for _, pattern := range append(append([]string{}, opts.Include...), opts.Exclude...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	if info, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("unable to find %q: %w", dir, err)
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("%q is not a directory", dir)
+	}
+
+	var files []dirFile
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("unable to list files from %q: %w", path, err)
+		}
+		if path == dir {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if !opts.Recursive || strings.HasPrefix(d.Name(), ".") || matchAny(opts.Exclude, d.Name(), rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if matchAny(opts.Exclude, d.Name(), rel) || (len(opts.Include) > 0 && !matchAny(opts.Include, d.Name(), rel)) {
+			return nil
+		}
+		// Follow symlinks, but only to files.
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		if opts.Playable != nil && !opts.Playable(path) {
+			return nil
+		}
+		files = append(files, dirFile{
+			path:    path,
+			dir:     filepath.Dir(rel),
+			name:    d.Name(),
+			modTime: info.ModTime(),
+			numbers: numbers(strings.TrimSuffix(d.Name(), filepath.Ext(d.Name()))),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(files, func(i, j int) bool { return naturalLess(files[i], files[j]) })
+	switch opts.Sort {
+	case SortName:
+		sort.SliceStable(files, func(i, j int) bool { return files[i].dir+"/"+files[i].name < files[j].dir+"/"+files[j].name })
+	case SortModified:
+		sort.SliceStable(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	case SortTrack:
+		for i := range files {
+			files[i].disc, files[i].track = trackNumber(files[i].path)
+		}
+		sort.SliceStable(files, func(i, j int) bool {
+			a, b := files[i], files[j]
+			switch {
+			case a.dir != b.dir:
+				return dirLess(a.dir, b.dir)
+			case a.track == 0 || b.track == 0:
+				// Untagged files go after tagged ones.
+				return a.track != 0 && b.track == 0
+			case a.disc != b.disc:
+				return a.disc < b.disc
+			}
+			return a.track < b.track
+		})
+	case SortRandom:
+		r := rand.New(rand.NewSource(opts.Seed))
+		r.Shuffle(len(files), func(i, j int) { files[i], files[j] = files[j], files[i] })
+	}
+
+	filenames := make([]string, len(files))
+	for i, f := range files {
+		filenames[i] = f.path
+	}
+	return filenames, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAny returns whether the name or relative path matches any of the
+// patterns.
+func matchAny(patterns []string, name, rel string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(filepath.ToSlash(pattern), rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// numbers returns the numbers in a name, in the order they appear. File
+// names should be passed without their extension, ie: the 3 of mp3.
+func numbers(name string) []int {
+	foundNum := false
+	numPos := 0
+	foundNumbers := []int{}
+	for i, c := range name {
+		if c < '0' || c > '9' {
+			if foundNum {
+				val, _ := strconv.Atoi(name[numPos:i])
+				foundNumbers = append(foundNumbers, val)
+			}
+			foundNum = false
+			continue
+		}
+
+		if !foundNum {
+			numPos = i
+			foundNum = true
+		}
+	}
+	if foundNum {
+		val, _ := strconv.Atoi(name[numPos:])
+		foundNumbers = append(foundNumbers, val)
+	}
+	return foundNumbers
+}
+
+// numbersLess compares the numbers in two file names. Files without
+// numbers go after those with them.
+func numbersLess(iNum, jNum []int) (less, equal bool) {
+	if len(iNum) == 0 || len(jNum) == 0 {
+		return len(iNum) != 0, len(iNum) == len(jNum)
+	}
+	for vi := 0; vi < len(iNum) || vi < len(jNum); vi++ {
+		if len(iNum) <= vi {
+			return false, false
+		}
+		if len(jNum) <= vi {
+			return true, false
+		}
+		if iNum[vi] != jNum[vi] {
+			return iNum[vi] < jNum[vi], false
+		}
+	}
+	return false, true
+}
+
+// dirLess orders directories, the top directory first and then by the
+// numbers in their names.
+func dirLess(a, b string) bool {
+	if a == "." || b == "." {
+		return a == "." && b != "."
+	}
+	if less, equal := numbersLess(numbers(a), numbers(b)); !equal {
+		return less
+	}
+	return a < b
+}
+
+func naturalLess(a, b dirFile) bool {
+	if a.dir != b.dir {
+		return dirLess(a.dir, b.dir)
+	}
+	if less, equal := numbersLess(a.numbers, b.numbers); !equal {
+		return less
+	}
+	return a.name < b.name
+}
+
+// trackNumber returns the disc and track number from the tags of the file,
+// or zero if it doesn't have them.
+func trackNumber(filename string) (disc, track int) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return 0, 0
+	}
+	defer f.Close()
+	m, err := tag.ReadFrom(f)
+	if err != nil {
+		return 0, 0
+	}
+	track, _ = m.Track()
+	disc, _ = m.Disc()
+	return disc, track
+}
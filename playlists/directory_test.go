@@ -0,0 +1,150 @@
+package playlists
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// id3 returns an ID3v2.3 tag with just a track number.
+func id3(track string) []byte {
+	frame := append([]byte("TRCK"), 0, 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(frame[4:8], uint32(len(track)+1))
+	frame = append(frame, track...)
+	size := len(frame)
+	header := []byte{'I', 'D', '3', 3, 0, 0, byte(size >> 21 & 0x7f), byte(size >> 14 & 0x7f), byte(size >> 7 & 0x7f), byte(size & 0x7f)}
+	return append(header, frame...)
+}
+
+func testLibrary(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"10 ten.mp3":              nil,
+		"2 two.mp3":               nil,
+		"intro.mp3":               nil,
+		"cover.jpg":               nil,
+		"Season 2/s02e01.mp4":     nil,
+		"Season 10/s10e01.mp4":    nil,
+		"Season 1/s01e10.mp4":     nil,
+		"Season 1/s01e02.mp4":     nil,
+		"Season 1/Extras/bts.mp4": nil,
+		".hidden/secret.mp3":      nil,
+		"Album/b.mp3":             id3("1/2"),
+		"Album/a.mp3":             id3("2/2"),
+		"Album/bonus.mp3":         nil,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func listDir(t *testing.T, dir string, opts DirOptions) []string {
+	t.Helper()
+	filenames, err := ListDir(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, f := range filenames {
+		rel, err := filepath.Rel(dir, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		filenames[i] = filepath.ToSlash(rel)
+	}
+	return filenames
+}
+
+func TestListDir(t *testing.T) {
+	dir := testLibrary(t)
+	notImages := func(filename string) bool { return filepath.Ext(filename) != ".jpg" }
+
+	tests := []struct {
+		name string
+		opts DirOptions
+		want []string
+	}{
+		{
+			name: "natural",
+			opts: DirOptions{Playable: notImages},
+			want: []string{"2 two.mp3", "10 ten.mp3", "intro.mp3"},
+		},
+		{
+			name: "name",
+			opts: DirOptions{Sort: SortName},
+			want: []string{"10 ten.mp3", "2 two.mp3", "cover.jpg", "intro.mp3"},
+		},
+		{
+			name: "recursive",
+			opts: DirOptions{Recursive: true, Include: []string{"*.mp4"}, Exclude: []string{"Extras"}},
+			want: []string{"Season 1/s01e02.mp4", "Season 1/s01e10.mp4", "Season 2/s02e01.mp4", "Season 10/s10e01.mp4"},
+		},
+		{
+			name: "relative path patterns",
+			opts: DirOptions{Recursive: true, Include: []string{"Season 1/*"}},
+			want: []string{"Season 1/s01e02.mp4", "Season 1/s01e10.mp4"},
+		},
+		{
+			name: "track",
+			opts: DirOptions{Recursive: true, Include: []string{"Album/*"}, Sort: SortTrack},
+			want: []string{"Album/b.mp3", "Album/a.mp3", "Album/bonus.mp3"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if have := listDir(t, dir, test.opts); !reflect.DeepEqual(have, test.want) {
+				t.Fatalf("have %q want %q", have, test.want)
+			}
+		})
+	}
+}
+
+func TestListDirModified(t *testing.T) {
+	dir := testLibrary(t)
+	now := time.Now()
+	for i, name := range []string{"intro.mp3", "10 ten.mp3", "2 two.mp3"} {
+		modified := now.Add(time.Duration(i) * time.Hour)
+		if err := os.Chtimes(filepath.Join(dir, name), modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"intro.mp3", "10 ten.mp3", "2 two.mp3"}
+	if have := listDir(t, dir, DirOptions{Sort: SortModified, Include: []string{"*.mp3"}}); !reflect.DeepEqual(have, want) {
+		t.Fatalf("have %q want %q", have, want)
+	}
+}
+
+func TestListDirRandom(t *testing.T) {
+	dir := testLibrary(t)
+	opts := DirOptions{Recursive: true, Sort: SortRandom, Seed: 42}
+	first := listDir(t, dir, opts)
+	if len(first) != 12 {
+		t.Fatalf("have %d files want 12", len(first))
+	}
+	if again := listDir(t, dir, opts); !reflect.DeepEqual(first, again) {
+		t.Fatalf("the same seed gave a different order, %q and %q", first, again)
+	}
+}
+
+func TestListDirErrors(t *testing.T) {
+	dir := testLibrary(t)
+	if _, err := ListDir(dir, DirOptions{Sort: "size"}); err == nil {
+		t.Fatal("expected an error for an unknown sort")
+	}
+	if _, err := ListDir(dir, DirOptions{Include: []string{"[a-"}}); err == nil {
+		t.Fatal("expected an error for an invalid pattern")
+	}
+	if _, err := ListDir(filepath.Join(dir, "intro.mp3"), DirOptions{}); err == nil {
+		t.Fatal("expected an error listing a file")
+	}
+}
@@ -0,0 +1,139 @@
+package playlists
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// hlsTags are tags that only appear in HLS manifests.
+var hlsTags = []string{
+	"#EXT-X-TARGETDURATION",
+	"#EXT-X-STREAM-INF",
+	"#EXT-X-MEDIA-SEQUENCE",
+	"#EXT-X-VERSION",
+	"#EXT-X-PLAYLIST-TYPE",
+	"#EXT-X-KEY",
+	"#EXT-X-MAP",
+}
+
+// extinfAttr matches the key="value" attributes of an #EXTINF line.
+var extinfAttr = regexp.MustCompile(`([A-Za-z0-9_-]+)="([^"]*)"`)
+
+// IsHLS returns whether the content of an m3u playlist is an HLS manifest.
+func IsHLS(content []byte) bool {
+	for _, tag := range hlsTags {
+		if bytes.Contains(content, []byte(tag)) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseM3U parses m3u-files.
+// https://docs.fileformat.com/audio/m3u/:
+//
+// There is no official specification for the M3U file format, it is a de-facto standard.
+// M3U is a plain text file that uses the .m3u extension if the text is encoded
+// in the local system’s default non-Unicode encoding or with the .m3u8 extension
+// if the text is UTF-8 encoded. Each entry in the M3U file can be one of the following:
+//
+//   - Absolute path to the file
+//   - File path relative to the M3U file.
+//   - URL
+//
+// In the extended M3U, additional directives are introduced that begin
+// with “#” and end with a colon(:) if they have parameters. #EXTINF
+// describes the entry that follows it, while #EXTART, #EXTALB, #EXTIMG
+// and #EXTGRP apply to every following entry until they are repeated.
+func parseM3U(uri string, content []byte) (Iterator, error) {
+	if IsHLS(content) {
+		return nil, fmt.Errorf("%v: %w", uri, ErrHLSManifest)
+	}
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+	text := string(content)
+	if !utf8.Valid(content) && extension(uri) != ".m3u8" {
+		// Assume the local encoding is latin-1, whose bytes are the
+		// first 256 code points.
+		runes := make([]rune, len(content))
+		for i, b := range content {
+			runes[i] = rune(b)
+		}
+		text = string(runes)
+	}
+
+	var (
+		entries []Entry
+		info    Entry
+		shared  Entry
+	)
+	// convert windows linebreaks, and split
+	for _, l := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		l = strings.TrimSpace(l)
+		directive, value, _ := strings.Cut(l, ":")
+		switch {
+		case len(l) == 0:
+		case directive == "#EXTINF":
+			info = parseExtinf(value)
+		case directive == "#EXTART":
+			shared.Artist = strings.TrimSpace(value)
+		case directive == "#EXTALB":
+			shared.Album = strings.TrimSpace(value)
+		case directive == "#EXTIMG":
+			shared.Image = resolve(uri, strings.TrimSpace(value))
+		case directive == "#EXTGRP":
+			shared.Group = strings.TrimSpace(value)
+		case strings.HasPrefix(l, "#"):
+			// Unsupported directives and comments.
+		default:
+			e := info
+			e.URL = resolve(uri, l)
+			if e.Artist == "" {
+				e.Artist = shared.Artist
+			}
+			if e.Album == "" {
+				e.Album = shared.Album
+			}
+			if e.Image == "" {
+				e.Image = shared.Image
+			}
+			if e.Group == "" {
+				e.Group = shared.Group
+			}
+			entries = append(entries, e)
+			info = Entry{}
+		}
+	}
+	return &entryIterator{entries: entries}, nil
+}
+
+// parseExtinf parses the value of an #EXTINF line, the duration in
+// seconds, any attributes and then the title after a comma:
+//
+//	#EXTINF:123 tvg-logo="logo.png" group-title="Rock",Artist - Title
+func parseExtinf(value string) Entry {
+	// The title starts after the first comma that isn't quoted.
+	info, title := value, ""
+	quoted := false
+	for i, r := range value {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ',' && !quoted {
+			info, title = value[:i], value[i+1:]
+			break
+		}
+	}
+	e := Entry{Title: strings.TrimSpace(title)}
+	duration, attrs, _ := strings.Cut(strings.TrimSpace(info), " ")
+	e.Duration = seconds(duration)
+	for _, m := range extinfAttr.FindAllStringSubmatch(attrs, -1) {
+		if e.Attributes == nil {
+			e.Attributes = map[string]string{}
+		}
+		e.Attributes[m[1]] = m[2]
+	}
+	e.Group = e.Attributes["group-title"]
+	return e
+}
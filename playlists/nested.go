@@ -0,0 +1,81 @@
+package playlists
+
+import (
+	"errors"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// MaxDepth is how deep playlists can be nested in other playlists.
+var MaxDepth = 5
+
+// expand returns the entries of the playlist, replacing the entries that
+// are playlists with their entries. chain is the locations of the
+// playlists being expanded, outermost first, and is used to detect
+// playlists that include themselves. Remote .m3u8 entries are kept as
+// they are rather than fetched, they are almost always HLS streams, ie: the
+// channels of an IPTV list. Other entries that can't be expanded are
+// logged and skipped.
+func expand(it Iterator, chain []string) []Entry {
+	playlist := chain[len(chain)-1]
+	var entries []Entry
+	for it.HasNext() {
+		e := it.Next()
+		// A remote playlist can't be used to read or serve local files.
+		if isRemote(playlist) && !hasScheme(e.URL) {
+			log.WithField("package", "playlists").Warnf("skipping local file %q in remote playlist %q", e.URL, playlist)
+			continue
+		}
+		if !IsPlaylist(e.URL) || (isRemote(e.URL) && MaybeHLS(e.URL)) {
+			entries = append(entries, e)
+			continue
+		}
+		nested, err := expandNested(e, chain)
+		if errors.Is(err, ErrHLSManifest) {
+			entries = append(entries, e)
+			continue
+		} else if err != nil {
+			log.WithField("package", "playlists").WithError(err).Warnf("skipping playlist %q in %q", e.URL, playlist)
+			continue
+		}
+		entries = append(entries, nested...)
+	}
+	return entries
+}
+
+// expandNested returns the entries of the playlist e refers to. Entries
+// without a title are given the title of e, as directories of radio
+// stations often only have titles for the stations.
+func expandNested(e Entry, chain []string) ([]Entry, error) {
+	uri, err := playlistURI(e.URL)
+	if err != nil {
+		return nil, err
+	}
+	if depth(chain) >= MaxDepth {
+		return nil, fmt.Errorf("playlists are nested more than %d deep", MaxDepth)
+	}
+	if contains(chain, uri) {
+		return nil, errors.New("playlist includes itself")
+	}
+	it, location, err := parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	if contains(chain, location) {
+		return nil, errors.New("playlist includes itself")
+	}
+	entries := expand(it, append(chain[:len(chain):len(chain)], uri, location))
+	for i := range entries {
+		if entries[i].Title == "" {
+			entries[i].Title = e.Title
+		}
+	}
+	return entries, nil
+}
+
+// depth returns how many playlists are in the chain, each playlist is in
+// it by its uri and its location after redirects.
+func depth(chain []string) int {
+	return len(chain) / 2
+}
@@ -0,0 +1,110 @@
+package playlists
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func playlistServer(t *testing.T, playlists map[string]string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	for path, content := range playlists {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, content)
+		})
+	}
+	mux.HandleFunc("/moved.pls", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/radio/jazz.pls", http.StatusFound)
+	})
+	mux.HandleFunc("/deep/", func(w http.ResponseWriter, r *http.Request) {
+		var n int
+		fmt.Sscanf(strings.TrimPrefix(r.URL.Path, "/deep/"), "%d.m3u", &n)
+		fmt.Fprintf(w, "http://example.com/%d.mp3\n%d.m3u\n", n, n+1)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestNestedPlaylists(t *testing.T) {
+	srv := playlistServer(t, map[string]string{
+		"/stations.m3u":   "#EXTM3U\n#EXTINF:-1,Jazz\n/moved.pls\n#EXTINF:-1,Rock\nrock.m3u?sid=1\nself.m3u\nhttp://example.com/direct.mp3\n",
+		"/radio/jazz.pls": "[playlist]\nFile1=jazz-high.mp3\nTitle1=Jazz High\nFile2=jazz-low.mp3\n",
+		"/rock.m3u":       "http://rock.example.com/stream\n/rock/backup\nfile:///etc/passwd\n",
+		"/self.m3u":       "self.m3u\nstations.m3u\n",
+	})
+
+	want := []Entry{
+		// Relative entries are resolved against the redirected location.
+		{URL: srv.URL + "/radio/jazz-high.mp3", Title: "Jazz High"},
+		{URL: srv.URL + "/radio/jazz-low.mp3", Title: "Jazz"},
+		{URL: "http://rock.example.com/stream", Title: "Rock"},
+		// Absolute paths are on the same server, and local files are
+		// skipped.
+		{URL: srv.URL + "/rock/backup", Title: "Rock"},
+		{URL: "http://example.com/direct.mp3"},
+	}
+	testEntries(t, srv.URL+"/stations.m3u", want)
+}
+
+func TestNestedPlaylistsDepth(t *testing.T) {
+	srv := playlistServer(t, nil)
+	it, err := NewIterator(srv.URL + "/deep/1.m3u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var have []string
+	for it.HasNext() {
+		have = append(have, it.Next().URL)
+	}
+	if len(have) != MaxDepth {
+		t.Fatalf("have %d entries %q want %d", len(have), have, MaxDepth)
+	}
+}
+
+func TestNestedHLSManifest(t *testing.T) {
+	srv := playlistServer(t, map[string]string{
+		"/channels.m3u":   "#EXTM3U\n#EXTINF:-1,News\nlive/news.m3u8\n#EXTINF:-1,Sport\nlive/sport.m3u8\n",
+		"/live/news.m3u8": "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-STREAM-INF:BANDWIDTH=1280000\nlow.m3u8\n",
+	})
+	// Streams that can't be fetched from here are kept, the device may
+	// still be able to play them.
+	testEntries(t, srv.URL+"/channels.m3u", []Entry{
+		{URL: srv.URL + "/live/news.m3u8", Title: "News"},
+		{URL: srv.URL + "/live/sport.m3u8", Title: "Sport"},
+	})
+}
+
+func TestFetchResource(t *testing.T) {
+	defer func(timeout time.Duration, size int64, redirects int) {
+		FetchTimeout, MaxFetchSize, MaxRedirects = timeout, size, redirects
+	}(FetchTimeout, MaxFetchSize, MaxRedirects)
+	FetchTimeout, MaxFetchSize, MaxRedirects = 100*time.Millisecond, 16, 1
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/small.m3u", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "a.mp3\n") })
+	mux.HandleFunc("/large.m3u", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, strings.Repeat("a.mp3\n", 10)) })
+	mux.HandleFunc("/slow.m3u", func(w http.ResponseWriter, r *http.Request) { time.Sleep(300 * time.Millisecond) })
+	mux.HandleFunc("/missing.m3u", http.NotFound)
+	mux.HandleFunc("/one", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/two", http.StatusFound) })
+	mux.HandleFunc("/two", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/small.m3u", http.StatusFound) })
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	if content, err := FetchResource(srv.URL + "/small.m3u"); err != nil || string(content) != "a.mp3\n" {
+		t.Fatalf("have %q, %v", content, err)
+	}
+	for _, path := range []string{"/large.m3u", "/slow.m3u", "/missing.m3u", "/one"} {
+		if _, err := FetchResource(srv.URL + path); err == nil {
+			t.Errorf("expected an error fetching %s", path)
+		}
+	}
+	MaxRedirects = 3
+	if content, err := FetchResource(srv.URL + "/one"); err != nil || string(content) != "a.mp3\n" {
+		t.Fatalf("have %q, %v", content, err)
+	}
+}
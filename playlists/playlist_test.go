@@ -1,9 +1,14 @@
 package playlists
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestParsePLSFormat(t *testing.T) {
@@ -23,7 +28,7 @@ func TestParsePLSFormat(t *testing.T) {
 	} else {
 		path = fmt.Sprintf("file://%v", abs)
 	}
-	it, err := newPLSIterator(path)
+	it, err := NewIterator(path)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -31,12 +36,15 @@ func TestParsePLSFormat(t *testing.T) {
 		if !it.HasNext() {
 			t.Fatal("iterator exhausted")
 		}
-		url, title := it.Next()
-		if url != want.url {
-			t.Fatalf("test %d, have url %v want %v", i, url, want.url)
+		entry := it.Next()
+		if entry.URL != want.url {
+			t.Fatalf("test %d, have url %v want %v", i, entry.URL, want.url)
 		}
-		if title != want.title {
-			t.Fatalf("test %d, have title %v want %v", i, title, want.title)
+		if entry.Title != want.title {
+			t.Fatalf("test %d, have title %v want %v", i, entry.Title, want.title)
+		}
+		if entry.Duration != 0 {
+			t.Fatalf("test %d, have duration %v want 0", i, entry.Duration)
 		}
 	}
 	if it.HasNext() {
@@ -49,11 +57,11 @@ func TestParseM3UFormat(t *testing.T) {
 		url   string
 		title string
 	}{
-		{"http://ice1.somafm.com/indiepop-128-aac", ""},
-		{"http://ice4.somafm.com/indiepop-128-aac", ""},
-		{"http://ice2.somafm.com/indiepop-128-aac", ""},
-		{"http://ice6.somafm.com/indiepop-128-aac", ""},
-		{"http://ice5.somafm.com/indiepop-128-aac", ""},
+		{"http://ice1.somafm.com/indiepop-128-aac", "SomaFM - Indie Pop Rocks!"},
+		{"http://ice4.somafm.com/indiepop-128-aac", "SomaFM - Indie Pop Rocks!"},
+		{"http://ice2.somafm.com/indiepop-128-aac", "SomaFM - Indie Pop Rocks!"},
+		{"http://ice6.somafm.com/indiepop-128-aac", "SomaFM - Indie Pop Rocks!"},
+		{"http://ice5.somafm.com/indiepop-128-aac", "SomaFM - Indie Pop Rocks!"},
 	}
 	var path string
 	if abs, err := filepath.Abs(filepath.Join("testdata", "indiepop130.m3u")); err != nil {
@@ -61,7 +69,7 @@ func TestParseM3UFormat(t *testing.T) {
 	} else {
 		path = fmt.Sprintf("file://%v", abs)
 	}
-	it, err := newM3UIterator(path)
+	it, err := NewIterator(path)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -69,15 +77,259 @@ func TestParseM3UFormat(t *testing.T) {
 		if !it.HasNext() {
 			t.Fatal("iterator exhausted")
 		}
-		url, title := it.Next()
-		if url != want.url {
-			t.Fatalf("test %d, have url %v want %v", i, url, want.url)
+		entry := it.Next()
+		if entry.URL != want.url {
+			t.Fatalf("test %d, have url %v want %v", i, entry.URL, want.url)
+		}
+		if entry.Title != want.title {
+			t.Fatalf("test %d, have title %v want %v", i, entry.Title, want.title)
 		}
-		if title != want.title {
-			t.Fatalf("test %d, have title %v want %v", i, title, want.title)
+		if entry.Duration != 0 {
+			t.Fatalf("test %d, have duration %v want 0", i, entry.Duration)
 		}
 	}
 	if it.HasNext() {
 		t.Fatal("expected exhausted iterator")
 	}
 }
+
+func TestParseExtendedM3U(t *testing.T) {
+	dir, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{
+		{
+			URL:      filepath.Join(dir, "01 Opening Track.flac"),
+			Title:    "The Band - Opening Track",
+			Duration: 215 * time.Second,
+			Artist:   "The Band",
+			Album:    "First Album",
+			Image:    filepath.Join(dir, "covers", "first.jpg"),
+		},
+		{
+			URL:        filepath.Join(filepath.Dir(dir), "other", "02 Second.mp3"),
+			Title:      "The Band - Ünïcödé",
+			Duration:   187500 * time.Millisecond,
+			Artist:     "The Band",
+			Album:      "First Album",
+			Image:      filepath.Join(dir, "covers", "first.jpg"),
+			Group:      "Singles, B-sides",
+			Attributes: map[string]string{"tvg-id": "two", "group-title": "Singles, B-sides"},
+		},
+		{
+			URL:    "http://radio.example.com/live",
+			Title:  "Live Stream",
+			Artist: "The Band",
+			Album:  "Second Album",
+			Image:  filepath.Join(dir, "covers", "first.jpg"),
+			Group:  "Live",
+		},
+		{
+			URL:    filepath.FromSlash("/music/absolute.ogg"),
+			Artist: "The Band",
+			Album:  "Second Album",
+			Image:  filepath.Join(dir, "covers", "first.jpg"),
+			Group:  "Live",
+		},
+		{
+			URL:    filepath.FromSlash("/music/with space.mp3"),
+			Artist: "The Band",
+			Album:  "Second Album",
+			Image:  filepath.Join(dir, "covers", "first.jpg"),
+			Group:  "Live",
+		},
+	}
+	// A path to a local playlist is accepted as well as a file:// url.
+	it, err := NewIterator(filepath.Join("testdata", "extended.m3u8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var have []Entry
+	for it.HasNext() {
+		have = append(have, it.Next())
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Fatalf("have entries\n%+v\nwant\n%+v", have, want)
+	}
+}
+
+func TestParseRemoteM3U(t *testing.T) {
+	srv := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	defer srv.Close()
+
+	it, err := NewIterator(srv.URL + "/extended.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		srv.URL + "/01%20Opening%20Track.flac",
+		srv.URL + "/other/02%20Second.mp3",
+		"http://radio.example.com/live",
+		srv.URL + "/music/absolute.ogg",
+		// The file:// url is skipped, remote playlists can't refer to
+		// local files.
+	}
+	for i, url := range want {
+		if !it.HasNext() {
+			t.Fatal("iterator exhausted")
+		}
+		entry := it.Next()
+		if entry.URL != url {
+			t.Fatalf("test %d, have url %v want %v", i, entry.URL, url)
+		}
+		if i == 0 && entry.Image != srv.URL+"/covers/first.jpg" {
+			t.Fatalf("have image %v want %v", entry.Image, srv.URL+"/covers/first.jpg")
+		}
+	}
+	if it.HasNext() {
+		t.Fatal("expected exhausted iterator")
+	}
+}
+
+func TestParseLatin1M3U(t *testing.T) {
+	it, err := NewIterator(filepath.Join("testdata", "latin1.m3u"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !it.HasNext() {
+		t.Fatal("iterator exhausted")
+	}
+	if entry := it.Next(); entry.Title != "Café del Mar" || entry.Duration != 2*time.Minute {
+		t.Fatalf("have title %q and duration %v", entry.Title, entry.Duration)
+	}
+}
+
+func TestHLSManifest(t *testing.T) {
+	if _, err := NewIterator(filepath.Join("testdata", "hls.m3u8")); !errors.Is(err, ErrHLSManifest) {
+		t.Fatalf("have error %v want %v", err, ErrHLSManifest)
+	}
+	for path, want := range map[string]bool{
+		"http://example.com/live/news.m3u8?token=abc": true,
+		"testdata/extended.M3U8":                      true,
+		"http://example.com/channels.m3u":             false,
+	} {
+		if have := MaybeHLS(path); have != want {
+			t.Errorf("MaybeHLS(%q) is %v, want %v", path, have, want)
+		}
+	}
+}
+
+func TestParseXSPF(t *testing.T) {
+	dir, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{
+		{
+			URL:        filepath.Join(dir, "music", "Artist One", "01 First.flac"),
+			Title:      "First",
+			Duration:   241 * time.Second,
+			Artist:     "Artist One",
+			Album:      "Debut",
+			Image:      filepath.Join(dir, "covers", "debut.jpg"),
+			Attributes: map[string]string{"annotation": "Remastered"},
+		},
+		{
+			URL:    "http://music.example.com/stream/2.mp3",
+			Title:  "Second & Last",
+			Artist: "Artist Two",
+		},
+	}
+	testEntries(t, filepath.Join("testdata", "library.xspf"), want)
+}
+
+func TestParseASX(t *testing.T) {
+	dir, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{
+		{
+			URL:        "http://stream.example.com/high?format=mp3&bitrate=128",
+			Title:      "Example Radio High Quality",
+			Artist:     "Example Broadcasting",
+			Attributes: map[string]string{"genre": "Jazz"},
+		},
+		{
+			URL:      filepath.Join(dir, "jingles", "intro.mp3"),
+			Title:    "Jingle",
+			Duration: 90500 * time.Millisecond,
+		},
+		// The <entryref> is replaced by the entries of the playlist.
+		{
+			URL: "http://stream.example.com/more",
+		},
+	}
+	testEntries(t, filepath.Join("testdata", "radio.asx"), want)
+}
+
+func TestParseWPL(t *testing.T) {
+	dir, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{
+		{
+			URL:      filepath.Join(filepath.Dir(dir), "Music", "Band", "01 Dance.mp3"),
+			Title:    "Dance",
+			Duration: 198 * time.Second,
+			Artist:   "Band Feat. Singer",
+			Album:    "Hits",
+		},
+		{
+			URL:    filepath.Join(dir, "Music", "02 Slow.wma"),
+			Title:  "Slow",
+			Artist: "Band",
+			Album:  "Hits",
+		},
+		{
+			URL: "http://music.example.com/03.mp3",
+		},
+	}
+	testEntries(t, filepath.Join("testdata", "party.wpl"), want)
+}
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"[playlist]\nFile1=a.mp3", "pls"},
+		{"\xef\xbb\xbf#EXTM3U\na.mp3", "m3u"},
+		{"a.mp3\nb.mp3", ""},
+		{`<?xml version="1.0"?><playlist xmlns="http://xspf.org/ns/0/"/>`, "xspf"},
+		{`<ASX version="3.0"><Entry><Ref href="a"></Entry></ASX>`, "asx"},
+		{`<?wpl version="1.0"?><smil><body/></smil>`, "wpl"},
+		{`<html><body/></html>`, ""},
+	}
+	for _, test := range tests {
+		if have := Sniff([]byte(test.content)); have != test.want {
+			t.Errorf("sniff %q, have %q want %q", test.content, have, test.want)
+		}
+	}
+
+	// The content decides the format when the extension is wrong.
+	it, err := NewIterator(filepath.Join("testdata", "mislabelled.m3u"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry := it.Next(); entry.Title != "First" {
+		t.Fatalf("have title %q want %q", entry.Title, "First")
+	}
+}
+
+func testEntries(t *testing.T, path string, want []Entry) {
+	t.Helper()
+	it, err := NewIterator(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var have []Entry
+	for it.HasNext() {
+		have = append(have, it.Next())
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Fatalf("have entries\n%+v\nwant\n%+v", have, want)
+	}
+}
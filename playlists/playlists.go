@@ -1,32 +1,267 @@
 package playlists
 
 import (
+	"bytes"
+	"encoding/xml"
+	"errors"
 	"fmt"
-	"gopkg.in/ini.v1"
+	"net/url"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"gopkg.in/ini.v1"
 )
 
+// ErrHLSManifest is returned by NewIterator for an HLS manifest, which
+// shares the .m3u8 extension with UTF-8 m3u playlists, but is a single
+// stream that should be played as is.
+var ErrHLSManifest = errors.New("playlist is an HLS manifest")
+
+// Entry is an item in a playlist.
+type Entry struct {
+	// URL is the url of the item or, for local files, the path. Relative
+	// paths are resolved against the location of the playlist.
+	URL string
+	// Title is the display title of the item, if the playlist has one.
+	Title string
+	// Duration is the length of the item, zero if it is unknown or the
+	// item is a stream.
+	Duration time.Duration
+	Artist   string
+	Album    string
+	// Image is the url or path of artwork for the item.
+	Image string
+	Group string
+	// Attributes are any extra key value pairs the playlist has for the
+	// item, ie: the tvg-id="..." attributes of an #EXTINF line.
+	Attributes map[string]string
+}
+
 type Iterator interface {
 	HasNext() bool
-	Next() (file, title string)
+	Next() Entry
 }
 
+// parsers are the playlist formats by their name, each parses the content
+// of the playlist at uri.
+var parsers = map[string]func(uri string, content []byte) (Iterator, error){
+	"pls":  parsePLS,
+	"m3u":  parseM3U,
+	"xspf": parseXSPF,
+	"asx":  parseASX,
+	"wpl":  parseWPL,
+}
+
+// extensions are the playlist formats by their file extension.
+var extensions = map[string]string{
+	".pls":  "pls",
+	".m3u":  "m3u",
+	".m3u8": "m3u",
+	".xspf": "xspf",
+	".asx":  "asx",
+	".wax":  "asx",
+	".wvx":  "asx",
+	".wpl":  "wpl",
+}
+
+// IsPlaylist returns whether the path or url has the extension of a
+// playlist.
 func IsPlaylist(path string) bool {
-	ext := strings.ToLower(filepath.Ext(path))
-	return ext == ".m3u" || ext == ".pls"
+	_, ok := extensions[extension(path)]
+	return ok
+}
+
+// MaybeHLS returns whether the path could be an HLS manifest, which
+// shares the .m3u8 extension with UTF-8 m3u playlists.
+func MaybeHLS(path string) bool {
+	return extension(path) == ".m3u8"
+}
+
+// extension returns the lower case file extension of a path, or of the
+// path of a url ignoring its query.
+func extension(path string) string {
+	if u, err := url.Parse(path); err == nil && len(u.Scheme) > 1 {
+		path = u.Path
+	}
+	return strings.ToLower(filepath.Ext(path))
 }
 
-// NewPlaylistIterator creates an iterator for the given playlist.
+// NewPlaylistIterator creates an iterator for the given playlist. The uri
+// can be an HTTP url, a file:// url or a path to a local file. The format
+// is detected from the content of the playlist, falling back to the file
+// extension when the content isn't recognized.
+//
+// Entries that are playlists themselves are replaced by their entries,
+// up to MaxDepth playlists deep.
 func NewIterator(uri string) (Iterator, error) {
-	ext := strings.ToLower(filepath.Ext(uri))
-	switch ext {
-	case ".pls":
-		return newPLSIterator(uri)
-	case ".m3u":
-		return newM3UIterator(uri)
+	uri, err := playlistURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	it, location, err := parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	return &entryIterator{entries: expand(it, []string{uri, location})}, nil
+}
+
+// parse parses the playlist at uri, returning its location after any
+// redirects, which relative entries are resolved against.
+func parse(uri string) (Iterator, string, error) {
+	content, location, err := fetch(uri)
+	if err != nil {
+		return nil, uri, fmt.Errorf("failed to read file %v: %w", uri, err)
+	}
+	format := Sniff(content)
+	if format == "" {
+		format = extensions[extension(uri)]
+	}
+	if format == "" {
+		format = extensions[extension(location)]
+	}
+	parse, ok := parsers[format]
+	if !ok {
+		return nil, location, fmt.Errorf("'%v' is not a recognized playlist format", extension(uri))
+	}
+	it, err := parse(location, content)
+	return it, location, err
+}
+
+// Sniff returns the format of the playlist content, one of pls, m3u, xspf,
+// asx or wpl, or an empty string if it isn't recognized. Plain m3u
+// playlists, without the #EXTM3U header, can only be recognized by their
+// extension.
+func Sniff(content []byte) string {
+	content = bytes.TrimSpace(bytes.TrimPrefix(content, []byte("\xef\xbb\xbf")))
+	switch {
+	case hasPrefixFold(content, "[playlist]"):
+		return "pls"
+	case hasPrefixFold(content, "#EXTM3U"):
+		return "m3u"
+	case !bytes.HasPrefix(content, []byte("<")):
+		return ""
+	}
+	d := xml.NewDecoder(bytes.NewReader(content))
+	d.Strict = false
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return ""
+		}
+		if el, ok := tok.(xml.StartElement); ok {
+			switch strings.ToLower(el.Name.Local) {
+			case "playlist":
+				return "xspf"
+			case "asx":
+				return "asx"
+			case "smil":
+				return "wpl"
+			}
+			return ""
+		}
+	}
+}
+
+func hasPrefixFold(content []byte, prefix string) bool {
+	return len(content) >= len(prefix) && strings.EqualFold(string(content[:len(prefix)]), prefix)
+}
+
+// entryIterator iterates over the entries of a parsed playlist.
+type entryIterator struct {
+	index   int
+	entries []Entry
+}
+
+func (it *entryIterator) HasNext() bool {
+	return it.index < len(it.entries)
+}
+
+func (it *entryIterator) Next() Entry {
+	e := it.entries[it.index]
+	it.index++
+	return e
+}
+
+// playlistURI converts a path to a local playlist to a file:// url, so
+// that the entries in it can be resolved against its location.
+func playlistURI(uri string) (string, error) {
+	if hasScheme(uri) {
+		return uri, nil
 	}
-	return nil, fmt.Errorf("'%v' is not a recognized playlist format", ext)
+	abs, err := filepath.Abs(uri)
+	if err != nil {
+		return "", err
+	}
+	return "file://" + abs, nil
+}
+
+// hasScheme returns whether ref is a url rather than a path. Single letter
+// schemes are treated as windows drive letters.
+func hasScheme(ref string) bool {
+	u, err := url.Parse(ref)
+	return err == nil && len(u.Scheme) > 1
+}
+
+// resolve returns the location of ref, an entry in the playlist at base.
+// URLs are returned as they are, and file:// urls as paths. Relative paths
+// are resolved against the directory of a local playlist, or the url of a
+// remote one.
+func resolve(base, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	if filep := strings.TrimPrefix(ref, "file://"); filep != ref {
+		if p, err := url.PathUnescape(filep); err == nil {
+			return filepath.FromSlash(p)
+		}
+		return filepath.FromSlash(filep)
+	}
+	if hasScheme(ref) {
+		return ref
+	}
+	if dir := strings.TrimPrefix(base, "file://"); dir != base {
+		ref = filepath.FromSlash(ref)
+		if filepath.IsAbs(ref) {
+			return ref
+		}
+		return filepath.Join(filepath.Dir(dir), ref)
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	r, err := url.Parse(strings.ReplaceAll(ref, "\\", "/"))
+	if err != nil {
+		return ref
+	}
+	return b.ResolveReference(r).String()
+}
+
+// isRemote returns whether the uri is fetched over HTTP.
+func isRemote(uri string) bool {
+	return strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://")
+}
+
+// resolveURI is resolve for playlists whose entries are URIs rather than
+// paths, so relative references are percent-encoded.
+func resolveURI(base, ref string) string {
+	if !hasScheme(ref) {
+		if p, err := url.PathUnescape(ref); err == nil {
+			ref = p
+		}
+	}
+	return resolve(base, ref)
+}
+
+// seconds returns the duration of a playlist length in seconds, negative
+// lengths mean the length is unknown.
+func seconds(length string) time.Duration {
+	var s float64
+	if _, err := fmt.Sscanf(strings.TrimSpace(length), "%g", &s); err != nil || s <= 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
 }
 
 // plsIterator is an iterator for playlist-files.
@@ -34,15 +269,12 @@ func NewIterator(uri string) (Iterator, error) {
 // The format is case-sensitive and essentially that of an INI file.
 // It has entries on the form File1, Title1 etc.
 type plsIterator struct {
+	uri      string
 	count    int
 	playlist *ini.Section
 }
 
-func newPLSIterator(uri string) (*plsIterator, error) {
-	content, err := FetchResource(uri)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file %v: %w", uri, err)
-	}
+func parsePLS(uri string, content []byte) (Iterator, error) {
 	pls, err := ini.LoadSources(ini.LoadOptions{IgnoreInlineComment: true}, content)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse file %v: %w", uri, err)
@@ -52,6 +284,7 @@ func newPLSIterator(uri string) (*plsIterator, error) {
 		return nil, fmt.Errorf("failed to find playlist in .pls-file %v", uri)
 	}
 	return &plsIterator{
+		uri:      uri,
 		playlist: section,
 	}, nil
 }
@@ -60,63 +293,17 @@ func (it *plsIterator) HasNext() bool {
 	return it.playlist.HasKey(fmt.Sprintf("File%d", it.count+1))
 }
 
-func (it *plsIterator) Next() (file, title string) {
+func (it *plsIterator) Next() Entry {
+	var e Entry
 	if val := it.playlist.Key(fmt.Sprintf("File%d", it.count+1)); val != nil {
-		file = val.Value()
+		e.URL = resolve(it.uri, val.Value())
 	}
 	if val := it.playlist.Key(fmt.Sprintf("Title%d", it.count+1)); val != nil {
-		title = val.Value()
+		e.Title = val.Value()
 	}
-	it.count = it.count + 1
-	return file, title
-}
-
-// m3uIterator is an iterator for m3u-files.
-// https://docs.fileformat.com/audio/m3u/:
-//
-// There is no official specification for the M3U file format, it is a de-facto standard.
-// M3U is a plain text file that uses the .m3u extension if the text is encoded
-// in the local system’s default non-Unicode encoding or with the .m3u8 extension
-// if the text is UTF-8 encoded. Each entry in the M3U file can be one of the following:
-//
-//   - Absolute path to the file
-//   - File path relative to the M3U file.
-//   - URL
-//
-// In the extended M3U, additional directives are introduced that begin
-// with “#” and end with a colon(:) if they have parameters
-type m3uIterator struct {
-	index int
-	lines []string
-}
-
-func newM3UIterator(uri string) (*m3uIterator, error) {
-	content, err := FetchResource(uri)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file %v: %w", uri, err)
-	}
-	var lines []string
-	// convert windows linebreaks, and split
-	for _, l := range strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n") {
-		// This is a very simple m3u decoder, ignores all extended info
-		l = strings.TrimSpace(l)
-		if len(l) > 0 && !strings.HasPrefix(l, "#") {
-			lines = append(lines, l)
-		}
+	if val := it.playlist.Key(fmt.Sprintf("Length%d", it.count+1)); val != nil {
+		e.Duration = seconds(val.Value())
 	}
-	return &m3uIterator{
-		index: 0,
-		lines: lines,
-	}, nil
-}
-
-func (it *m3uIterator) HasNext() bool {
-	return it.index < len(it.lines)
-}
-
-func (it *m3uIterator) Next() (file, title string) {
-	file = it.lines[it.index]
-	title = "" // Todo?
-	it.index++
-	return file, title
+	it.count = it.count + 1
+	return e
 }
@@ -1,26 +1,74 @@
 package playlists
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"strings"
+	"time"
+)
+
+var (
+	// FetchTimeout is how long fetching a remote playlist can take,
+	// including any redirects.
+	FetchTimeout = 15 * time.Second
+	// MaxFetchSize is the largest playlist that will be read.
+	MaxFetchSize int64 = 4 << 20
+	// MaxRedirects is how many redirects fetching a remote playlist stops
+	// after, counting the same way as net/http.
+	MaxRedirects = 10
 )
 
 // FetchResource fetches the given url and returns the response body. The url can either
 // be an HTTP url or a file:// url.
 func FetchResource(url string) ([]byte, error) {
+	content, _, err := fetch(url)
+	return content, err
+}
+
+// fetch returns the content at the url, and the url it was fetched from
+// after following any redirects.
+func fetch(url string) ([]byte, string, error) {
 	if filep := strings.TrimPrefix(url, "file://"); filep != url {
-		return os.ReadFile(filep)
+		f, err := os.Open(filep)
+		if err != nil {
+			return nil, url, err
+		}
+		defer f.Close()
+		content, err := readLimited(f)
+		return content, url, err
+	}
+	client := &http.Client{
+		Timeout: FetchTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= MaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", MaxRedirects)
+			}
+			return nil
+		},
 	}
-	res, err := http.Get(url)
+	res, err := client.Get(url)
 	if err != nil {
-		return nil, err
+		return nil, url, err
 	}
 	defer res.Body.Close()
-	body, err := io.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK {
+		return nil, url, fmt.Errorf("unexpected status %s", res.Status)
+	}
+	content, err := readLimited(res.Body)
+	return content, res.Request.URL.String(), err
+}
+
+// readLimited reads r, failing if it is larger than MaxFetchSize.
+func readLimited(r io.Reader) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(r, MaxFetchSize+1))
 	if err != nil {
 		return nil, err
 	}
-	return body, nil
+	if int64(len(content)) > MaxFetchSize {
+		return nil, errors.New("playlist is larger than the maximum size")
+	}
+	return content, nil
 }
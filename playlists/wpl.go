@@ -0,0 +1,50 @@
+package playlists
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// wplPlaylist is a Windows Media Player playlist, a subset of SMIL, see
+// https://en.wikipedia.org/wiki/Windows_Media_Player_Playlist. Media
+// durations, when they are set, are in milliseconds.
+type wplPlaylist struct {
+	Media []struct {
+		Src         string `xml:"src,attr"`
+		TrackTitle  string `xml:"trackTitle,attr"`
+		TrackArtist string `xml:"trackArtist,attr"`
+		AlbumTitle  string `xml:"albumTitle,attr"`
+		AlbumArtist string `xml:"albumArtist,attr"`
+		Duration    string `xml:"duration,attr"`
+	} `xml:"body>seq>media"`
+}
+
+func parseWPL(uri string, content []byte) (Iterator, error) {
+	var pl wplPlaylist
+	d := xml.NewDecoder(bytes.NewReader(content))
+	d.Strict = false
+	if err := d.Decode(&pl); err != nil {
+		return nil, fmt.Errorf("failed to parse file %v: %w", uri, err)
+	}
+	var entries []Entry
+	for _, m := range pl.Media {
+		if m.Src == "" {
+			continue
+		}
+		artist := m.TrackArtist
+		if artist == "" {
+			artist = m.AlbumArtist
+		}
+		entries = append(entries, Entry{
+			// Paths are written with windows separators.
+			URL:      resolve(uri, strings.ReplaceAll(m.Src, "\\", "/")),
+			Title:    m.TrackTitle,
+			Duration: milliseconds(m.Duration),
+			Artist:   artist,
+			Album:    m.AlbumTitle,
+		})
+	}
+	return &entryIterator{entries: entries}, nil
+}
@@ -0,0 +1,172 @@
+package playlists
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// writers are the playlist formats that can be written, by their name.
+var writers = map[string]func(w io.Writer, entries []Entry) error{
+	"m3u":  writeM3U,
+	"pls":  writePLS,
+	"xspf": writeXSPF,
+}
+
+// WriteFormats are the names of the playlist formats that can be written.
+var WriteFormats = []string{"m3u", "pls", "xspf"}
+
+// FormatFor returns the name of the playlist format for the file
+// extension of path, or an empty string if the format isn't known.
+func FormatFor(path string) string {
+	return extensions[extension(path)]
+}
+
+// Write writes the entries to w as a playlist in the named format, one of
+// WriteFormats.
+func Write(w io.Writer, format string, entries []Entry) error {
+	write, ok := writers[format]
+	if !ok {
+		return fmt.Errorf("unable to write %q playlists, expected one of %s", format, strings.Join(WriteFormats, ", "))
+	}
+	return write(w, entries)
+}
+
+// ReadAll returns every entry of the playlist at uri.
+func ReadAll(uri string) ([]Entry, error) {
+	it, err := NewIterator(uri)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for it.HasNext() {
+		entries = append(entries, it.Next())
+	}
+	return entries, nil
+}
+
+// writeM3U writes an extended M3U playlist. #EXTART, #EXTALB, #EXTIMG and
+// #EXTGRP are only written when they change, as they apply to every
+// following entry.
+func writeM3U(w io.Writer, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "#EXTM3U")
+	var shared Entry
+	for _, e := range entries {
+		for _, d := range []struct {
+			directive   string
+			value, prev string
+		}{
+			{"#EXTART", e.Artist, shared.Artist},
+			{"#EXTALB", e.Album, shared.Album},
+			{"#EXTIMG", e.Image, shared.Image},
+			{"#EXTGRP", e.Group, shared.Group},
+		} {
+			if d.value != d.prev {
+				fmt.Fprintf(bw, "%s:%s\n", d.directive, d.value)
+			}
+		}
+		shared = e
+
+		duration := "-1"
+		if e.Duration > 0 {
+			duration = fmt.Sprint(int64((e.Duration + time.Second/2) / time.Second))
+		}
+		keys := make([]string, 0, len(e.Attributes))
+		for k := range e.Attributes {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			duration += fmt.Sprintf(` %s="%s"`, k, strings.ReplaceAll(e.Attributes[k], `"`, "'"))
+		}
+		fmt.Fprintf(bw, "#EXTINF:%s,%s\n", duration, e.Title)
+		fmt.Fprintln(bw, e.URL)
+	}
+	return bw.Flush()
+}
+
+func writePLS(w io.Writer, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "[playlist]")
+	for i, e := range entries {
+		fmt.Fprintf(bw, "File%d=%s\n", i+1, e.URL)
+		if e.Title != "" {
+			fmt.Fprintf(bw, "Title%d=%s\n", i+1, e.Title)
+		}
+		length := int64(-1)
+		if e.Duration > 0 {
+			length = int64((e.Duration + time.Second/2) / time.Second)
+		}
+		fmt.Fprintf(bw, "Length%d=%d\n", i+1, length)
+	}
+	fmt.Fprintf(bw, "NumberOfEntries=%d\n", len(entries))
+	fmt.Fprintln(bw, "Version=2")
+	return bw.Flush()
+}
+
+type xspfWriteTrack struct {
+	Location   string `xml:"location"`
+	Title      string `xml:"title,omitempty"`
+	Creator    string `xml:"creator,omitempty"`
+	Album      string `xml:"album,omitempty"`
+	Image      string `xml:"image,omitempty"`
+	Duration   int64  `xml:"duration,omitempty"`
+	Annotation string `xml:"annotation,omitempty"`
+	Info       string `xml:"info,omitempty"`
+}
+
+type xspfWritePlaylist struct {
+	XMLName xml.Name         `xml:"http://xspf.org/ns/0/ playlist"`
+	Version int              `xml:"version,attr"`
+	Tracks  []xspfWriteTrack `xml:"trackList>track"`
+}
+
+func writeXSPF(w io.Writer, entries []Entry) error {
+	pl := xspfWritePlaylist{Version: 1, Tracks: make([]xspfWriteTrack, len(entries))}
+	for i, e := range entries {
+		pl.Tracks[i] = xspfWriteTrack{
+			Location:   toURI(e.URL),
+			Title:      e.Title,
+			Creator:    e.Artist,
+			Album:      e.Album,
+			Image:      toURI(e.Image),
+			Duration:   e.Duration.Milliseconds(),
+			Annotation: e.Attributes["annotation"],
+			Info:       e.Attributes["info"],
+		}
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(pl); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// toURI returns a file:// url for a local path, XSPF locations have to be
+// URIs.
+func toURI(location string) string {
+	if location == "" || hasScheme(location) {
+		return location
+	}
+	if abs, err := filepath.Abs(location); err == nil {
+		location = abs
+	}
+	location = filepath.ToSlash(location)
+	if !strings.HasPrefix(location, "/") {
+		// ie: a windows drive letter.
+		location = "/" + location
+	}
+	return (&url.URL{Scheme: "file", Path: location}).String()
+}
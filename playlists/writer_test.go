@@ -0,0 +1,69 @@
+package playlists
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWriteRoundTrip(t *testing.T) {
+	local, err := filepath.Abs(filepath.Join("testdata", "music", "01 First Song.flac"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []Entry{
+		{
+			URL:        local,
+			Title:      "Artist - First Song",
+			Duration:   241 * time.Second,
+			Artist:     "Artist",
+			Album:      "Album",
+			Attributes: map[string]string{"annotation": "Remastered"},
+		},
+		{
+			URL:      "http://radio.example.com/live?format=mp3&bitrate=128",
+			Title:    "Live, Loud & Ünïcödé",
+			Duration: 0,
+		},
+	}
+
+	tests := []struct {
+		format string
+		want   []Entry
+	}{
+		{"m3u", entries},
+		{"xspf", entries},
+		{"pls", []Entry{
+			{URL: entries[0].URL, Title: entries[0].Title, Duration: entries[0].Duration},
+			{URL: entries[1].URL, Title: entries[1].Title},
+		}},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := Write(&buf, test.format, entries); err != nil {
+			t.Fatalf("%s: %v", test.format, err)
+		}
+		if format := Sniff(buf.Bytes()); format != test.format {
+			t.Fatalf("%s: sniffed written playlist as %q", test.format, format)
+		}
+		it, err := parsers[test.format]("file:///playlist", buf.Bytes())
+		if err != nil {
+			t.Fatalf("%s: %v", test.format, err)
+		}
+		var have []Entry
+		for it.HasNext() {
+			have = append(have, it.Next())
+		}
+		if !reflect.DeepEqual(have, test.want) {
+			t.Fatalf("%s: have entries\n%+v\nwant\n%+v\nfrom\n%s", test.format, have, test.want, buf.String())
+		}
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, "asx", nil); err == nil {
+		t.Fatal("expected an error writing asx")
+	}
+}
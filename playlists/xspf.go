@@ -0,0 +1,66 @@
+package playlists
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// xspfPlaylist is an XML Shareable Playlist Format playlist, see
+// https://www.xspf.org/spec. Track durations are in milliseconds.
+type xspfPlaylist struct {
+	Tracks []struct {
+		Locations  []string `xml:"location"`
+		Title      string   `xml:"title"`
+		Creator    string   `xml:"creator"`
+		Album      string   `xml:"album"`
+		Image      string   `xml:"image"`
+		Duration   string   `xml:"duration"`
+		Annotation string   `xml:"annotation"`
+		Info       string   `xml:"info"`
+	} `xml:"trackList>track"`
+}
+
+func parseXSPF(uri string, content []byte) (Iterator, error) {
+	var pl xspfPlaylist
+	if err := xml.Unmarshal(content, &pl); err != nil {
+		return nil, fmt.Errorf("failed to parse file %v: %w", uri, err)
+	}
+	var entries []Entry
+	for _, t := range pl.Tracks {
+		// A track can have several locations for the same media, only
+		// the first is played.
+		if len(t.Locations) == 0 {
+			continue
+		}
+		e := Entry{
+			URL:      resolveURI(uri, strings.TrimSpace(t.Locations[0])),
+			Title:    strings.TrimSpace(t.Title),
+			Duration: milliseconds(t.Duration),
+			Artist:   strings.TrimSpace(t.Creator),
+			Album:    strings.TrimSpace(t.Album),
+			Image:    resolveURI(uri, strings.TrimSpace(t.Image)),
+		}
+		for k, v := range map[string]string{"annotation": t.Annotation, "info": t.Info} {
+			if v = strings.TrimSpace(v); v != "" {
+				if e.Attributes == nil {
+					e.Attributes = map[string]string{}
+				}
+				e.Attributes[k] = v
+			}
+		}
+		entries = append(entries, e)
+	}
+	return &entryIterator{entries: entries}, nil
+}
+
+// milliseconds returns the duration of a playlist length in milliseconds.
+func milliseconds(length string) time.Duration {
+	ms, err := strconv.ParseInt(strings.TrimSpace(length), 10, 64)
+	if err != nil || ms <= 0 {
+		return 0
+	}
+	return time.Duration(ms) * time.Millisecond
+}
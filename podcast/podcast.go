@@ -0,0 +1,327 @@
+package podcast
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// FetchTimeout is how long fetching a remote feed can take.
+	FetchTimeout = 30 * time.Second
+	// MaxFeedSize is the largest feed that will be read, feeds with every
+	// episode ever published can be large.
+	MaxFeedSize int64 = 32 << 20
+)
+
+// Feed is a podcast.
+type Feed struct {
+	Title  string
+	Author string
+	// Image is the url of the artwork of the podcast.
+	Image string
+	// Episodes are the episodes that have media, newest first.
+	Episodes []Episode
+}
+
+// Episode is an episode of a podcast.
+type Episode struct {
+	Title     string
+	Published time.Time
+	// Duration is zero when the feed doesn't give it.
+	Duration time.Duration
+	// URL and Type are the url and content type of the media of the
+	// episode.
+	URL  string
+	Type string
+	// Image is the url of the artwork of the episode, or of the podcast
+	// when the episode has none.
+	Image string
+}
+
+// rssFeed is an RSS 2.0 feed, see https://www.rssboard.org/rss-specification,
+// with the iTunes podcast extensions most podcasts use for artwork and
+// durations.
+type rssFeed struct {
+	Channel struct {
+		Title  string     `xml:"title"`
+		Author string     `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd author"`
+		Images []rssImage `xml:"image"`
+		Items  []struct {
+			Titles    []string   `xml:"title"`
+			PubDate   string     `xml:"pubDate"`
+			Duration  string     `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+			Images    []rssImage `xml:"image"`
+			Enclosure struct {
+				URL  string `xml:"url,attr"`
+				Type string `xml:"type,attr"`
+			} `xml:"enclosure"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+// rssImage is either the image of an RSS channel, or the image of the
+// iTunes extensions, which is in its href.
+type rssImage struct {
+	URL  string `xml:"url"`
+	Href string `xml:"href,attr"`
+}
+
+// atomFeed is an Atom feed, see https://www.rfc-editor.org/rfc/rfc4287.
+type atomFeed struct {
+	Title   string     `xml:"title"`
+	Author  string     `xml:"author>name"`
+	Logo    string     `xml:"logo"`
+	Icon    string     `xml:"icon"`
+	Images  []rssImage `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+	Entries []struct {
+		Title     string     `xml:"title"`
+		Published string     `xml:"published"`
+		Updated   string     `xml:"updated"`
+		Duration  string     `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+		Images    []rssImage `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+		Links     []struct {
+			Rel  string `xml:"rel,attr"`
+			Href string `xml:"href,attr"`
+			Type string `xml:"type,attr"`
+		} `xml:"link"`
+	} `xml:"entry"`
+}
+
+// Load reads the feed at uri, a url or a path to a local file.
+func Load(uri string) (*Feed, error) {
+	content, err := fetch(uri)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read feed %v: %w", uri, err)
+	}
+	return Parse(uri, content)
+}
+
+func fetch(uri string) ([]byte, error) {
+	if !strings.HasPrefix(uri, "http://") && !strings.HasPrefix(uri, "https://") {
+		f, err := os.Open(strings.TrimPrefix(uri, "file://"))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return readLimited(f)
+	}
+
+	client := &http.Client{Timeout: FetchTimeout}
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "go-chromecast")
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", res.Status)
+	}
+	return readLimited(res.Body)
+}
+
+// readLimited reads r, failing if it is larger than MaxFeedSize.
+func readLimited(r io.Reader) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(r, MaxFeedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > MaxFeedSize {
+		return nil, errors.New("feed is larger than the maximum size")
+	}
+	return content, nil
+}
+
+// Parse parses an RSS or Atom feed read from uri, which relative urls in
+// the feed are resolved against.
+func Parse(uri string, content []byte) (*Feed, error) {
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(content, &root); err != nil {
+		return nil, fmt.Errorf("unable to parse feed %v: %w", uri, err)
+	}
+
+	var feed *Feed
+	var err error
+	switch root.XMLName.Local {
+	case "rss":
+		feed, err = parseRSS(uri, content)
+	case "feed":
+		feed, err = parseAtom(uri, content)
+	default:
+		return nil, fmt.Errorf("%v isn't an RSS or Atom feed", uri)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse feed %v: %w", uri, err)
+	}
+	// Episodes without a date go after the ones with one.
+	slices.SortStableFunc(feed.Episodes, func(a, b Episode) int {
+		return b.Published.Compare(a.Published)
+	})
+	return feed, nil
+}
+
+func parseRSS(uri string, content []byte) (*Feed, error) {
+	var rss rssFeed
+	if err := xml.Unmarshal(content, &rss); err != nil {
+		return nil, err
+	}
+	c := rss.Channel
+	feed := &Feed{
+		Title:  strings.TrimSpace(c.Title),
+		Author: strings.TrimSpace(c.Author),
+		Image:  resolve(uri, image(c.Images)),
+	}
+	for _, item := range c.Items {
+		if item.Enclosure.URL == "" {
+			continue
+		}
+		ep := Episode{
+			Published: parseDate(item.PubDate),
+			Duration:  parseDuration(item.Duration),
+			URL:       resolve(uri, strings.TrimSpace(item.Enclosure.URL)),
+			Type:      strings.TrimSpace(item.Enclosure.Type),
+			Image:     resolve(uri, image(item.Images)),
+		}
+		// Items can have an itunes:title as well as a title.
+		for _, t := range item.Titles {
+			if ep.Title = strings.TrimSpace(t); ep.Title != "" {
+				break
+			}
+		}
+		if ep.Image == "" {
+			ep.Image = feed.Image
+		}
+		feed.Episodes = append(feed.Episodes, ep)
+	}
+	return feed, nil
+}
+
+func parseAtom(uri string, content []byte) (*Feed, error) {
+	var atom atomFeed
+	if err := xml.Unmarshal(content, &atom); err != nil {
+		return nil, err
+	}
+	feed := &Feed{
+		Title:  strings.TrimSpace(atom.Title),
+		Author: strings.TrimSpace(atom.Author),
+		Image:  image(atom.Images),
+	}
+	for _, img := range []string{atom.Logo, atom.Icon} {
+		if feed.Image == "" {
+			feed.Image = strings.TrimSpace(img)
+		}
+	}
+	feed.Image = resolve(uri, feed.Image)
+	for _, entry := range atom.Entries {
+		ep := Episode{
+			Title:     strings.TrimSpace(entry.Title),
+			Published: parseDate(entry.Published),
+			Duration:  parseDuration(entry.Duration),
+			Image:     resolve(uri, image(entry.Images)),
+		}
+		if ep.Published.IsZero() {
+			ep.Published = parseDate(entry.Updated)
+		}
+		for _, link := range entry.Links {
+			if link.Rel == "enclosure" && link.Href != "" {
+				ep.URL = resolve(uri, strings.TrimSpace(link.Href))
+				ep.Type = strings.TrimSpace(link.Type)
+				break
+			}
+		}
+		if ep.URL == "" {
+			continue
+		}
+		if ep.Image == "" {
+			ep.Image = feed.Image
+		}
+		feed.Episodes = append(feed.Episodes, ep)
+	}
+	return feed, nil
+}
+
+// image returns the url of the first of the images, preferring the iTunes
+// ones as they are usually larger.
+func image(images []rssImage) string {
+	for _, img := range images {
+		if href := strings.TrimSpace(img.Href); href != "" {
+			return href
+		}
+	}
+	for _, img := range images {
+		if u := strings.TrimSpace(img.URL); u != "" {
+			return u
+		}
+	}
+	return ""
+}
+
+// dateFormats are the formats of dates in feeds. RSS uses RFC 822 dates,
+// which are often written a little differently, and Atom RFC 3339 ones.
+var dateFormats = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	time.RFC3339,
+	"2006-01-02",
+}
+
+// parseDate parses the date of an episode, returning the zero time when it
+// isn't recognised.
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, format := range dateFormats {
+		if t, err := time.Parse(format, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// parseDuration parses an itunes:duration, which is either seconds or
+// [hh:]mm:ss.
+func parseDuration(s string) time.Duration {
+	var seconds float64
+	for _, part := range strings.Split(strings.TrimSpace(s), ":") {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil || v < 0 {
+			return 0
+		}
+		seconds = seconds*60 + v
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// resolve resolves a url in the feed against the url of the feed.
+func resolve(base, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	r, err := url.Parse(ref)
+	if err != nil || r.IsAbs() {
+		return ref
+	}
+	b, err := url.Parse(base)
+	if err != nil || !b.IsAbs() {
+		return ref
+	}
+	return b.ResolveReference(r).String()
+}
@@ -0,0 +1,91 @@
+package podcast
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRSS(t *testing.T) {
+	assertions := require.New(t)
+	content, err := os.ReadFile("testdata/feed.xml")
+	assertions.NoError(err)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assertions.Equal("go-chromecast", r.UserAgent())
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	feed, err := Load(server.URL + "/podcasts/feed.xml")
+	assertions.NoError(err)
+	assertions.Equal("Lectures in Go", feed.Title)
+	assertions.Equal("Gopher University", feed.Author)
+	assertions.Equal("https://example.com/artwork.jpg", feed.Image)
+
+	assertions.Len(feed.Episodes, 3)
+	newest := feed.Episodes[0]
+	newest.Published = newest.Published.UTC()
+	assertions.Equal(Episode{
+		Title:     "Episode 3: Generics",
+		Published: time.Date(2026, 10, 21, 8, 0, 0, 0, time.UTC),
+		Duration:  time.Hour + 2*time.Minute + 3*time.Second,
+		URL:       "https://example.com/episodes/3.mp3",
+		Type:      "audio/mpeg",
+		Image:     "https://example.com/artwork.jpg",
+	}, newest)
+	assertions.Equal("Episode 2: Channels", feed.Episodes[1].Title)
+	assertions.Equal(45*time.Minute+30*time.Second, feed.Episodes[1].Duration)
+	assertions.Equal("https://example.com/episodes/2.jpg", feed.Episodes[1].Image)
+	// Relative urls are resolved against the feed.
+	assertions.Equal(server.URL+"/podcasts/episodes/1.mp3", feed.Episodes[2].URL)
+	assertions.Equal(time.Hour+2*time.Minute+5*time.Second, feed.Episodes[2].Duration)
+}
+
+func TestLoadAtom(t *testing.T) {
+	assertions := require.New(t)
+	feed, err := Load("testdata/atom.xml")
+	assertions.NoError(err)
+	assertions.Equal("Gopher Radio", feed.Title)
+	assertions.Equal("The Gophers", feed.Author)
+
+	assertions.Len(feed.Episodes, 2)
+	assertions.Equal("Newer", feed.Episodes[0].Title)
+	assertions.Equal("https://example.com/newer.mp3", feed.Episodes[0].URL)
+	assertions.Equal("https://example.com/logo.png", feed.Episodes[0].Image)
+	assertions.Equal("Older", feed.Episodes[1].Title)
+	assertions.Equal("audio/ogg", feed.Episodes[1].Type)
+	assertions.Equal(time.Date(2026, 9, 30, 10, 0, 0, 0, time.UTC), feed.Episodes[1].Published.UTC())
+}
+
+func TestLoadInvalid(t *testing.T) {
+	_, err := Parse("feed.xml", []byte("<html><body>hello</body></html>"))
+	require.ErrorContains(t, err, "feed.xml isn't an RSS or Atom feed")
+
+	_, err = Parse("feed.xml", []byte("not xml"))
+	require.ErrorContains(t, err, "unable to parse feed feed.xml")
+
+	_, err = Load("testdata/missing.xml")
+	require.ErrorContains(t, err, "unable to read feed testdata/missing.xml")
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	_, err = Load(server.URL)
+	require.ErrorContains(t, err, "unexpected status 404")
+}
+
+func TestParseDuration(t *testing.T) {
+	for s, want := range map[string]time.Duration{
+		"90":      90 * time.Second,
+		"01:30":   90 * time.Second,
+		"1:00:00": time.Hour,
+		"":        0,
+		"an hour": 0,
+		"2700.5":  2700*time.Second + 500*time.Millisecond,
+	} {
+		require.Equal(t, want, parseDuration(s), s)
+	}
+}
@@ -0,0 +1,87 @@
+package rules
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/vishen/go-chromecast/application"
+)
+
+// The actions a rule can run.
+const (
+	// ActionVolume sets the volume.
+	ActionVolume = "volume"
+	// ActionMute mutes the device.
+	ActionMute = "mute"
+	// ActionUnmute unmutes the device.
+	ActionUnmute = "unmute"
+	// ActionPause pauses the media.
+	ActionPause = "pause"
+	// ActionUnpause unpauses the media.
+	ActionUnpause = "unpause"
+	// ActionStopMedia stops the media, leaving the application running.
+	ActionStopMedia = "stop_media"
+	// ActionStop stops casting, closing the application.
+	ActionStop = "stop"
+)
+
+// Actions are the actions a rule can run.
+var Actions = []string{ActionVolume, ActionMute, ActionUnmute, ActionPause, ActionUnpause, ActionStopMedia, ActionStop}
+
+// Action is what a rule does to the device.
+type Action struct {
+	Action string   `yaml:"action"`
+	Volume *float32 `yaml:"volume"`
+}
+
+func (a *Action) init() error {
+	switch {
+	case a.Action == "":
+		return fmt.Errorf("missing action, one of: %s", strings.Join(Actions, ", "))
+	case !slices.Contains(Actions, a.Action):
+		return fmt.Errorf("unknown action %q, one of: %s", a.Action, strings.Join(Actions, ", "))
+	case a.Action == ActionVolume && a.Volume == nil:
+		return fmt.Errorf("volume needs a volume")
+	case a.Action != ActionVolume && a.Volume != nil:
+		return fmt.Errorf("only the volume action takes a volume")
+	case a.Volume != nil && (*a.Volume < 0 || *a.Volume > 1):
+		return fmt.Errorf("volume %v is out of range (0 - 1)", *a.Volume)
+	}
+	return nil
+}
+
+// String describes what the action does.
+func (a Action) String() string {
+	if a.Action == ActionVolume {
+		return fmt.Sprintf("volume to %.2f", *a.Volume)
+	}
+	return a.Action
+}
+
+// Do runs the action on the device.
+func (a Action) Do(app application.App) error {
+	// The media session the device has may have changed since the last
+	// update.
+	if err := app.Update(); err != nil {
+		return errors.Wrap(err, "unable to update device")
+	}
+	switch a.Action {
+	case ActionVolume:
+		return app.SetVolume(*a.Volume)
+	case ActionMute:
+		return app.SetMuted(true)
+	case ActionUnmute:
+		return app.SetMuted(false)
+	case ActionPause:
+		return app.Pause()
+	case ActionUnpause:
+		return app.Unpause()
+	case ActionStopMedia:
+		return app.StopMedia()
+	case ActionStop:
+		return app.Stop()
+	}
+	return errors.Errorf("unknown action %q", a.Action)
+}
@@ -0,0 +1,126 @@
+package rules
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vishen/go-chromecast/hooks"
+)
+
+// Firing is a rule running for an event.
+type Firing struct {
+	Rule  Rule
+	Event hooks.Event
+}
+
+// Engine runs rules against the events of devices. It is safe to use from
+// several goroutines.
+type Engine struct {
+	rules []Rule
+
+	mu      sync.Mutex
+	devices map[string]*deviceState
+}
+
+// deviceState is what the engine knows about a device.
+type deviceState struct {
+	last hooks.Event
+	// idleSince is when the device stopped playing, zero while it is
+	// playing.
+	idleSince time.Time
+	// fired are the idle rules that have run since the device stopped
+	// playing.
+	fired map[int]bool
+}
+
+// NewEngine returns an engine for the rules.
+func NewEngine(cfg *Config) *Engine {
+	return &Engine{rules: cfg.Rules, devices: map[string]*deviceState{}}
+}
+
+// Event updates the state of the device from the event, and returns the
+// rules that run for it, after any idle rules due by the time of the event.
+// Events without a type, ie: from hooks.Tracker.State, only update the
+// state.
+func (g *Engine) Event(e hooks.Event) []Firing {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	firings := g.advance(e.Time)
+
+	key := e.Device.Key()
+	if e.Type == hooks.EventDisconnect {
+		delete(g.devices, key)
+	} else {
+		st, ok := g.devices[key]
+		if !ok {
+			st = &deviceState{fired: map[int]bool{}}
+			g.devices[key] = st
+		}
+		st.last = e
+		if state := playerState(e); state == "PLAYING" || state == "BUFFERING" {
+			st.idleSince = time.Time{}
+			clear(st.fired)
+		} else if st.idleSince.IsZero() {
+			st.idleSince = e.Time
+		}
+	}
+
+	if e.Type == "" {
+		return firings
+	}
+	for _, r := range g.rules {
+		if r.When.Match(e) {
+			firings = append(firings, Firing{Rule: r, Event: e})
+		}
+	}
+	return firings
+}
+
+// Advance returns the idle rules due by t, in the order they became due.
+// Each idle rule runs once each time a device stops playing.
+func (g *Engine) Advance(t time.Time) []Firing {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.advance(t)
+}
+
+func (g *Engine) advance(t time.Time) []Firing {
+	var firings []Firing
+	for _, st := range g.devices {
+		if st.idleSince.IsZero() {
+			continue
+		}
+		for i, r := range g.rules {
+			due := st.idleSince.Add(r.When.IdleFor)
+			if r.When.IdleFor <= 0 || st.fired[i] || t.Before(due) {
+				continue
+			}
+			st.fired[i] = true
+			e := st.last
+			e.Type, e.Time = EventIdle, due
+			if r.When.Match(e) {
+				firings = append(firings, Firing{Rule: r, Event: e})
+			}
+		}
+	}
+	slices.SortStableFunc(firings, func(a, b Firing) int {
+		if c := a.Event.Time.Compare(b.Event.Time); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Event.Device.Name, b.Event.Device.Name)
+	})
+	return firings
+}
+
+// Replay runs the rules against a log of events, returning the rules that
+// would have run.
+func Replay(cfg *Config, events []hooks.Event) []Firing {
+	g := NewEngine(cfg)
+	var firings []Firing
+	for _, e := range events {
+		firings = append(firings, g.Event(e)...)
+	}
+	return firings
+}
@@ -0,0 +1,240 @@
+package rules
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/vishen/go-chromecast/hooks"
+)
+
+// EventIdle is the event of a rule with idle_for, sent once a device has
+// not been playing for that long.
+const EventIdle = "idle"
+
+// Config is a set of rules, kept in a yaml file.
+//
+//	rules:
+//	  - name: quiet youtube at night
+//	    when:
+//	      events: [media_start, volume_change]
+//	      devices: [Living room TV]
+//	      apps: [YouTube]
+//	      between: "22:00-07:00"
+//	      volume_above: 0.3
+//	    then:
+//	      action: volume
+//	      volume: 0.3
+//	  - name: kitchen idle
+//	    when:
+//	      devices: [Kitchen speaker]
+//	      idle_for: 10m
+//	    then:
+//	      action: stop
+type Config struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule runs an action on a device when an event from it meets the
+// conditions of the rule.
+type Rule struct {
+	Name string    `yaml:"name"`
+	When Condition `yaml:"when"`
+	Then Action    `yaml:"then"`
+}
+
+// Condition is what an event, and the state of the device after it, has to
+// be for a rule to run. Empty fields match everything.
+type Condition struct {
+	// Events are the types of event, see hooks.Events.
+	Events []string `yaml:"events"`
+	// Devices are the names or uuids of the devices.
+	Devices []string `yaml:"devices"`
+	// Apps are the ids or names of the application running on the device,
+	// ie: CC1AD845 or Default Media Receiver.
+	Apps []string `yaml:"apps"`
+	// PlayerStates are the states of the media, one of PLAYING, PAUSED,
+	// BUFFERING or IDLE, which is also the state when there is no media.
+	PlayerStates []string `yaml:"player_states"`
+	// Between is a time of day window, ie: "22:00-07:00".
+	Between     string   `yaml:"between"`
+	VolumeAbove *float32 `yaml:"volume_above"`
+	VolumeBelow *float32 `yaml:"volume_below"`
+	// IdleFor makes the rule run once the device hasn't been playing for
+	// this long, instead of on events.
+	IdleFor time.Duration `yaml:"idle_for"`
+
+	from, to time.Duration
+}
+
+// Load reads and checks a rules file.
+func Load(filename string) (*Config, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := yaml.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("unable to parse rules %q: %w", filename, err)
+	}
+	if err := cfg.init(); err != nil {
+		return nil, fmt.Errorf("rules %q: %w", filename, err)
+	}
+	return cfg, nil
+}
+
+// init checks the rules.
+func (c *Config) init() error {
+	if len(c.Rules) == 0 {
+		return fmt.Errorf("there are no rules")
+	}
+	for i := range c.Rules {
+		r := &c.Rules[i]
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if err := r.When.init(); err != nil {
+			return fmt.Errorf("%s: %w", r.Name, err)
+		}
+		if err := r.Then.init(); err != nil {
+			return fmt.Errorf("%s: %w", r.Name, err)
+		}
+	}
+	return nil
+}
+
+var playerStates = []string{"PLAYING", "PAUSED", "BUFFERING", "IDLE"}
+
+func (c *Condition) init() error {
+	for _, e := range c.Events {
+		if !slices.Contains(hooks.Events, e) {
+			return fmt.Errorf("unknown event %q, one of: %s", e, strings.Join(hooks.Events, ", "))
+		}
+	}
+	for i, s := range c.PlayerStates {
+		c.PlayerStates[i] = strings.ToUpper(s)
+		if !slices.Contains(playerStates, c.PlayerStates[i]) {
+			return fmt.Errorf("unknown player state %q, one of: %s", s, strings.Join(playerStates, ", "))
+		}
+	}
+	if c.Between != "" {
+		from, to, ok := strings.Cut(c.Between, "-")
+		var err error
+		if ok {
+			if c.from, err = timeOfDay(from); err == nil {
+				c.to, err = timeOfDay(to)
+			}
+		}
+		if !ok || err != nil {
+			return fmt.Errorf("between %q isn't a time window, ie: 22:00-07:00", c.Between)
+		}
+	}
+	for _, v := range []*float32{c.VolumeAbove, c.VolumeBelow} {
+		if v != nil && (*v < 0 || *v > 1) {
+			return fmt.Errorf("volume %v is out of range (0 - 1)", *v)
+		}
+	}
+	if c.IdleFor < 0 {
+		return fmt.Errorf("idle_for must not be negative")
+	}
+	if c.IdleFor > 0 && len(c.Events) > 0 {
+		return fmt.Errorf("idle_for can't be used with events")
+	}
+	return nil
+}
+
+// timeOfDay parses a time like 22:00 into the time since midnight.
+func timeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Match returns whether the event meets the condition. Rules with IdleFor
+// only match EventIdle.
+func (c Condition) Match(e hooks.Event) bool {
+	if c.IdleFor > 0 {
+		if e.Type != EventIdle {
+			return false
+		}
+	} else if e.Type == EventIdle || (len(c.Events) > 0 && !slices.Contains(c.Events, e.Type)) {
+		return false
+	}
+	if len(c.Devices) > 0 && !matchAny(c.Devices, e.Device.Name, e.Device.UUID) {
+		return false
+	}
+	if len(c.Apps) > 0 && (e.App == nil || !matchAny(c.Apps, e.App.ID, e.App.Name)) {
+		return false
+	}
+	if len(c.PlayerStates) > 0 && !slices.Contains(c.PlayerStates, playerState(e)) {
+		return false
+	}
+	if c.Between != "" && !c.within(e.Time) {
+		return false
+	}
+	if c.VolumeAbove != nil && (e.Volume == nil || e.Volume.Level <= *c.VolumeAbove) {
+		return false
+	}
+	if c.VolumeBelow != nil && (e.Volume == nil || e.Volume.Level >= *c.VolumeBelow) {
+		return false
+	}
+	return true
+}
+
+// within returns whether t, in local time, is in the time window. Windows
+// that end before they start go over midnight.
+func (c Condition) within(t time.Time) bool {
+	t = t.Local()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	d := t.Sub(midnight)
+	if c.from <= c.to {
+		return d >= c.from && d < c.to
+	}
+	return d >= c.from || d < c.to
+}
+
+func matchAny(patterns []string, values ...string) bool {
+	for _, p := range patterns {
+		for _, v := range values {
+			if v != "" && strings.EqualFold(p, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func playerState(e hooks.Event) string {
+	if e.Media == nil || e.Media.PlayerState == "" {
+		return "IDLE"
+	}
+	return e.Media.PlayerState
+}
+
+// ReadEvents reads a log of events, one JSON event a line, ie: as written
+// by a hook with the command "cat >> events.jsonl".
+func ReadEvents(r io.Reader) ([]hooks.Event, error) {
+	var events []hooks.Event
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		e := hooks.Event{}
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		events = append(events, e)
+	}
+	return events, scanner.Err()
+}
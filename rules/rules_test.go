@@ -0,0 +1,170 @@
+package rules
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vishen/go-chromecast/application/mocks"
+	"github.com/vishen/go-chromecast/hooks"
+)
+
+func volume(v float32) *float32 {
+	return &v
+}
+
+// utc makes local time UTC, so that time windows don't depend on where
+// the tests run.
+func utc(t *testing.T) {
+	local := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = local })
+}
+
+func TestLoad(t *testing.T) {
+	assertions := require.New(t)
+	cfg, err := Load(filepath.Join("testdata", "rules.yaml"))
+	assertions.NoError(err)
+
+	assertions.Len(cfg.Rules, 3)
+	quiet := cfg.Rules[0]
+	assertions.Equal([]string{"PLAYING"}, quiet.When.PlayerStates)
+	assertions.Equal(22*time.Hour, quiet.When.from)
+	assertions.Equal(7*time.Hour, quiet.When.to)
+	assertions.Equal("volume to 0.30", quiet.Then.String())
+	assertions.Equal(10*time.Minute, cfg.Rules[1].When.IdleFor)
+	assertions.Equal("rule 3", cfg.Rules[2].Name)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for content, want := range map[string]string{
+		"rules: []": "there are no rules",
+		"rules: [{then: {action: stop}, when: {events: [media_stop]}}]":               `rule 1: unknown event "media_stop"`,
+		"rules: [{then: {action: stop}, when: {player_states: [stopped]}}]":           `unknown player state "stopped"`,
+		"rules: [{then: {action: stop}, when: {between: '22:00'}}]":                   "isn't a time window",
+		"rules: [{then: {action: stop}, when: {between: '22:00-25:00'}}]":             "isn't a time window",
+		"rules: [{then: {action: stop}, when: {volume_above: 1.5}}]":                  "volume 1.5 is out of range",
+		"rules: [{then: {action: stop}, when: {idle_for: 1m, events: [app_change]}}]": "idle_for can't be used with events",
+		"rules: [{name: x, when: {}}]":                                                "x: missing action",
+		"rules: [{then: {action: skip}}]":                                             `unknown action "skip"`,
+		"rules: [{then: {action: volume}}]":                                           "volume needs a volume",
+		"rules: [{then: {action: mute, volume: 0.5}}]":                                "only the volume action takes a volume",
+		"rules: [{then: {action: volume, volume: -1}}]":                               "volume -1 is out of range",
+		"rules: [{then: {action: stop}, when: {idle_for: soon}}]":                     "unable to parse rules",
+	} {
+		filename := filepath.Join(t.TempDir(), "rules.yaml")
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+		_, err := Load(filename)
+		require.ErrorContains(t, err, want, content)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	utc(t)
+	assertions := require.New(t)
+	c := Condition{Between: "22:00-07:00", Apps: []string{"youtube"}, PlayerStates: []string{"IDLE"}}
+	assertions.NoError(c.init())
+
+	e := hooks.Event{
+		Type: hooks.EventAppChange,
+		Time: time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC),
+		App:  &hooks.App{ID: "233637DE", Name: "YouTube"},
+	}
+	assertions.True(c.Match(e))
+	e.Time = time.Date(2026, 10, 17, 6, 59, 0, 0, time.UTC)
+	assertions.True(c.Match(e))
+	e.Time = time.Date(2026, 10, 17, 7, 0, 0, 0, time.UTC)
+	assertions.False(c.Match(e))
+
+	e.Time = time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC)
+	e.Media = &hooks.Media{PlayerState: "PLAYING"}
+	assertions.False(c.Match(e))
+	e.Media, e.App = nil, nil
+	assertions.False(c.Match(e))
+
+	// Idle rules only match idle events, and other rules never do.
+	idle := Condition{IdleFor: time.Minute}
+	assertions.NoError(idle.init())
+	assertions.False(idle.Match(hooks.Event{Type: hooks.EventMediaPause}))
+	assertions.True(idle.Match(hooks.Event{Type: EventIdle}))
+	assertions.False(Condition{}.Match(hooks.Event{Type: EventIdle}))
+	assertions.True(Condition{}.Match(hooks.Event{Type: hooks.EventDisconnect}))
+}
+
+func TestReplay(t *testing.T) {
+	utc(t)
+	assertions := require.New(t)
+	cfg, err := Load(filepath.Join("testdata", "rules.yaml"))
+	assertions.NoError(err)
+	f, err := os.Open(filepath.Join("testdata", "events.jsonl"))
+	assertions.NoError(err)
+	defer f.Close()
+	events, err := ReadEvents(f)
+	assertions.NoError(err)
+	assertions.Len(events, 8)
+
+	var got []string
+	for _, f := range Replay(cfg, events) {
+		got = append(got, f.Event.Time.Format("15:04")+" "+f.Rule.Name+" "+f.Event.Device.Name)
+	}
+	assertions.Equal([]string{
+		"22:15 quiet youtube at night Living room TV",
+		"22:40 kitchen idle Kitchen speaker",
+	}, got)
+}
+
+func TestReadEventsInvalid(t *testing.T) {
+	_, err := ReadEvents(strings.NewReader("{\"type\":\"media_start\"}\nnope\n"))
+	require.ErrorContains(t, err, "line 2")
+}
+
+func TestIdle(t *testing.T) {
+	assertions := require.New(t)
+	cfg := &Config{Rules: []Rule{{When: Condition{IdleFor: 10 * time.Minute}, Then: Action{Action: ActionStop}}}}
+	assertions.NoError(cfg.init())
+	g := NewEngine(cfg)
+
+	start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	kitchen := hooks.Device{Name: "Kitchen speaker", UUID: "kitchen"}
+	// A device that is idle when it is first seen starts the timer.
+	assertions.Empty(g.Event(hooks.Event{Time: start, Device: kitchen}))
+	assertions.Empty(g.Advance(start.Add(9 * time.Minute)))
+	assertions.Len(g.Advance(start.Add(10*time.Minute)), 1)
+	assertions.Empty(g.Advance(start.Add(time.Hour)))
+
+	// Playing starts it again.
+	playing := &hooks.Media{PlayerState: "PLAYING"}
+	g.Event(hooks.Event{Type: hooks.EventMediaStart, Time: start.Add(time.Hour), Device: kitchen, Media: playing})
+	assertions.Empty(g.Advance(start.Add(2 * time.Hour)))
+	g.Event(hooks.Event{Type: hooks.EventMediaFinish, Time: start.Add(2 * time.Hour), Device: kitchen})
+	g.Event(hooks.Event{Type: hooks.EventMediaStart, Time: start.Add(2*time.Hour + 5*time.Minute), Device: kitchen, Media: playing})
+	assertions.Empty(g.Advance(start.Add(3 * time.Hour)))
+
+	// Devices that are disconnected are forgotten.
+	g.Event(hooks.Event{Type: hooks.EventMediaPause, Time: start.Add(3 * time.Hour), Device: kitchen})
+	g.Event(hooks.Event{Type: hooks.EventDisconnect, Time: start.Add(3 * time.Hour), Device: kitchen})
+	assertions.Empty(g.Advance(start.Add(4 * time.Hour)))
+}
+
+func TestActionDo(t *testing.T) {
+	assertions := require.New(t)
+
+	app := mocks.NewApp(t)
+	app.On("Update").Return(nil)
+	app.On("SetVolume", float32(0.3)).Return(nil).Once()
+	app.On("SetMuted", true).Return(nil).Once()
+	app.On("StopMedia").Return(nil).Once()
+	app.On("Stop").Return(nil).Once()
+	assertions.NoError(Action{Action: ActionVolume, Volume: volume(0.3)}.Do(app))
+	assertions.NoError(Action{Action: ActionMute}.Do(app))
+	assertions.NoError(Action{Action: ActionStopMedia}.Do(app))
+	assertions.NoError(Action{Action: ActionStop}.Do(app))
+
+	disconnected := mocks.NewApp(t)
+	disconnected.On("Update").Return(errors.New("EOF")).Once()
+	assertions.ErrorContains(Action{Action: ActionPause}.Do(disconnected), "unable to update device")
+}
@@ -0,0 +1,128 @@
+package schedule
+
+import (
+	"context"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/vishen/go-chromecast/application"
+	"github.com/vishen/go-chromecast/tts"
+)
+
+// speak creates the text-to-speech audio for a job.
+var speak = func(j Job) ([]byte, error) {
+	key, err := os.ReadFile(j.serviceAccount)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open google service account file")
+	}
+	languageCode, voiceName := j.LanguageCode, j.VoiceName
+	if languageCode == "" {
+		languageCode = "en-US"
+	}
+	if voiceName == "" {
+		voiceName = "en-US-Wavenet-G"
+	}
+	speakingRate, pitch := j.SpeakingRate, j.Pitch
+	if speakingRate == 0 {
+		speakingRate = 1
+	}
+	if pitch == 0 {
+		pitch = 1
+	}
+	return tts.Create(j.Text, key, languageCode, voiceName, speakingRate, pitch, j.SSML)
+}
+
+// Do runs the action of the job on a device.
+func (j Job) Do(ctx context.Context, app application.App) error {
+	switch j.Action {
+	case ActionLoad:
+		return j.load(ctx, app)
+	case ActionStop:
+		return app.Stop()
+	case ActionVolume:
+		from, err := currentVolume(app)
+		if err != nil {
+			return err
+		}
+		return application.FadeVolume(ctx, app, from, *j.Volume, j.FadeIn)
+	case ActionTTS:
+		return j.announce(app)
+	}
+	return errors.Errorf("unknown action %q", j.Action)
+}
+
+// load loads the media, starting it silent and fading it in if there is a
+// fade. The media keeps playing, and any local files being served, after
+// load returns.
+func (j Job) load(ctx context.Context, app application.App) error {
+	to := float32(-1)
+	if j.Volume != nil {
+		to = *j.Volume
+	}
+	if j.FadeIn > 0 {
+		if to < 0 {
+			var err error
+			if to, err = currentVolume(app); err != nil {
+				return err
+			}
+		}
+		if err := app.SetVolume(0); err != nil {
+			return errors.Wrap(err, "unable to set volume")
+		}
+	} else if to >= 0 {
+		if err := app.SetVolume(to); err != nil {
+			return errors.Wrap(err, "unable to set volume")
+		}
+	}
+
+	// The load doesn't wait for the media to finish, which would keep it
+	// running alongside the jobs after it. Local files are served for as
+	// long as the scheduler runs.
+	if err := app.Load(j.URL, 0, j.ContentType, true, false, true); err != nil {
+		return errors.Wrap(err, "unable to load media")
+	}
+
+	if j.FadeIn > 0 {
+		return application.FadeVolume(ctx, app, 0, to, j.FadeIn)
+	}
+	return nil
+}
+
+// announce plays the text-to-speech audio of the job, waiting until it
+// has been played.
+func (j Job) announce(app application.App) error {
+	data, err := speak(j)
+	if err != nil {
+		return errors.Wrap(err, "unable to create tts")
+	}
+	f, err := os.CreateTemp("", "go-chromecast-tts")
+	if err != nil {
+		return errors.Wrap(err, "unable to create temp file")
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return errors.Wrap(err, "unable to write to temp file")
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "unable to close temp file")
+	}
+
+	if j.Volume != nil {
+		if err := app.SetVolume(*j.Volume); err != nil {
+			return errors.Wrap(err, "unable to set volume")
+		}
+	}
+	return errors.Wrap(app.Load(f.Name(), 0, "audio/mp3", false, false, false), "unable to load media")
+}
+
+func currentVolume(app application.App) (float32, error) {
+	if err := app.Update(); err != nil {
+		return 0, errors.Wrap(err, "unable to update device")
+	}
+	_, _, volume := app.Status()
+	if volume == nil {
+		return 0, errors.New("unable to get the volume of the device")
+	}
+	return volume.Level, nil
+}
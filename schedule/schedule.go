@@ -0,0 +1,261 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// The actions a job can run.
+const (
+	// ActionLoad loads a url, local file or playlist, optionally at a
+	// volume that it fades in to.
+	ActionLoad = "load"
+	// ActionStop stops casting.
+	ActionStop = "stop"
+	// ActionVolume sets the volume, optionally fading to it.
+	ActionVolume = "volume"
+	// ActionTTS announces text with text-to-speech.
+	ActionTTS = "tts"
+)
+
+// Actions are the actions a job can run.
+var Actions = []string{ActionLoad, ActionStop, ActionVolume, ActionTTS}
+
+// parser parses standard cron expressions, with an optional seconds
+// field first, and descriptors like @daily. A timezone can be given with
+// a CRON_TZ= prefix, ie: "CRON_TZ=Europe/London 30 6 * * *".
+var parser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Config is a schedule of jobs, kept in a yaml file. Relative paths in the
+// file are relative to the directory it is in.
+//
+//	google_service_account: tts.json
+//	jobs:
+//	  - name: alarm
+//	    cron: "30 6 * * 1-5"
+//	    devices: [Bedroom speaker]
+//	    action: load
+//	    url: https://somafm.com/indiepop64.pls
+//	    volume: 0.4
+//	    fade_in: 10m
+//	  - name: bedtime
+//	    cron: "0 23 * * *"
+//	    devices: [tag:speakers]
+//	    action: stop
+type Config struct {
+	// GoogleServiceAccount is the google service account JSON file used
+	// for text-to-speech.
+	GoogleServiceAccount string `yaml:"google_service_account"`
+	Jobs                 []Job  `yaml:"jobs"`
+}
+
+// Job is an action run on devices at the times given by a cron expression.
+type Job struct {
+	Name string `yaml:"name"`
+	Cron string `yaml:"cron"`
+	// Devices are the selectors of the devices to run the action on, ie:
+	// 'Kitchen speaker' or 'tag:speakers'. When there are none, the
+	// devices picked by the command flags are used.
+	Devices []string `yaml:"devices"`
+	Action  string   `yaml:"action"`
+
+	// URL is the url, local file or playlist to load.
+	URL         string `yaml:"url"`
+	ContentType string `yaml:"content_type"`
+	// Volume is the volume, between 0 and 1, to set, or to load or
+	// announce at.
+	Volume *float32 `yaml:"volume"`
+	// FadeIn is how long to take to change the volume, loaded media
+	// starts silent and fades in.
+	FadeIn time.Duration `yaml:"fade_in"`
+
+	// Text is the text to announce, with LanguageCode and VoiceName
+	// picking the voice.
+	Text         string  `yaml:"text"`
+	LanguageCode string  `yaml:"language_code"`
+	VoiceName    string  `yaml:"voice_name"`
+	SpeakingRate float32 `yaml:"speaking_rate"`
+	Pitch        float32 `yaml:"pitch"`
+	SSML         bool    `yaml:"ssml"`
+
+	schedule       cron.Schedule
+	serviceAccount string
+}
+
+// Load reads and checks a schedule file.
+func Load(filename string) (*Config, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := yaml.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("unable to parse schedule %q: %w", filename, err)
+	}
+	if err := cfg.init(filepath.Dir(filename)); err != nil {
+		return nil, fmt.Errorf("schedule %q: %w", filename, err)
+	}
+	return cfg, nil
+}
+
+// init checks the jobs, parses their cron expressions and resolves paths
+// relative to dir.
+func (c *Config) init(dir string) error {
+	if len(c.Jobs) == 0 {
+		return fmt.Errorf("there are no jobs")
+	}
+	if c.GoogleServiceAccount != "" && !filepath.IsAbs(c.GoogleServiceAccount) {
+		c.GoogleServiceAccount = filepath.Join(dir, c.GoogleServiceAccount)
+	}
+	for i := range c.Jobs {
+		j := &c.Jobs[i]
+		if j.Name == "" {
+			j.Name = fmt.Sprintf("job %d", i+1)
+		}
+		if err := j.init(dir, c.GoogleServiceAccount); err != nil {
+			return fmt.Errorf("%s: %w", j.Name, err)
+		}
+	}
+	return nil
+}
+
+func (j *Job) init(dir, serviceAccount string) error {
+	var err error
+	if j.schedule, err = parser.Parse(j.Cron); err != nil {
+		return fmt.Errorf("invalid cron %q: %w", j.Cron, err)
+	}
+	if j.Volume != nil && (*j.Volume < 0 || *j.Volume > 1) {
+		return fmt.Errorf("volume %v is out of range (0 - 1)", *j.Volume)
+	}
+	if j.FadeIn < 0 {
+		return fmt.Errorf("fade_in must not be negative")
+	}
+
+	switch j.Action {
+	case ActionLoad:
+		if j.URL == "" {
+			return fmt.Errorf("load needs a url")
+		}
+		if !strings.Contains(j.URL, "://") && !filepath.IsAbs(j.URL) {
+			j.URL = filepath.Join(dir, j.URL)
+		}
+	case ActionStop:
+	case ActionVolume:
+		if j.Volume == nil {
+			return fmt.Errorf("volume needs a volume")
+		}
+	case ActionTTS:
+		if j.Text == "" {
+			return fmt.Errorf("tts needs text")
+		}
+		if serviceAccount == "" {
+			return fmt.Errorf("tts needs google_service_account")
+		}
+		j.serviceAccount = serviceAccount
+	case "":
+		return fmt.Errorf("missing action, one of: %s", strings.Join(Actions, ", "))
+	default:
+		return fmt.Errorf("unknown action %q, one of: %s", j.Action, strings.Join(Actions, ", "))
+	}
+	if j.FadeIn > 0 && j.Action != ActionLoad && j.Action != ActionVolume {
+		return fmt.Errorf("fade_in only works with load and volume")
+	}
+	return nil
+}
+
+// Next returns the next time the job runs after t.
+func (j Job) Next(t time.Time) time.Time {
+	return j.schedule.Next(t)
+}
+
+// String describes what the job does.
+func (j Job) String() string {
+	var s string
+	switch j.Action {
+	case ActionLoad:
+		s = "load " + j.URL
+	case ActionTTS:
+		s = fmt.Sprintf("announce %q", j.Text)
+	default:
+		s = j.Action
+	}
+	if j.Volume != nil {
+		if j.Action == ActionVolume {
+			s += fmt.Sprintf(" to %.2f", *j.Volume)
+		} else {
+			s += fmt.Sprintf(" at volume %.2f", *j.Volume)
+		}
+	}
+	if j.FadeIn > 0 {
+		s += fmt.Sprintf(", fading in over %s", j.FadeIn)
+	}
+	return s
+}
+
+// Firing is a time that a job runs.
+type Firing struct {
+	Time time.Time
+	Job  Job
+}
+
+// Upcoming returns the next n times that the jobs run after t, in order.
+// Jobs that run at the same time are in the order of the schedule.
+func (c *Config) Upcoming(t time.Time, n int) []Firing {
+	next := make([]time.Time, len(c.Jobs))
+	for i, j := range c.Jobs {
+		next[i] = j.Next(t)
+	}
+	var firings []Firing
+	for len(firings) < n {
+		first := -1
+		for i, at := range next {
+			if at.IsZero() {
+				continue
+			}
+			if first < 0 || at.Before(next[first]) {
+				first = i
+			}
+		}
+		if first < 0 {
+			break
+		}
+		firings = append(firings, Firing{Time: next[first], Job: c.Jobs[first]})
+		next[first] = c.Jobs[first].Next(next[first])
+	}
+	return firings
+}
+
+// Run calls fire with each job at the times it runs, until ctx is done.
+// Each job is fired in its own goroutine, so that a slow job doesn't hold
+// up the others.
+func (c *Config) Run(ctx context.Context, fire func(ctx context.Context, j Job)) error {
+	t := time.Now()
+	for {
+		upcoming := c.Upcoming(t, len(c.Jobs))
+		if len(upcoming) == 0 {
+			return fmt.Errorf("no jobs will run again")
+		}
+		t = upcoming[0].Time
+		// Every job that runs at the same time is fired together.
+		due := slices.DeleteFunc(upcoming, func(f Firing) bool { return !f.Time.Equal(t) })
+
+		timer := time.NewTimer(time.Until(t))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		for _, f := range due {
+			go fire(ctx, f.Job)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package schedule
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vishen/go-chromecast/application/mocks"
+	"github.com/vishen/go-chromecast/cast"
+)
+
+func volume(v float32) *float32 {
+	return &v
+}
+
+func TestLoad(t *testing.T) {
+	assertions := require.New(t)
+	cfg, err := Load(filepath.Join("testdata", "schedule.yaml"))
+	assertions.NoError(err)
+
+	assertions.Len(cfg.Jobs, 4)
+	alarm := cfg.Jobs[0]
+	assertions.Equal("alarm", alarm.Name)
+	assertions.Equal([]string{"Bedroom speaker"}, alarm.Devices)
+	assertions.Equal(float32(0.4), *alarm.Volume)
+	assertions.Equal(10*time.Minute, alarm.FadeIn)
+	assertions.Equal("load https://somafm.com/indiepop64.pls at volume 0.40, fading in over 10m0s", alarm.String())
+
+	assertions.Equal(filepath.Join("testdata", "music", "wake-up.m3u"), cfg.Jobs[1].URL)
+	assertions.Equal(filepath.Join("testdata", "tts.json"), cfg.Jobs[2].serviceAccount)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for content, want := range map[string]string{
+		"jobs: []":                                                  "there are no jobs",
+		"jobs: [{cron: '* * *', action: stop}]":                     "job 1: invalid cron",
+		"jobs: [{cron: '@daily'}]":                                  "job 1: missing action",
+		"jobs: [{name: x, cron: '@daily', action: go}]":             `x: unknown action "go"`,
+		"jobs: [{cron: '@daily', action: load}]":                    "load needs a url",
+		"jobs: [{cron: '@daily', action: volume}]":                  "volume needs a volume",
+		"jobs: [{cron: '@daily', action: volume, volume: 2}]":       "volume 2 is out of range",
+		"jobs: [{cron: '@daily', action: tts, text: hi}]":           "tts needs google_service_account",
+		"jobs: [{cron: '@daily', action: stop, fade_in: 1m}]":       "fade_in only works with load and volume",
+		"jobs: [{cron: '@daily', action: volume, fade_in: nope}]":   "unable to parse schedule",
+		"jobs: [{cron: '@daily', action: volume, volume: 0.5}]\n}}": "unable to parse schedule",
+	} {
+		filename := filepath.Join(t.TempDir(), "schedule.yaml")
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+		_, err := Load(filename)
+		require.ErrorContains(t, err, want, content)
+	}
+}
+
+func TestUpcoming(t *testing.T) {
+	assertions := require.New(t)
+	cfg := &Config{Jobs: []Job{
+		{Name: "weekdays", Cron: "30 6 * * 1-5", Action: ActionStop},
+		{Name: "daily", Cron: "30 6 * * *", Action: ActionStop},
+		{Name: "evening", Cron: "0 18 * * *", Action: ActionStop},
+	}}
+	assertions.NoError(cfg.init("."))
+
+	// A Friday.
+	from := time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+	var got []string
+	for _, f := range cfg.Upcoming(from, 5) {
+		got = append(got, f.Time.Format("Mon 15:04")+" "+f.Job.Name)
+	}
+	assertions.Equal([]string{
+		"Fri 18:00 evening",
+		"Sat 06:30 daily",
+		"Sat 18:00 evening",
+		"Sun 06:30 daily",
+		"Sun 18:00 evening",
+	}, got)
+
+	got = nil
+	for _, f := range cfg.Upcoming(from.AddDate(0, 0, 3), 2) {
+		got = append(got, f.Time.Format("Mon 15:04")+" "+f.Job.Name)
+	}
+	assertions.Equal([]string{"Mon 18:00 evening", "Tue 06:30 weekdays"}, got)
+}
+
+func TestRun(t *testing.T) {
+	assertions := require.New(t)
+	cfg := &Config{Jobs: []Job{
+		{Name: "every second", Cron: "* * * * * *", Action: ActionStop},
+		{Name: "also every second", Cron: "@every 1s", Action: ActionStop},
+	}}
+	assertions.NoError(cfg.init("."))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+	var fired atomic.Int32
+	err := cfg.Run(ctx, func(ctx context.Context, j Job) { fired.Add(1) })
+	assertions.ErrorIs(err, context.DeadlineExceeded)
+	assertions.GreaterOrEqual(fired.Load(), int32(2))
+}
+
+func TestDoLoadFadeIn(t *testing.T) {
+	assertions := require.New(t)
+	job := Job{Cron: "@daily", Action: ActionLoad, URL: "https://example.com/radio.mp3", Volume: volume(0.4), FadeIn: 50 * time.Millisecond}
+	assertions.NoError(job.init(".", ""))
+
+	var levels []float32
+	app := mocks.NewApp(t)
+	app.On("SetVolume", mock.Anything).Run(func(args mock.Arguments) {
+		levels = append(levels, args.Get(0).(float32))
+	}).Return(nil)
+	app.On("Load", job.URL, 0, "", true, false, true).Return(nil).Once()
+
+	assertions.NoError(job.Do(context.Background(), app))
+	assertions.Equal(float32(0), levels[0])
+	assertions.Equal(float32(0.4), levels[len(levels)-1])
+	for i := 1; i < len(levels); i++ {
+		assertions.GreaterOrEqual(levels[i], levels[i-1])
+	}
+}
+
+func TestDoLoadPlaying(t *testing.T) {
+	assertions := require.New(t)
+	job := Job{Cron: "@daily", Action: ActionLoad, URL: "/music/album.m3u"}
+	assertions.NoError(job.init(".", ""))
+
+	// Local media is loaded without waiting for it to finish playing, so
+	// the next job on the device doesn't run alongside the load.
+	app := mocks.NewApp(t)
+	app.On("Load", job.URL, 0, "", true, false, true).Return(nil).Once()
+	assertions.NoError(job.Do(context.Background(), app))
+}
+
+func TestDoLoadFailed(t *testing.T) {
+	assertions := require.New(t)
+	job := Job{Cron: "@daily", Action: ActionLoad, URL: "https://example.com/missing.mp3", Volume: volume(0.5)}
+	assertions.NoError(job.init(".", ""))
+
+	app := mocks.NewApp(t)
+	app.On("SetVolume", float32(0.5)).Return(nil).Once()
+	app.On("Load", job.URL, 0, "", true, false, true).Return(context.DeadlineExceeded).Once()
+	assertions.ErrorContains(job.Do(context.Background(), app), "unable to load media")
+}
+
+func TestDoVolume(t *testing.T) {
+	assertions := require.New(t)
+	job := Job{Cron: "@daily", Action: ActionVolume, Volume: volume(0.2)}
+	assertions.NoError(job.init(".", ""))
+
+	app := mocks.NewApp(t)
+	app.On("Update").Return(nil)
+	app.On("Status").Return(nil, nil, &cast.Volume{Level: 0.6})
+	app.On("SetVolume", float32(0.2)).Return(nil).Once()
+	assertions.NoError(job.Do(context.Background(), app))
+}
+
+func TestDoTTS(t *testing.T) {
+	assertions := require.New(t)
+	defer func(s func(Job) ([]byte, error)) { speak = s }(speak)
+	speak = func(j Job) ([]byte, error) {
+		return []byte("ID3 " + j.Text), nil
+	}
+
+	job := Job{Cron: "@daily", Action: ActionTTS, Text: "Dinner is ready", Volume: volume(0.6)}
+	assertions.NoError(job.init(".", "tts.json"))
+
+	app := mocks.NewApp(t)
+	app.On("SetVolume", float32(0.6)).Return(nil).Once()
+	app.On("Load", mock.MatchedBy(func(filename string) bool {
+		b, err := os.ReadFile(filename)
+		return err == nil && string(b) == "ID3 Dinner is ready"
+	}), 0, "audio/mp3", false, false, false).Return(nil).Once()
+	assertions.NoError(job.Do(context.Background(), app))
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltTimeout is how long to wait for another process to finish with
+// the database.
+const boltTimeout = 5 * time.Second
+
+// BoltStore is a store kept in an embedded bolt database. The database
+// is only opened for each operation, as bolt allows a single process to
+// have it open and there is often more than one go-chromecast running.
+type BoltStore struct {
+	filename string
+}
+
+func NewBoltStore(filename string) *BoltStore {
+	return &BoltStore{filename: filename}
+}
+
+func (s *BoltStore) open(readOnly bool) (*bolt.DB, error) {
+	if readOnly {
+		if _, err := os.Stat(s.filename); os.IsNotExist(err) {
+			return nil, nil
+		}
+	} else if err := os.MkdirAll(filepath.Dir(s.filename), 0755); err != nil {
+		return nil, errors.Wrap(err, "unable to create storage directory")
+	}
+	db, err := bolt.Open(s.filename, 0644, &bolt.Options{Timeout: boltTimeout, ReadOnly: readOnly})
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open %q", s.filename)
+	}
+	return db, nil
+}
+
+func (s *BoltStore) view(fn func(tx *bolt.Tx) error) error {
+	db, err := s.open(true)
+	if err != nil || db == nil {
+		return err
+	}
+	defer db.Close()
+	return db.View(fn)
+}
+
+func (s *BoltStore) update(fn func(tx *bolt.Tx) error) error {
+	db, err := s.open(false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Update(fn)
+}
+
+func (s *BoltStore) Load(bucket, key string) ([]byte, error) {
+	var value []byte
+	err := s.view(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(bucket)); b != nil {
+			value = slices.Clone(b.Get([]byte(key)))
+		}
+		return nil
+	})
+	return value, err
+}
+
+func (s *BoltStore) Save(bucket, key string, value []byte) error {
+	return s.update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(key), value)
+	})
+}
+
+func (s *BoltStore) Delete(bucket, key string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(bucket)); b != nil {
+			return b.Delete([]byte(key))
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) Keys(bucket, prefix string) ([]string, error) {
+	var keys []string
+	err := s.view(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		for k, _ := c.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, _ = c.Next() {
+			keys = append(keys, string(k))
+		}
+		return nil
+	})
+	return keys, err
+}
+
+func (s *BoltStore) Close() error { return nil }
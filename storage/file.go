@@ -0,0 +1,321 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+)
+
+// legacyCachePaths are where the cache file was kept, relative to the
+// home directory, before it moved to the cache directory.
+var legacyCachePaths = []string{
+	".config/gochromecast",
+	".gochromecast",
+}
+
+// schemaVersion is the version of the store file written. Version 0 is
+// the original flat json object of key to base64 encoded value, version 1
+// added a version with the same flat items, and version 2 put the items
+// into buckets.
+const schemaVersion = 2
+
+// storeFile is the versioned store file format.
+type storeFile struct {
+	Version int                          `json:"version"`
+	Buckets map[string]map[string][]byte `json:"buckets,omitempty"`
+	// Items are the flat items from version 1.
+	Items map[string][]byte `json:"items,omitempty"`
+}
+
+// FileStore is a store persisted to a json file. It can be shared between
+// several go-chromecast processes: every write locks the file, merges into
+// what is on disk and atomically replaces it, and reads reload the file
+// when another process has changed it.
+type FileStore struct {
+	mu       sync.Mutex
+	buckets  map[string]map[string][]byte
+	filename string
+
+	// The file when it was last read, to know when another process has
+	// written to it. As writes replace the file it is enough to compare
+	// the file along with its modification time and size.
+	info os.FileInfo
+}
+
+// NewFileStore returns a store persisted to filename. If filename is
+// empty, store.json in CacheDir is used.
+func NewFileStore(filename string) *FileStore {
+	return &FileStore{buckets: map[string]map[string][]byte{}, filename: filename}
+}
+
+func (s *FileStore) lazyLoadFilename() error {
+	if s.filename != "" {
+		return nil
+	}
+	dir, err := CacheDir()
+	if err != nil {
+		return errors.Wrap(err, "unable to find cache directory")
+	}
+	s.filename = filepath.Join(dir, "store.json")
+	return nil
+}
+
+// reload reads the file if it has changed since it was last read. The
+// caller must hold s.mu.
+func (s *FileStore) reload() error {
+	fi, err := os.Stat(s.filename)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "unable to stat store file")
+	}
+	if fi != nil && s.info != nil && os.SameFile(fi, s.info) && fi.ModTime().Equal(s.info.ModTime()) && fi.Size() == s.info.Size() {
+		return nil
+	}
+	if fi == nil {
+		if s.info != nil {
+			// Nothing has been written yet, and the legacy file has
+			// already been read.
+			return nil
+		}
+		// There is nothing to lock until the first write.
+		return s.read()
+	}
+
+	unlock, err := lockFile(s.filename, false)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.read()
+}
+
+// read reads the file, the caller must hold s.mu and the file lock. Until
+// anything is written the legacy cache file is read instead.
+func (s *FileStore) read() error {
+	filename := s.filename
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		filename = legacyCacheFile()
+		if filename == "" {
+			s.buckets = map[string]map[string][]byte{}
+			return nil
+		}
+	}
+
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return errors.Wrap(err, "unable to read store file")
+	}
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return errors.Wrap(err, "unable to stat store file")
+	}
+	buckets, err := decode(b)
+	if err != nil {
+		return errors.Wrapf(err, "unable to parse store file %q", filename)
+	}
+	s.buckets = buckets
+	s.info = fi
+	return nil
+}
+
+// legacyCacheFile returns the cache file used by older versions, if there
+// is one.
+func legacyCacheFile() string {
+	homeDir, err := homedir.Dir()
+	if err != nil {
+		return ""
+	}
+	for _, p := range legacyCachePaths {
+		filename := filepath.Join(homeDir, p)
+		if fi, err := os.Stat(filename); err == nil && !fi.IsDir() {
+			return filename
+		}
+	}
+	return ""
+}
+
+// decode parses any version of the store file.
+func decode(b []byte) (map[string]map[string][]byte, error) {
+	if len(bytes.TrimSpace(b)) == 0 {
+		return map[string]map[string][]byte{}, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	var version int
+	if err := json.Unmarshal(fields["version"], &version); err != nil || (fields["items"] == nil && fields["buckets"] == nil) {
+		// Version 0, a flat object with no version.
+		items := map[string][]byte{}
+		if err := json.Unmarshal(b, &items); err != nil {
+			return nil, err
+		}
+		return migrateFlatItems(items), nil
+	}
+	if version > schemaVersion {
+		return nil, fmt.Errorf("store file version %d is newer than the supported version %d", version, schemaVersion)
+	}
+
+	f := storeFile{}
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+	if version < 2 {
+		return migrateFlatItems(f.Items), nil
+	}
+	if f.Buckets == nil {
+		f.Buckets = map[string]map[string][]byte{}
+	}
+	return f.Buckets, nil
+}
+
+// migrateFlatItems moves the items from before there were buckets into
+// the bucket they belong in.
+func migrateFlatItems(items map[string][]byte) map[string]map[string][]byte {
+	buckets := map[string]map[string][]byte{}
+	put := func(bucket, key string, value []byte) {
+		if buckets[bucket] == nil {
+			buckets[bucket] = map[string][]byte{}
+		}
+		buckets[bucket][key] = value
+	}
+	for key, value := range items {
+		switch {
+		case strings.HasPrefix(key, "cmd/utils/dns/"):
+			// Invalidated devices were saved as empty values.
+			if len(value) > 0 {
+				put(BucketDevices, strings.TrimPrefix(key, "cmd/utils/dns/"), value)
+			}
+		case key == "application":
+			// The played items were a single json object keyed by
+			// content id, they are now saved individually.
+			playedItems := map[string]json.RawMessage{}
+			if err := json.Unmarshal(value, &playedItems); err != nil {
+				put(BucketSettings, key, value)
+				continue
+			}
+			for contentID, item := range playedItems {
+				put(BucketPlayedItems, contentID, item)
+			}
+		default:
+			put(BucketSettings, key, value)
+		}
+	}
+	return buckets
+}
+
+// write atomically replaces the file, the caller must hold s.mu and the
+// exclusive file lock.
+func (s *FileStore) write() error {
+	b, err := json.Marshal(storeFile{Version: schemaVersion, Buckets: s.buckets})
+	if err != nil {
+		return errors.Wrap(err, "unable to encode store")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.filename), filepath.Base(s.filename)+".tmp-*")
+	if err != nil {
+		return errors.Wrap(err, "unable to create temporary store file")
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "unable to write store file")
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "unable to write store file")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "unable to write store file")
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return errors.Wrap(err, "unable to write store file")
+	}
+	if err := os.Rename(tmp.Name(), s.filename); err != nil {
+		return errors.Wrap(err, "unable to replace store file")
+	}
+
+	if fi, err := os.Stat(s.filename); err == nil {
+		s.info = fi
+	}
+	return nil
+}
+
+// modify applies fn to the latest buckets on disk and writes them back.
+func (s *FileStore) modify(fn func()) error {
+	if err := s.lazyLoadFilename(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.filename), 0755); err != nil {
+		return errors.Wrap(err, "unable to create store directory")
+	}
+	unlock, err := lockFile(s.filename, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Always merge into the latest file, so keys written by other
+	// processes aren't lost.
+	if err := s.read(); err != nil {
+		return err
+	}
+	fn()
+	return s.write()
+}
+
+func (s *FileStore) Save(bucket, key string, value []byte) error {
+	return s.modify(func() {
+		if s.buckets[bucket] == nil {
+			s.buckets[bucket] = map[string][]byte{}
+		}
+		s.buckets[bucket][key] = value
+	})
+}
+
+func (s *FileStore) Delete(bucket, key string) error {
+	return s.modify(func() {
+		delete(s.buckets[bucket], key)
+	})
+}
+
+func (s *FileStore) Load(bucket, key string) ([]byte, error) {
+	if err := s.lazyLoadFilename(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s.buckets[bucket][key], nil
+}
+
+func (s *FileStore) Keys(bucket, prefix string) ([]string, error) {
+	if err := s.lazyLoadFilename(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return sortedKeys(s.buckets[bucket], prefix), nil
+}
+
+func (s *FileStore) Close() error { return nil }
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestFileStoreMigratesFlatFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "store.json")
+	flat, _ := json.Marshal(map[string][]byte{
+		"application":          []byte(`{"/media/a.mp3":{"started":1}}`),
+		"cmd/utils/dns/Lab TV": []byte(`{"addr":"10.0.0.1"}`),
+		"cmd/utils/dns/Gone":   []byte{},
+		"other":                []byte("1"),
+	})
+	if err := os.WriteFile(filename, flat, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewFileStore(filename)
+	for _, tc := range []struct {
+		bucket, key, want string
+	}{
+		{BucketPlayedItems, "/media/a.mp3", `{"started":1}`},
+		{BucketDevices, "Lab TV", `{"addr":"10.0.0.1"}`},
+		{BucketSettings, "other", "1"},
+	} {
+		b, err := s.Load(tc.bucket, tc.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != tc.want {
+			t.Fatalf("have %q for %s/%s, want %q", b, tc.bucket, tc.key, tc.want)
+		}
+	}
+	if keys, _ := s.Keys(BucketDevices, ""); len(keys) != 1 {
+		t.Fatalf("have devices %v, invalidated devices should be dropped", keys)
+	}
+
+	if err := s.Save(BucketSettings, "new", []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := storeFile{}
+	if err := json.Unmarshal(contents, &f); err != nil {
+		t.Fatal(err)
+	}
+	if f.Version != schemaVersion || len(f.Buckets) != 3 || len(f.Items) != 0 {
+		t.Fatalf("file wasn't migrated: %s", contents)
+	}
+}
+
+func TestFileStoreMigratesVersion1(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "store.json")
+	v1, _ := json.Marshal(map[string]interface{}{
+		"version": 1,
+		"items": map[string][]byte{
+			"cmd/utils/dns/abc": []byte(`{"uuid":"abc"}`),
+		},
+	})
+	if err := os.WriteFile(filename, v1, 0644); err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewFileStore(filename).Load(BucketDevices, "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"uuid":"abc"}` {
+		t.Fatalf("have %q from a version 1 file", b)
+	}
+}
+
+func TestFileStoreNewerVersion(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "store.json")
+	if err := os.WriteFile(filename, []byte(`{"version": 99, "buckets": {}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileStore(filename).Load(BucketSettings, "key"); err == nil {
+		t.Fatal("expected an error loading a newer store file")
+	}
+}
+
+func TestFileStoreSharedBetweenInstances(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "store.json")
+
+	// Each instance stands in for a separate go-chromecast process.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s := NewFileStore(filename)
+			for j := 0; j < 10; j++ {
+				if err := s.Save(BucketSettings, fmt.Sprintf("key-%d-%d", i, j), []byte("value")); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	keys, err := NewFileStore(filename).Keys(BucketSettings, "key-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 100 {
+		t.Fatalf("have %d keys, want 100; writes were lost", len(keys))
+	}
+}
+
+func TestFileStoreReloadsOnChange(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "store.json")
+	reader := NewFileStore(filename)
+	if b, err := reader.Load(BucketSettings, "key"); err != nil || b != nil {
+		t.Fatalf("have %q, %v from an empty store", b, err)
+	}
+
+	writer := NewFileStore(filename)
+	if err := writer.Save(BucketSettings, "key", []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	b, err := reader.Load(BucketSettings, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "value" {
+		t.Fatalf("have %q, the change by another instance wasn't seen", b)
+	}
+
+	if err := writer.Delete(BucketSettings, "key"); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := reader.Load(BucketSettings, "key"); err != nil || b != nil {
+		t.Fatalf("have %q, %v after the key was deleted", b, err)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix && !windows
+
+package storage
+
+// lockFile is a no-op where there is no file locking, ie: wasm.
+func lockFile(filename string, exclusive bool) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,31 @@
+//go:build unix
+
+package storage
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// lockFile takes an advisory lock on a lock file next to filename. The
+// lock file is separate so it survives the cache file being replaced.
+func lockFile(filename string, exclusive bool) (func(), error) {
+	f, err := os.OpenFile(filename+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open cache lock file")
+	}
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "unable to lock cache file")
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,32 @@
+//go:build windows
+
+package storage
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/windows"
+)
+
+// lockFile takes an advisory lock on a lock file next to filename. The
+// lock file is separate so it survives the cache file being replaced.
+func lockFile(filename string, exclusive bool) (func(), error) {
+	f, err := os.OpenFile(filename+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open cache lock file")
+	}
+	var flags uint32
+	if exclusive {
+		flags = windows.LOCKFILE_EXCLUSIVE_LOCK
+	}
+	overlapped := &windows.Overlapped{}
+	if err := windows.LockFileEx(windows.Handle(f.Fd()), flags, 0, 1, 0, overlapped); err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "unable to lock cache file")
+	}
+	return func() {
+		windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, overlapped)
+		f.Close()
+	}, nil
+}
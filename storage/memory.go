@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// MemoryStore is a store that isn't persisted anywhere, for tests and for
+// when nothing should be written to disk.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]map[string][]byte
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]map[string][]byte{}}
+}
+
+func (s *MemoryStore) Load(bucket, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.buckets[bucket][key]), nil
+}
+
+func (s *MemoryStore) Save(bucket, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.buckets[bucket] == nil {
+		s.buckets[bucket] = map[string][]byte{}
+	}
+	s.buckets[bucket][key] = slices.Clone(value)
+	return nil
+}
+
+func (s *MemoryStore) Delete(bucket, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.buckets[bucket], key)
+	return nil
+}
+
+func (s *MemoryStore) Keys(bucket, prefix string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return sortedKeys(s.buckets[bucket], prefix), nil
+}
+
+func (s *MemoryStore) Close() error { return nil }
+
+func sortedKeys(items map[string][]byte, prefix string) []string {
+	var keys []string
+	for k := range items {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Buckets keep the different kinds of stored data apart.
+const (
+	BucketDevices     = "devices"
+	BucketPlayedItems = "played-items"
+	BucketSettings    = "settings"
+	// BucketPlaybackRates has the playback rate chosen for each device
+	// and playlist.
+	BucketPlaybackRates = "playback-rates"
+)
+
+// Store is a key value store with the keys namespaced into buckets.
+type Store interface {
+	// Load returns the value for the key, or nil if there isn't one.
+	Load(bucket, key string) ([]byte, error)
+	Save(bucket, key string, value []byte) error
+	Delete(bucket, key string) error
+	// Keys returns the sorted keys in the bucket that start with prefix.
+	Keys(bucket, prefix string) ([]string, error)
+	Close() error
+}
+
+// Backends are the names of the stores that Open can create.
+var Backends = []string{"file", "memory", "bolt"}
+
+// Open returns the named store, kept in dir. If dir is empty CacheDir is
+// used.
+func Open(backend, dir string) (Store, error) {
+	if backend == "memory" {
+		return NewMemoryStore(), nil
+	}
+	if dir == "" {
+		var err error
+		if dir, err = CacheDir(); err != nil {
+			return nil, err
+		}
+	}
+	switch backend {
+	case "", "file":
+		return NewFileStore(filepath.Join(dir, "store.json")), nil
+	case "bolt":
+		return NewBoltStore(filepath.Join(dir, "store.db")), nil
+	}
+	return nil, fmt.Errorf("unknown storage backend %q, expected one of %v", backend, Backends)
+}
+
+// CacheDir returns the directory go-chromecast keeps its cache in, which
+// is go-chromecast in $XDG_CACHE_HOME or the platform's cache directory.
+func CacheDir() (string, error) {
+	return userDir("XDG_CACHE_HOME", os.UserCacheDir)
+}
+
+// ConfigDir returns the directory go-chromecast looks for config files in,
+// which is go-chromecast in $XDG_CONFIG_HOME or the platform's config
+// directory.
+func ConfigDir() (string, error) {
+	return userDir("XDG_CONFIG_HOME", os.UserConfigDir)
+}
+
+func userDir(env string, platformDir func() (string, error)) (string, error) {
+	dir := os.Getenv(env)
+	if dir == "" {
+		var err error
+		if dir, err = platformDir(); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(dir, "go-chromecast"), nil
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStores(t *testing.T) {
+	dir := t.TempDir()
+	for _, backend := range Backends {
+		t.Run(backend, func(t *testing.T) {
+			s, err := Open(backend, filepath.Join(dir, backend))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+
+			if b, err := s.Load(BucketDevices, "missing"); err != nil || b != nil {
+				t.Fatalf("have %q, %v for a missing key", b, err)
+			}
+			for _, key := range []string{"b", "a", "c/1", "c/2"} {
+				if err := s.Save(BucketDevices, key, []byte(key)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := s.Save(BucketSettings, "a", []byte("setting")); err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := s.Load(BucketDevices, "a")
+			if err != nil || string(b) != "a" {
+				t.Fatalf("have %q, %v, want the device", b, err)
+			}
+			keys, err := s.Keys(BucketDevices, "")
+			if err != nil || !reflect.DeepEqual(keys, []string{"a", "b", "c/1", "c/2"}) {
+				t.Fatalf("have keys %v, %v", keys, err)
+			}
+			keys, err = s.Keys(BucketDevices, "c/")
+			if err != nil || !reflect.DeepEqual(keys, []string{"c/1", "c/2"}) {
+				t.Fatalf("have prefixed keys %v, %v", keys, err)
+			}
+
+			if err := s.Delete(BucketDevices, "a"); err != nil {
+				t.Fatal(err)
+			}
+			if b, err := s.Load(BucketDevices, "a"); err != nil || b != nil {
+				t.Fatalf("have %q, %v after delete", b, err)
+			}
+			if b, err := s.Load(BucketSettings, "a"); err != nil || string(b) != "setting" {
+				t.Fatalf("have %q, %v, buckets aren't separate", b, err)
+			}
+		})
+	}
+}
+
+func TestCacheDirHonoursXDG(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", "/tmp/xdg-cache")
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg-config")
+	if dir, _ := CacheDir(); dir != filepath.Join("/tmp/xdg-cache", "go-chromecast") {
+		t.Fatalf("have cache dir %q", dir)
+	}
+	if dir, _ := ConfigDir(); dir != filepath.Join("/tmp/xdg-config", "go-chromecast") {
+		t.Fatalf("have config dir %q", dir)
+	}
+}
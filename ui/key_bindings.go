@@ -1,6 +1,10 @@
 package ui
 
 import (
+	"context"
+	"math"
+	"time"
+
 	"github.com/vishen/go-chromecast/application"
 
 	"github.com/jroimartin/gocui"
@@ -21,8 +25,22 @@ func (ui *UserInterface) setupKeyBindings() {
 	ui.gui.SetKeybinding("", 'm', gocui.ModNone, ui.volumeMute)
 	ui.gui.SetKeybinding("", gocui.KeyPgup, gocui.ModNone, ui.previousMedia)
 	ui.gui.SetKeybinding("", gocui.KeyPgdn, gocui.ModNone, ui.nextMedia)
+	ui.gui.SetKeybinding("", 'z', gocui.ModNone, ui.sleep)
+	ui.gui.SetKeybinding("", '[', gocui.ModNone, ui.slower)
+	ui.gui.SetKeybinding("", ']', gocui.ModNone, ui.faster)
 }
 
+// playbackRates are the playback rates that the rate keys step through.
+var playbackRates = []float32{0.5, 0.75, 1, 1.25, 1.5, 1.75, 2}
+
+// sleepDurations are the sleep timers that the sleep key cycles through,
+// before turning the timer off again.
+var sleepDurations = []time.Duration{15 * time.Minute, 30 * time.Minute, time.Hour, 90 * time.Minute}
+
+// sleepFade is how long the volume fades out for before the sleep timer
+// stops the media.
+const sleepFade = 2 * time.Minute
+
 // playPause tells the app to play / pause:
 func (ui *UserInterface) playPause(g *gocui.Gui, v *gocui.View) error {
 	if ui.paused {
@@ -81,64 +99,98 @@ func (ui *UserInterface) seekForwards(g *gocui.Gui, v *gocui.View) error {
 	return nil
 }
 
-// volumeUp increases the volume:
+// volumeUp increases the volume by the step of the device:
 func (ui *UserInterface) volumeUp(g *gocui.Gui, v *gocui.View) error {
+	ui.stepVolume(1, "Volume up", "Volume already at maximum")
+	return nil
+}
+
+// volumeDown decreases the volume by the step of the device:
+func (ui *UserInterface) volumeDown(g *gocui.Gui, v *gocui.View) error {
+	ui.stepVolume(-1, "Volume down", "Volume already at minimum")
+	return nil
+}
+
+// stepVolume changes the volume by a step of the device in the direction,
+// keeping it within the limits of the device:
+func (ui *UserInterface) stepVolume(direction float32, action, atLimit string) {
 	ui.volumeMutex.Lock()
 	defer ui.volumeMutex.Unlock()
 
-	// Attempt to increment our version of the volume:
-	if ui.volume+5 > 100 {
-		log.Warn("Volume already at maximum")
-		return nil
+	// Attempt to change our version of the volume:
+	settings := ui.app.VolumeSettings()
+	floatVolume := settings.Clamp(float32(ui.volume)/100 + direction*settings.Step)
+	volume := int(math.Round(float64(floatVolume) * 100))
+	if volume == ui.volume {
+		log.Warn(atLimit)
+		return
 	}
-	ui.volume += 5
-
-	floatVolume := float32(ui.volume) / 100
+	ui.volume = volume
 
 	err := ui.app.SetVolume(floatVolume)
 	if err != nil {
 		switch err {
 		case application.ErrVolumeOutOfRange:
-			log.WithError(err).WithField("volume", floatVolume).Warn("Volume up")
-			return nil
+			log.WithError(err).WithField("volume", floatVolume).Warn(action)
 		default:
-			log.WithError(err).WithField("volume", floatVolume).Error("Volume up")
-			return nil
+			log.WithError(err).WithField("volume", floatVolume).Error(action)
 		}
+		return
 	}
 
-	log.WithField("volume", floatVolume).Info("Volume up")
+	log.WithField("volume", floatVolume).Info(action)
+}
+
+// slower decreases the playback rate to the next slower one:
+func (ui *UserInterface) slower(g *gocui.Gui, v *gocui.View) error {
+	ui.stepRate(-1, "Slower", "Playback rate already at minimum")
 	return nil
 }
 
-// volumeDown decreases the volume:
-func (ui *UserInterface) volumeDown(g *gocui.Gui, v *gocui.View) error {
-	ui.volumeMutex.Lock()
-	defer ui.volumeMutex.Unlock()
+// faster increases the playback rate to the next faster one:
+func (ui *UserInterface) faster(g *gocui.Gui, v *gocui.View) error {
+	ui.stepRate(1, "Faster", "Playback rate already at maximum")
+	return nil
+}
 
-	// Attempt to decrement our version of the volume:
-	if ui.volume-5 < 0 {
-		log.Warn("Volume already at minimum")
-		return nil
-	}
-	ui.volume -= 5
+// stepRate changes the playback rate to the next of playbackRates in the
+// direction:
+func (ui *UserInterface) stepRate(direction int, action, atLimit string) {
+	ui.rateMutex.Lock()
+	defer ui.rateMutex.Unlock()
 
-	floatVolume := float32(ui.volume) / 100
+	current := ui.rate
+	if current == 0 {
+		current = 1
+	}
+	rate := current
+	for i := range playbackRates {
+		if direction < 0 {
+			i = len(playbackRates) - 1 - i
+		}
+		if r := playbackRates[i]; (direction > 0 && r > current) || (direction < 0 && r < current) {
+			rate = r
+			break
+		}
+	}
+	if rate == current {
+		log.Warn(atLimit)
+		return
+	}
 
-	err := ui.app.SetVolume(floatVolume)
+	err := ui.app.SetPlaybackRate(rate)
 	if err != nil {
 		switch err {
-		case application.ErrVolumeOutOfRange:
-			log.WithError(err).WithField("volume", floatVolume).Warn("Volume down")
-			return nil
+		case application.ErrNoMediaPlaybackRate:
+			log.WithError(err).Warn(action)
 		default:
-			log.WithError(err).WithField("volume", floatVolume).Error("Volume down")
-			return nil
+			log.WithError(err).WithField("rate", rate).Error(action)
 		}
+		return
 	}
+	ui.rate = rate
 
-	log.WithField("volume", floatVolume).Info("Volume down")
-	return nil
+	log.WithField("rate", rate).Info(action)
 }
 
 // volumeMute mutes the volume:
@@ -216,3 +268,65 @@ func (ui *UserInterface) previousMedia(g *gocui.Gui, v *gocui.View) error {
 	log.Info("Previous")
 	return nil
 }
+
+// sleep starts a sleep timer, or moves it on to the next longer one, or
+// turns it off after the longest:
+func (ui *UserInterface) sleep(g *gocui.Gui, v *gocui.View) error {
+	ui.sleepMutex.Lock()
+	defer ui.sleepMutex.Unlock()
+
+	next := sleepDurations[0]
+	if ui.sleepTimer != nil {
+		remaining := ui.sleepTimer.Remaining()
+		ui.sleepTimer.Cancel()
+		ui.sleepTimer = nil
+
+		next = 0
+		for _, d := range sleepDurations {
+			if d > remaining+time.Minute {
+				next = d
+				break
+			}
+		}
+		if next == 0 {
+			log.Info("Sleep timer off")
+			return nil
+		}
+	}
+
+	timer, err := application.StartSleepTimer(ui.app, next, sleepFade)
+	if err != nil {
+		log.WithError(err).Error("Sleep")
+		return nil
+	}
+	ui.sleepTimer = timer
+	go func() {
+		<-timer.Done()
+		if err := timer.Err(); err != nil && err != context.Canceled {
+			log.WithError(err).Warn("Sleep timer")
+		} else if err == nil {
+			log.Info("Sleep timer stopped media")
+		}
+	}()
+
+	log.WithField("duration", next).Info("Sleep")
+	return nil
+}
+
+// sleepRemaining returns how long is left on the sleep timer, zero if
+// there isn't one:
+func (ui *UserInterface) sleepRemaining() time.Duration {
+	ui.sleepMutex.Lock()
+	defer ui.sleepMutex.Unlock()
+
+	if ui.sleepTimer == nil {
+		return 0
+	}
+	select {
+	case <-ui.sleepTimer.Done():
+		ui.sleepTimer = nil
+		return 0
+	default:
+		return ui.sleepTimer.Remaining()
+	}
+}
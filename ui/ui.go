@@ -21,8 +21,12 @@ type UserInterface struct {
 	paused          bool
 	positionCurrent float32
 	positionTotal   float32
+	rate            float32
+	rateMutex       sync.Mutex
 	seekFastforward int
 	seekRewind      int
+	sleepMutex      sync.Mutex
+	sleepTimer      *application.SleepTimer
 	volume          int
 	volumeMutex     sync.Mutex
 	wg              sync.WaitGroup
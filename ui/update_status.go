@@ -2,8 +2,11 @@ package ui
 
 import (
 	"fmt"
+	"math"
 	"time"
 
+	"github.com/vishen/go-chromecast/application"
+
 	"github.com/jroimartin/gocui"
 	log "github.com/sirupsen/logrus"
 )
@@ -50,6 +53,15 @@ func (ui *UserInterface) updateStatus(sleepTime time.Duration) {
 		} else {
 			ui.displayName = castApplication.DisplayName
 		}
+		ui.rateMutex.Lock()
+		ui.rate = application.MediaPlaybackRate(castMedia)
+		if ui.rate != 0 && ui.rate != 1 {
+			ui.displayName += fmt.Sprintf(", %gx", ui.rate)
+		}
+		ui.rateMutex.Unlock()
+		if remaining := ui.sleepRemaining(); remaining > 0 {
+			ui.displayName += fmt.Sprintf(", sleep in %s", remaining.Round(time.Second))
+		}
 
 		// Update the media info:
 		if castMedia != nil {
@@ -101,7 +113,7 @@ func (ui *UserInterface) updateStatus(sleepTime time.Duration) {
 
 		// Update the "volume" view:
 		if castVolume != nil {
-			ui.volume = int(castVolume.Level * 100)
+			ui.volume = int(math.Round(float64(castVolume.Level) * 100))
 			ui.muted = castVolume.Muted
 
 			viewVolume.Clear()
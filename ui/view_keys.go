@@ -27,6 +27,8 @@ func (ui *UserInterface) viewKeys(g *gocui.Gui) error {
 		fmt.Fprintf(v, "%s, Previous/Next: %sPgUp%s / %sPgDn", normalTextColour, boldTextColour, normalTextColour, boldTextColour)
 		fmt.Fprintf(v, "%s, Stop: %ss", normalTextColour, boldTextColour)
 		fmt.Fprintf(v, "%s, Skip Ad: %sa", normalTextColour, boldTextColour)
+		fmt.Fprintf(v, "%s, Sleep: %sz", normalTextColour, boldTextColour)
+		fmt.Fprintf(v, "%s, Rate: %s[%s / %s]", normalTextColour, boldTextColour, normalTextColour, boldTextColour)
 		fmt.Fprint(v, resetTextColour)
 	}
 	return nil